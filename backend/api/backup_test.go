@@ -0,0 +1,42 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/backup"
+	"blog/models"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.AdminClient()
+
+	var post models.Post
+	client.POST("/api/posts").JSON(map[string]any{"Title": "Backup", "Content": "# Body", "Tags": []map[string]string{{"Name": "go"}}}).Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&post)
+
+	srv.Client().GET("/api/admin/export").Do().ExpectStatus(http.StatusUnauthorized)
+	client.GET("/api/admin/export?format=rar").Do().ExpectStatus(http.StatusBadRequest)
+
+	for _, format := range []backup.ArchiveFormat{backup.Zip, backup.TarGz} {
+		resp := client.GET("/api/admin/export?format="+string(format)).Do().
+			ExpectStatus(http.StatusOK).
+			ExpectHeader("Content-Type", format.ContentType())
+		assert.True(t, strings.HasSuffix(resp.Header("Content-Disposition"), "."+string(format)+`"`), resp.Header("Content-Disposition"))
+
+		data, manifest, err := backup.Read([]byte(resp.Body()))
+		require.NoError(t, err)
+		assert.Equal(t, 1, manifest.Posts)
+		require.Len(t, data.Posts, 1)
+		assert.Equal(t, post.ID, data.Posts[0].ID)
+		assert.Equal(t, "# Body", data.Posts[0].Content)
+		require.Len(t, data.Posts[0].Tags, 1)
+		assert.Equal(t, "go", data.Posts[0].Tags[0].Name)
+	}
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"blog/cache"
+	"blog/config"
+	"blog/graph"
+	"blog/services"
+)
+
+// newGraphQLExecutor は設定に従って GraphQL の実行と永続化クエリの保存先を用意する
+func newGraphQLExecutor(service services.PostService, related services.RelatedPostService, cfg config.GraphQLConfig) (*graph.Executor, error) {
+	graphConfig := graph.Config{
+		MaxDepth:       cfg.MaxDepth,
+		MaxComplexity:  cfg.MaxComplexity,
+		PersistedOnly:  cfg.PersistedOnly,
+		PersistedStore: cache.NewMemory(cfg.PersistedMaxEntries),
+		PersistedTTL:   cfg.PersistedTTL,
+	}
+	if cfg.PersistedQueries != "" {
+		manifest, err := graph.LoadManifest(cfg.PersistedQueries)
+		if err != nil {
+			return nil, err
+		}
+		graphConfig.Manifest = manifest
+	}
+	return graph.NewExecutor(service, related, graphConfig)
+}
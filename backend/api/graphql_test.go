@@ -0,0 +1,140 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type graphqlResponse struct {
+	Data   json.RawMessage
+	Errors []struct {
+		Message    string
+		Extensions map[string]any
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	srv := apitest.NewServer(t)
+	posted := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	posts := srv.SeedPosts(
+		models.Post{Title: "Hello", Content: "# Body", Slug: "hello", Author: "alice", Tags: []models.Tag{{Name: "go"}}, CreatedAt: posted, UpdatedAt: posted},
+	)
+	srv.SeedPosts(
+		models.Post{Title: "Second", Content: "text", Author: "bob", Tags: []models.Tag{posts[0].Tags[0], {Name: "web"}}, CreatedAt: posted.AddDate(0, 0, 1), UpdatedAt: posted},
+	)
+	client := srv.Client()
+
+	var resp graphqlResponse
+	client.POST("/graphql").JSON(map[string]any{
+		"query": `query Page($slug: String) {
+			post(slug: $slug) { id title content createdAt author { name posts { title } } tags { name postCount } }
+			posts(tag: "web") { title }
+		}`,
+		"variables": map[string]any{"slug": "hello"},
+	}).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", "no-store").
+		DecodeJSON(&resp)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"post": {"id": "%d", "title": "Hello", "content": "# Body", "createdAt": "2024-03-05T09:00:00Z",
+			"author": {"name": "alice", "posts": [{"title": "Hello"}]}, "tags": [{"name": "go", "postCount": 2}]},
+		"posts": [{"title": "Second"}]
+	}`, posts[0].ID), string(resp.Data))
+
+	// 誤ったクエリと上限を超えるクエリは 200 の errors で返す
+	resp = graphqlResponse{}
+	client.POST("/graphql").JSON(map[string]any{"query": `{ posts { unknown } }`}).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&resp)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, `Cannot query field "unknown"`)
+
+	resp = graphqlResponse{}
+	client.POST("/graphql").JSON(map[string]any{"query": `{ post(id: "1") { related { post { related { post { related { post { author { name } } } } } } } } }`}).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&resp)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "QUERY_TOO_DEEP", resp.Errors[0].Extensions["code"])
+
+	client.POST("/graphql").JSON(map[string]any{"variables": map[string]any{}}).Do().ExpectStatus(http.StatusBadRequest)
+	client.POST("/graphql").Body("application/json", nil).Do().ExpectStatus(http.StatusBadRequest)
+}
+
+func TestGraphQL_PersistedQueries(t *testing.T) {
+	srv := apitest.NewServer(t)
+	srv.SeedPosts(models.Post{Title: "Hello", Author: "alice"})
+	client := srv.Client()
+
+	query := `{ authors { name postCount } }`
+	sum := sha256.Sum256([]byte(query))
+	extensions := fmt.Sprintf(`{"persistedQuery":{"version":1,"sha256Hash":%q}}`, hex.EncodeToString(sum[:]))
+
+	// 未登録のハッシュはキャッシュさせず、クライアントにクエリを送り直させる
+	var resp graphqlResponse
+	client.GET("/graphql").Query("extensions", extensions).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", "no-store").
+		DecodeJSON(&resp)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "PERSISTED_QUERY_NOT_FOUND", resp.Errors[0].Extensions["code"])
+
+	resp = graphqlResponse{}
+	client.GET("/graphql").Query("query", query).Query("extensions", extensions).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&resp)
+	require.Empty(t, resp.Errors)
+
+	// 登録後はハッシュだけの GET を CDN でキャッシュできる
+	resp = graphqlResponse{}
+	client.GET("/graphql").Query("extensions", extensions).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", "public, max-age=60, stale-while-revalidate=300").
+		DecodeJSON(&resp)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"authors": [{"name": "alice", "postCount": 1}]}`, string(resp.Data))
+
+	client.GET("/graphql").Query("extensions", "{").Do().ExpectStatus(http.StatusBadRequest)
+}
+
+func TestGraphQL_Related(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+
+	goBasics := createPost(t, client, "Go の並行処理入門", "goroutine と channel で並行処理を書く", "go")
+	createPost(t, client, "Go の並行処理パターン", "channel を使った並行処理のパターン", "go", "concurrency")
+	createPost(t, client, "Go のテスト", "testing パッケージの使い方", "go")
+	eventuallyRelated(t, client, goBasics.ID, func(r []relatedPost) bool { return len(r) == 2 })
+
+	var resp graphqlResponse
+	client.POST("/graphql").JSON(map[string]any{"query": `{ posts { title related(first: 1) { score post { title } } } }`}).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&resp)
+	require.Empty(t, resp.Errors)
+	type post struct {
+		Title   string
+		Related []struct {
+			Score float64
+			Post  struct{ Title string }
+		}
+	}
+	var data struct{ Posts []post }
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.Len(t, data.Posts, 3)
+	i := slices.IndexFunc(data.Posts, func(p post) bool { return p.Title == goBasics.Title })
+	require.NotEqual(t, -1, i)
+	require.Len(t, data.Posts[i].Related, 1)
+	assert.Equal(t, "Go の並行処理パターン", data.Posts[i].Related[0].Post.Title)
+	assert.Greater(t, data.Posts[i].Related[0].Score, 0.0)
+}
@@ -0,0 +1,144 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/config"
+	"blog/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 1 件の公開済みの投稿と 1 件の下書きを含む WXR を返す
+func wxrExport(content string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<item>
+		<title>Imported Post</title>
+		<dc:creator>taro</dc:creator>
+		<content:encoded><![CDATA[` + content + `]]></content:encoded>
+		<wp:post_id>42</wp:post_id>
+		<wp:post_date_gmt>2020-01-02 03:04:05</wp:post_date_gmt>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+	</item>
+	<item>
+		<title>Draft</title>
+		<wp:post_id>43</wp:post_id>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+</channel>
+</rss>`)
+}
+
+func importWXR(client *apitest.Client, data []byte, dryRun bool) *apitest.Response {
+	return client.POST("/api/import").
+		File("file", "export.xml", data, map[string]string{"format": "wordpress", "dry_run": fmt.Sprint(dryRun)}).
+		Do()
+}
+
+func TestImport_WordPress(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.AdminClient()
+
+	// 一覧をキャッシュさせてから取り込む
+	var posts []models.Post
+	client.GET("/api/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	require.Empty(t, posts)
+
+	var report models.ImportReport
+	importWXR(client, wxrExport("<p>Hello <b>world</b></p>"), true).ExpectStatus(http.StatusOK).DecodeJSON(&report)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	client.GET("/api/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	assert.Empty(t, posts, "dry run must not save anything")
+
+	importWXR(client, wxrExport("<p>Hello <b>world</b></p>"), false).ExpectStatus(http.StatusOK).DecodeJSON(&report)
+	require.Equal(t, 1, report.Created)
+	require.Equal(t, models.ImportCreated, report.Results[0].Action)
+	postID := report.Results[0].PostID
+
+	client.GET("/api/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	require.Len(t, posts, 1)
+	var post models.Post
+	client.GET(fmt.Sprintf("/api/posts/%d", postID)).Do().ExpectStatus(http.StatusOK).DecodeJSON(&post)
+	assert.Equal(t, "Imported Post", post.Title)
+	assert.Equal(t, "Hello **world**", post.Content)
+	assert.Equal(t, "taro", post.Author)
+	assert.Equal(t, "Hello world", post.Excerpt)
+	assert.True(t, post.CreatedAt.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	require.Len(t, post.Tags, 1)
+	assert.Equal(t, "go", post.Tags[0].Name)
+
+	// 再実行しても重複しない
+	importWXR(client, wxrExport("<p>Hello <b>world</b></p>"), false).ExpectStatus(http.StatusOK).DecodeJSON(&report)
+	assert.Equal(t, 0, report.Created)
+	assert.Equal(t, 1, report.Unchanged)
+
+	// 取り込み元が変わっていれば更新する
+	importWXR(client, wxrExport("<p>Updated</p>"), false).ExpectStatus(http.StatusOK).DecodeJSON(&report)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, postID, report.Results[0].PostID)
+	client.GET(fmt.Sprintf("/api/posts/%d", postID)).Do().ExpectStatus(http.StatusOK).DecodeJSON(&post)
+	assert.Equal(t, "Updated", post.Content)
+	client.GET("/api/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	assert.Len(t, posts, 1)
+
+	// 取り込んだ後に削除した投稿は復活させない
+	client.DELETE(fmt.Sprintf("/api/posts/%d", postID)).Do().ExpectStatus(http.StatusOK)
+	importWXR(client, wxrExport("<p>Again</p>"), false).ExpectStatus(http.StatusOK).DecodeJSON(&report)
+	assert.Equal(t, 2, report.Skipped)
+	client.GET("/api/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	assert.Empty(t, posts)
+}
+
+func TestImport_Markdown(t *testing.T) {
+	srv := apitest.NewServer(t)
+
+	var report models.ImportReport
+	srv.AdminClient().POST("/api/import").
+		File("file", "2024-01-01-hello.md", []byte("---\ntitle: Hello Jekyll\ntags: [ruby]\n---\n# Hello\n"), map[string]string{"format": "jekyll"}).
+		Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&report)
+	require.Equal(t, 1, report.Created, report.Results)
+	assert.Equal(t, "2024-01-01-hello", report.Results[0].SourceID)
+
+	var post models.Post
+	srv.Client().GET(fmt.Sprintf("/api/posts/%d", report.Results[0].PostID)).Do().ExpectStatus(http.StatusOK).DecodeJSON(&post)
+	assert.Equal(t, "# Hello\n", post.Content)
+	assert.Equal(t, 2024, post.CreatedAt.Year())
+}
+
+func TestImport_Validation(t *testing.T) {
+	srv := apitest.NewServer(t)
+
+	importWXR(srv.Client(), wxrExport("x"), false).ExpectStatus(http.StatusUnauthorized)
+	importWXR(srv.Client().WithBearerToken("wrong"), wxrExport("x"), false).ExpectStatus(http.StatusUnauthorized)
+
+	client := srv.AdminClient()
+	client.POST("/api/import").File("file", "export.xml", wxrExport("x"), map[string]string{"format": "blogger"}).Do().
+		ExpectStatus(http.StatusBadRequest)
+	client.POST("/api/import").File("file", "export.xml", []byte("<rss><channel>"), map[string]string{"format": "wordpress"}).Do().
+		ExpectStatus(http.StatusBadRequest)
+	client.POST("/api/import").JSON(map[string]string{"format": "wordpress"}).Do().
+		ExpectStatus(http.StatusBadRequest)
+	client.POST("/api/import").File("file", "export.xml", []byte(strings.Repeat("x", 2<<20)), map[string]string{"format": "wordpress"}).Do().
+		ExpectStatus(http.StatusRequestEntityTooLarge)
+}
+
+func TestImport_DisabledWithoutToken(t *testing.T) {
+	srv := apitest.NewServer(t, apitest.WithConfig(func(cfg *config.Config) {
+		cfg.Admin.Token = ""
+	}))
+	importWXR(srv.Client().WithBearerToken(""), wxrExport("x"), false).ExpectStatus(http.StatusNotFound)
+}
@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/openapi"
+	blogv1 "blog/proto/blog/v1"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+)
+
+// ginParam は Gin のパスパラメータ (:id, *filepath)
+var ginParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// protoParam は google.api.http のパス変数 ({id}, {post.id})
+var protoParam = regexp.MustCompile(`\{(?:[^/}]+\.)?([^./}]+)\}`)
+
+// 登録した全てのルートが OpenAPI ドキュメントにあり、ドキュメントの全ての操作がルートとして登録されている
+func TestOpenAPI_Routes(t *testing.T) {
+	srv := apitest.NewServer(t)
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	documented := doc.Operations()
+
+	var registered []openapi.Operation
+	for _, route := range srv.Router.Routes() {
+		operation := openapi.Operation{Method: route.Method, Path: ginParam.ReplaceAllString(route.Path, "{$1}")}
+		registered = append(registered, operation)
+		assert.Contains(t, documented, operation, "route %s %s is missing from openapi/openapi.yaml", route.Method, route.Path)
+	}
+	for _, operation := range documented {
+		assert.True(t, slices.Contains(registered, operation), "%s %s is documented but not registered", operation.Method, operation.Path)
+	}
+}
+
+// gRPC API の google.api.http のマッピングが全て REST API の操作と一致する
+func TestOpenAPI_GRPCMapping(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	documented := doc.Operations()
+
+	methods := blogv1.File_blog_v1_posts_proto.Services().ByName("PostService").Methods()
+	require.NotZero(t, methods.Len())
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		require.True(t, ok && rule != nil, "%s has no google.api.http option", method.Name())
+
+		var operation openapi.Operation
+		switch pattern := rule.GetPattern().(type) {
+		case *annotations.HttpRule_Get:
+			operation = openapi.Operation{Method: http.MethodGet, Path: pattern.Get}
+		case *annotations.HttpRule_Post:
+			operation = openapi.Operation{Method: http.MethodPost, Path: pattern.Post}
+		case *annotations.HttpRule_Put:
+			operation = openapi.Operation{Method: http.MethodPut, Path: pattern.Put}
+		case *annotations.HttpRule_Delete:
+			operation = openapi.Operation{Method: http.MethodDelete, Path: pattern.Delete}
+		default:
+			t.Fatalf("%s has unsupported pattern %T", method.Name(), pattern)
+		}
+		// {post.id} のようなフィールドのパスは REST API のパラメータ名 ({id}) にする
+		operation.Path = protoParam.ReplaceAllString(operation.Path, "{$1}")
+		assert.Contains(t, documented, operation, "%s maps to %s %s, which is not a REST API operation", method.Name(), operation.Method, operation.Path)
+	}
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]any
+	}
+	resp := client.GET("/openapi.json").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "application/json; charset=utf-8")
+	require.NoError(t, json.Unmarshal([]byte(resp.Body()), &spec))
+	assert.Equal(t, "3.1.0", spec.OpenAPI)
+	assert.Contains(t, spec.Paths, "/api/posts/{id}")
+	client.GET("/openapi.json").Header("If-None-Match", resp.Header("ETag")).Do().ExpectStatus(http.StatusNotModified)
+
+	// UI は外部のスクリプトを読み込まず、同じオリジンの docs.js だけを実行する
+	docs := client.GET("/docs").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Security-Policy", openapi.DocsContentSecurityPolicy)
+	assert.Contains(t, docs.Body(), `<script src="docs.js"></script>`)
+	assert.NotContains(t, docs.Body(), "https://")
+	script := client.GET("/docs.js").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "text/javascript; charset=utf-8").
+		Body()
+	assert.Contains(t, script, `fetch("openapi.json")`)
+}
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPages(t *testing.T) {
+	srv := apitest.NewServer(t)
+	posted := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	posts := srv.SeedPosts(
+		models.Post{Title: "Hello <SSR>", Content: "# Body", Slug: "hello", Description: "First post", CoverImage: "/images/cover.png",
+			Author: "alice", Tags: []models.Tag{{Name: "go"}}, CreatedAt: posted, UpdatedAt: posted},
+		models.Post{Title: "No slug", Content: "text", CreatedAt: posted.AddDate(0, 1, 0), UpdatedAt: posted.AddDate(0, 1, 0)},
+		models.Post{Title: "Secret draft", Content: "draft", Slug: "secret", Draft: true, CreatedAt: posted, UpdatedAt: posted},
+	)
+	client := srv.Client()
+
+	index := client.GET("/").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "text/html; charset=utf-8").
+		ExpectHeader("Cache-Control", "public, max-age=60, stale-while-revalidate=300")
+	assert.Contains(t, index.Body(), "<title>Test Blog</title>")
+	assert.Contains(t, index.Body(), `<a href="/posts/hello">Hello &lt;SSR&gt;</a>`)
+	assert.Contains(t, index.Body(), fmt.Sprintf(`<a href="/posts/%d">No slug</a>`, posts[1].ID))
+	assert.NotContains(t, index.Body(), "Secret draft")
+	client.GET("/").Header("If-None-Match", index.Header("ETag")).Do().ExpectStatus(http.StatusNotModified)
+
+	post := client.GET("/posts/hello").Do().ExpectStatus(http.StatusOK).Body()
+	for _, want := range []string{
+		"<title>Hello &lt;SSR&gt; - Test Blog</title>",
+		`<link rel="canonical" href="https://blog.example.com/posts/hello">`,
+		`<meta property="og:type" content="article">`,
+		`<meta property="og:title" content="Hello &lt;SSR&gt;">`,
+		`<meta property="og:description" content="First post">`,
+		`<meta property="og:image" content="https://blog.example.com/images/cover.png">`,
+		`<meta property="article:published_time" content="2024-03-05T09:00:00Z">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		// JSON-LD の中の < は <script> を閉じられないようにエスケープする
+		`<script type="application/ld+json">{"@context":"https://schema.org","@type":"BlogPosting","headline":"Hello \u003cSSR\u003e"`,
+		`"author":{"@type":"Person","name":"alice"}`,
+		"<h1>Body</h1>",
+		`<a class="tag" href="/tags/go">#go</a>`,
+		`<a href="/archive/2024/03">`,
+	} {
+		assert.Contains(t, post, want)
+	}
+
+	// ID で開くと slug の URL に転送する。下書きは公開しない
+	redirected := client.GET(fmt.Sprintf("/posts/%d", posts[0].ID)).Do().ExpectStatus(http.StatusOK)
+	assert.Equal(t, "/posts/hello", redirected.Raw.Request.URL.Path)
+	client.GET(fmt.Sprintf("/posts/%d", posts[1].ID)).Do().ExpectStatus(http.StatusOK)
+	client.GET("/posts/secret").Do().ExpectStatus(http.StatusNotFound)
+	client.GET(fmt.Sprintf("/posts/%d", posts[2].ID)).Do().ExpectStatus(http.StatusNotFound)
+	client.GET("/posts/missing").Do().ExpectStatus(http.StatusNotFound)
+
+	tag := client.GET("/tags/go").Do().ExpectStatus(http.StatusOK).Body()
+	assert.Contains(t, tag, "<h1>#go</h1>")
+	assert.NotContains(t, tag, "No slug")
+	client.GET("/tags/rust").Do().ExpectStatus(http.StatusNotFound)
+
+	archive := client.GET("/archive/2024/03").Do().ExpectStatus(http.StatusOK).Body()
+	assert.Contains(t, archive, "<h1>2024年3月</h1>")
+	assert.Contains(t, archive, "Hello &lt;SSR&gt;")
+	assert.NotContains(t, archive, "No slug")
+	short := client.GET("/archive/2024/3").Do().ExpectStatus(http.StatusOK)
+	assert.Equal(t, "/archive/2024/03", short.Raw.Request.URL.Path)
+	client.GET("/archive/2024/05").Do().ExpectStatus(http.StatusNotFound)
+	client.GET("/archive/2024/13").Do().ExpectStatus(http.StatusNotFound)
+	client.GET("/page/2").Do().ExpectStatus(http.StatusNotFound)
+
+	feed := client.GET("/feed.xml").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "application/xml; charset=utf-8").
+		Body()
+	assert.Contains(t, feed, "<link>https://blog.example.com/posts/hello</link>")
+	assert.NotContains(t, feed, "Secret draft")
+	client.GET("/static/style.css").Do().ExpectStatus(http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"blog/config"
+	"blog/middlewares"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// rateLimiters はルートごとのレート制限ミドルウェアを設定から生成する
+type rateLimiters struct {
+	write  gin.HandlersChain
+	render gin.HandlersChain
+}
+
+// adminToken で認証した書き込みは IP ではなくユーザーごとに制限する
+func newRateLimiters(db *gorm.DB, cfg config.RateLimitConfig, adminToken string) (*rateLimiters, error) {
+	var store middlewares.RateLimitStore
+	switch cfg.Store {
+	case "", "memory":
+		store = middlewares.NewMemoryRateLimitStore()
+	case "postgres":
+		store = middlewares.NewPostgresRateLimitStore(db)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store: %q", cfg.Store)
+	}
+
+	limiter := func(name, spec string, key middlewares.RateLimitKeyFunc) (gin.HandlersChain, error) {
+		if spec == "" {
+			return nil, nil
+		}
+		policy, err := middlewares.ParseRateLimitPolicy(name, spec, key)
+		if err != nil {
+			return nil, err
+		}
+		return gin.HandlersChain{middlewares.RateLimit(store, policy)}, nil
+	}
+
+	write, err := limiter("write", cfg.Write, middlewares.KeyByUser)
+	if err != nil {
+		return nil, err
+	}
+	if write != nil && adminToken != "" {
+		write = append(gin.HandlersChain{middlewares.IdentifyAdmin(adminToken)}, write...)
+	}
+	render, err := limiter("render", cfg.Render, middlewares.KeyByIP)
+	if err != nil {
+		return nil, err
+	}
+	return &rateLimiters{write: write, render: render}, nil
+}
+
+// with はハンドラの前にレート制限などの省略可能なミドルウェアを連結する
+func with(middlewares gin.HandlersChain, handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+	return append(append(gin.HandlersChain{}, middlewares...), handlers...)
+}
@@ -0,0 +1,119 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type relatedPost struct {
+	ID    uint
+	Title string
+	Score float64
+}
+
+// createPost は API 経由で投稿を作成する (関連記事の再計算が通知される)
+func createPost(t *testing.T, client *apitest.Client, title, content string, tags ...string) models.Post {
+	t.Helper()
+	body := map[string]any{"Title": title, "Content": content, "Tags": tagList(tags)}
+	var post models.Post
+	client.POST("/api/posts").JSON(body).Do().ExpectStatus(http.StatusCreated).DecodeJSON(&post)
+	require.NotZero(t, post.ID)
+	return post
+}
+
+func tagList(names []string) []map[string]string {
+	tags := make([]map[string]string, len(names))
+	for i, name := range names {
+		tags[i] = map[string]string{"Name": name}
+	}
+	return tags
+}
+
+// eventuallyRelated はバックグラウンドジョブの再計算を待って関連記事を返す
+func eventuallyRelated(t *testing.T, client *apitest.Client, postID uint, cond func([]relatedPost) bool) []relatedPost {
+	t.Helper()
+	var related []relatedPost
+	require.Eventually(t, func() bool {
+		related = nil
+		client.GET(fmt.Sprintf("/api/posts/%d/related", postID)).Do().
+			ExpectStatus(http.StatusOK).
+			DecodeJSON(&related)
+		return cond(related)
+	}, 5*time.Second, 20*time.Millisecond)
+	return related
+}
+
+func TestRelatedPosts(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+
+	goBasics := createPost(t, client, "Go の並行処理入門", "goroutine と channel で並行処理を書く", "go")
+	goPatterns := createPost(t, client, "Go の並行処理パターン", "channel を使った並行処理のパターン", "Go", "concurrency")
+	goTesting := createPost(t, client, "Go のテスト", "testing パッケージの使い方", "go")
+	createPost(t, client, "Baking bread", "flour, yeast and a hot oven", "cooking")
+
+	related := eventuallyRelated(t, client, goBasics.ID, func(r []relatedPost) bool { return len(r) == 2 })
+	assert.Equal(t, goPatterns.ID, related[0].ID)
+	assert.Equal(t, "Go の並行処理パターン", related[0].Title)
+	assert.Equal(t, goTesting.ID, related[1].ID)
+	assert.Greater(t, related[0].Score, related[1].Score)
+
+	var limited []relatedPost
+	client.GET(fmt.Sprintf("/api/posts/%d/related", goBasics.ID)).Query("limit", "1").Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&limited)
+	assert.Len(t, limited, 1)
+
+	// 削除した投稿は関連記事から外れる
+	client.DELETE(fmt.Sprintf("/api/posts/%d", goPatterns.ID)).Do().ExpectStatus(http.StatusOK)
+	related = eventuallyRelated(t, client, goBasics.ID, func(r []relatedPost) bool { return len(r) == 1 })
+	assert.Equal(t, goTesting.ID, related[0].ID)
+
+	// 更新で内容が変わると関連記事も変わる
+	client.PUT(fmt.Sprintf("/api/posts/%d", goTesting.ID)).
+		JSON(map[string]any{"Title": "Sourdough", "Content": "bread with wild yeast", "Tags": tagList([]string{"cooking"})}).
+		Do().
+		ExpectStatus(http.StatusOK)
+	eventuallyRelated(t, client, goBasics.ID, func(r []relatedPost) bool { return len(r) == 0 })
+}
+
+func TestRelatedPosts_Validation(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+	post := createPost(t, client, "Hello", "World")
+
+	client.GET("/api/posts/999/related").Do().ExpectStatus(http.StatusNotFound)
+	client.GET(fmt.Sprintf("/api/posts/%d/related", post.ID)).Query("limit", "0").Do().ExpectStatus(http.StatusBadRequest)
+	client.GET(fmt.Sprintf("/api/posts/%d/related", post.ID)).Query("limit", "abc").Do().ExpectStatus(http.StatusBadRequest)
+	client.GET(fmt.Sprintf("/api/posts/%d/related", post.ID)).Query("limit", "21").Do().ExpectStatus(http.StatusBadRequest)
+}
+
+func TestPostTags(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+	post := createPost(t, client, "Tagged", "Content", " Go ", "go", "ブログ")
+
+	var fetched models.Post
+	client.GET(fmt.Sprintf("/api/posts/%d", post.ID)).Do().ExpectStatus(http.StatusOK).DecodeJSON(&fetched)
+	require.Len(t, fetched.Tags, 2)
+	assert.Equal(t, "go", fetched.Tags[0].Name)
+	assert.Equal(t, "ブログ", fetched.Tags[1].Name)
+
+	// Tags を省略した更新では既存のタグを維持する
+	client.PUT(fmt.Sprintf("/api/posts/%d", post.ID)).JSON(map[string]any{"Title": "Tagged", "Content": "Edited"}).Do().ExpectStatus(http.StatusOK)
+	client.GET(fmt.Sprintf("/api/posts/%d", post.ID)).Do().DecodeJSON(&fetched)
+	assert.Len(t, fetched.Tags, 2)
+
+	client.PUT(fmt.Sprintf("/api/posts/%d", post.ID)).JSON(map[string]any{"Title": "Tagged", "Content": "Edited", "Tags": tagList([]string{"docker"})}).Do().ExpectStatus(http.StatusOK)
+	fetched = models.Post{}
+	client.GET(fmt.Sprintf("/api/posts/%d", post.ID)).Do().DecodeJSON(&fetched)
+	require.Len(t, fetched.Tags, 1)
+	assert.Equal(t, "docker", fetched.Tags[0].Name)
+}
@@ -1,36 +1,213 @@
 package api
 
 import (
+	"blog/cache"
+	"blog/config"
 	"blog/controllers"
+	"blog/grpcapi"
+	"blog/jobs"
+	"blog/metrics"
 	"blog/middlewares"
+	"blog/openapi"
 	"blog/repositories"
 	"blog/services"
+	"blog/site"
+	"blog/views"
+	"context"
+	"log/slog"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(db *gorm.DB) *gin.Engine {
-	r := gin.Default()
+// App は HTTP のルーターと、それと一緒に動かすバックグラウンドジョブ
+type App struct {
+	Router *gin.Engine
+	jobs   []job
+	wg     sync.WaitGroup
+}
+
+// job は ctx がキャンセルされるまで動くバックグラウンドジョブ
+type job interface {
+	Run(ctx context.Context) error
+}
+
+// Start はバックグラウンドジョブを起動する。ジョブは ctx がキャンセルされると停止する
+func (a *App) Start(ctx context.Context) {
+	for _, j := range a.jobs {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			if err := j.Run(ctx); err != nil {
+				slog.ErrorContext(ctx, "background job stopped", "error", err)
+			}
+		}()
+	}
+}
+
+// Wait は Start で起動したジョブの停止を待つ
+func (a *App) Wait() {
+	a.wg.Wait()
+}
+
+// RegisterRoutes はルートを登録したルーターを返す。NewApp のバックグラウンドジョブはここで起動し、プロセスの終了まで動かす。
+// サーバーの停止に合わせてジョブを止める場合は NewApp と App.Start を使う
+func RegisterRoutes(db *gorm.DB, cfg *config.Config) (*gin.Engine, error) {
+	app, err := NewApp(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	app.Start(context.Background())
+	return app.Router, nil
+}
+
+// NewApp はルートを登録したルーターとバックグラウンドジョブを生成する
+func NewApp(db *gorm.DB, cfg *config.Config) (*App, error) {
+	app := &App{}
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// X-Forwarded-For を信頼するプロキシを設定 (ClientIP とレート制限で使用)
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	// サーバースパンの生成と W3C traceparent の伝搬
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+
+	// リクエスト ID の付与とアクセスログ
+	r.Use(middlewares.RequestLogger(slog.Default()))
+
+	// Prometheus メトリクスの記録
+	r.Use(middlewares.Metrics())
 
 	// CORS ミドルウェアを適用
 	r.Use(middlewares.CORSConfig())
 
+	// リクエスト単位の DB タイムアウトを設定
+	r.Use(middlewares.DBTimeout(cfg.DBTimeout))
+
 	// リポジトリ、サービス、コントローラーの初期化
-	repo := repositories.NewPostRepository(db)
-	service := services.NewPostService(repo)
-	postController := controllers.NewPostController(service)
+	uow := repositories.NewUnitOfWork(db)
+	service := services.NewPostServiceWithUnitOfWork(uow)
+	// 取り込みは PostService を経由しないため、デコレータが行う処理をリスナーとして渡す
+	var listeners, importListeners, seriesListeners []services.PostEventListener
+	if cfg.RelatedPosts.Enabled {
+		// 投稿の変更を受けて関連記事を再計算する
+		worker := jobs.NewRelatedPostsWorker(uow, cfg.RelatedPosts.Limit, cfg.RelatedPosts.Interval)
+		app.jobs = append(app.jobs, worker)
+		markDirty := func(_ context.Context, event services.PostEvent) {
+			worker.MarkDirty(event.PostID)
+		}
+		listeners = append(listeners, markDirty)
+		importListeners = append(importListeners, markDirty)
+	}
+	// Webhook のイベントは PostService と取り込みが投稿と同じトランザクションで保存する。送信のジョブには保存後に知らせる
+	var wakeWebhooks func()
+	if cfg.Webhooks.Enabled {
+		dispatcher := jobs.NewWebhookDispatcher(uow.Repositories().Webhooks, jobs.WebhookOptions{
+			PollInterval: cfg.Webhooks.PollInterval,
+			Timeout:      cfg.Webhooks.Timeout,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			BackoffBase:  cfg.Webhooks.BackoffBase,
+			BackoffMax:   cfg.Webhooks.BackoffMax,
+			BatchSize:    cfg.Webhooks.BatchSize,
+		})
+		app.jobs = append(app.jobs, dispatcher)
+		wakeWebhooks = dispatcher.Notify
+		notify := func(context.Context, services.PostEvent) { dispatcher.Notify() }
+		listeners = append(listeners, notify)
+		importListeners = append(importListeners, notify)
+	}
+	if len(listeners) > 0 {
+		service = services.NewObservedPostService(service, listeners...)
+	}
+	if cfg.Cache.Enabled {
+		cached := services.NewCachedPostService(service, cache.NewMemory(cfg.Cache.MaxEntries), services.CacheTTL{
+			Post: cfg.Cache.PostTTL,
+			List: cfg.Cache.ListTTL,
+		})
+		service = cached
+		importListeners = append(importListeners, cached.Invalidate)
+		// 投稿の一覧に含まれる連載の情報を古くしない
+		seriesListeners = append(seriesListeners, cached.Invalidate)
+	}
+	service = services.NewTracedPostService(service)
+	seriesService := services.NewSeriesService(uow, seriesListeners...)
+	relatedService := services.NewRelatedPostService(uow)
+	h := &handlers{
+		posts: controllers.NewPostController(service).
+			WithSeries(seriesService).
+			WithRelated(relatedService).
+			WithStats(services.NewPostStatsService(uow)),
+		series:     controllers.NewSeriesController(seriesService),
+		adminToken: cfg.Admin.Token,
+	}
+	if cfg.Admin.Token != "" {
+		h.identifyAdmin = gin.HandlersChain{middlewares.IdentifyAdmin(cfg.Admin.Token)}
+		h.imports = controllers.NewImportController(services.NewImportService(uow, importListeners...), cfg.Admin.Timeout, cfg.Admin.MaxUploadSize)
+		h.backups = controllers.NewBackupController(services.NewBackupService(uow), cfg.Admin.Timeout)
+		h.webhooks = controllers.NewWebhookController(services.NewWebhookService(uow, wakeWebhooks))
+	}
+
+	limiters, err := newRateLimiters(db, cfg.RateLimit, cfg.Admin.Token)
+	if err != nil {
+		return nil, err
+	}
+	h.limiters = limiters
+
+	// 投稿の取得と表示を閲覧として数え、まとめてデータベースに書き込む
+	if cfg.Views.Enabled {
+		recorder := views.NewRecorder(uow.Repositories().Views, cfg.Views.FlushInterval, cfg.Views.MaxBuffered, cfg.Views.MaxVisitors)
+		app.jobs = append(app.jobs, recorder)
+		h.countView = gin.HandlersChain{middlewares.CountView(recorder)}
+	}
 
-	// ルートグループを作成
-	api := r.Group("/api/posts")
+	// バージョンごとの API。バージョンなしのパスは v1 と同じハンドラーの非推奨の別名として残す
+	registerV1(r.Group(v1Prefix), h)
+	registerV1(legacyGroup(r, cfg.LegacyAPI), h)
+
+	// 公開サイトのページ。URL は静的サイトの書き出しと同じ
+	renderer, err := site.NewRenderer(cfg.Site)
+	if err != nil {
+		return nil, err
+	}
+	pageController := controllers.NewPageController(service, renderer)
+	pages := r.Group("", middlewares.CacheControl(middlewares.PublishedContentCache))
 	{
-		api.GET("", postController.GetAllPosts)
-		api.GET("/:id", postController.GetPostByID)
-		api.POST("", postController.CreatePost)
-		api.PUT("/:id", postController.UpdatePost)
-		api.DELETE("/:id", postController.DeletePost)
-		api.GET("/:id/render", postController.RenderMarkdown)
+		pages.GET("/", pageController.Index)
+		pages.GET("/page/:page", pageController.Index)
+		pages.GET("/posts/:slug", with(h.countView, pageController.Post)...)
+		pages.GET("/tags/:tag", pageController.Tag)
+		pages.GET("/tags/:tag/page/:page", pageController.Tag)
+		pages.GET("/archive/:year/:month", pageController.Archive)
+		pages.GET("/archive/:year/:month/page/:page", pageController.Archive)
+		for _, file := range site.FeedFiles {
+			pages.GET("/"+file, pageController.Feed(file))
+		}
+		pages.StaticFS("/static", http.FS(renderer.Static()))
+	}
+
+	// GraphQL。読み取り専用で、REST API と同じ PostService を使う
+	executor, err := newGraphQLExecutor(service, relatedService, cfg.GraphQL)
+	if err != nil {
+		return nil, err
+	}
+	graphqlController := controllers.NewGraphQLController(executor)
+	r.GET("/graphql", middlewares.CacheControl(middlewares.PublishedContentCache), graphqlController.Query)
+	r.POST("/graphql", middlewares.CacheControl(middlewares.NoStoreCache), graphqlController.Query)
+
+	// 内部サービス向けの gRPC API。REST API と同じ PostService を使い、別のポートで待ち受ける
+	if cfg.GRPC.Port != "" {
+		grpcServer, err := grpcapi.NewServer(":"+cfg.GRPC.Port, cfg.GRPC.Token, service)
+		if err != nil {
+			return nil, err
+		}
+		app.jobs = append(app.jobs, grpcServer)
 	}
 
 	// ヘルスチェックエンドポイント
@@ -38,5 +215,22 @@ func RegisterRoutes(db *gorm.DB) *gin.Engine {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
-	return r
+	// Prometheus メトリクスエンドポイント
+	r.GET("/metrics", gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+
+	// API の OpenAPI ドキュメントと UI。ルートを追加したら openapi/openapi.yaml にも書く
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	docsController := controllers.NewDocsController(doc)
+	docs := r.Group("", middlewares.CacheControl(middlewares.PublishedContentCache))
+	{
+		docs.GET("/openapi.json", docsController.Spec)
+		docs.GET("/docs", docsController.UI)
+		docs.GET("/docs.js", docsController.Script)
+	}
+
+	app.Router = r
+	return app, nil
 }
@@ -0,0 +1,372 @@
+package api_test
+
+import (
+	"blog/api"
+	"blog/apitest"
+	"blog/config"
+	"blog/database"
+	"blog/middlewares"
+	"blog/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	srv := apitest.NewServer(t)
+
+	srv.Client().GET("/health").Do().
+		ExpectStatus(http.StatusOK)
+}
+
+func TestPostLifecycle(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+
+	var created models.Post
+	client.POST("/api/v1/posts").
+		JSON(map[string]string{"title": "New Post", "content": "# Hello", "author": "New Author"}).
+		Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&created)
+	require.NotZero(t, created.ID)
+	path := fmt.Sprintf("/api/v1/posts/%d", created.ID)
+
+	var fetched models.Post
+	client.GET(path).Do().ExpectStatus(http.StatusOK).DecodeJSON(&fetched)
+	assert.Equal(t, "New Post", fetched.Title)
+
+	client.PUT(path).
+		JSON(map[string]string{"title": "Updated Title", "content": "# Updated"}).
+		Do().
+		ExpectStatus(http.StatusOK)
+
+	client.GET(path).Do().ExpectStatus(http.StatusOK).DecodeJSON(&fetched)
+	assert.Equal(t, "Updated Title", fetched.Title)
+	assert.Equal(t, "Updated", fetched.Excerpt)
+	assert.Equal(t, 1, fetched.WordCount)
+	assert.Equal(t, 1, fetched.ReadingTimeMinutes)
+
+	// 一覧は既定で本文を含めず、?fields=content で含める
+	var summaries []models.Post
+	client.GET("/api/v1/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&summaries)
+	require.Len(t, summaries, 1)
+	assert.Empty(t, summaries[0].Content)
+	assert.Equal(t, "Updated", summaries[0].Excerpt)
+	client.GET("/api/v1/posts").Query("fields", "content").Do().ExpectStatus(http.StatusOK).DecodeJSON(&summaries)
+	assert.Equal(t, "# Updated", summaries[0].Content)
+
+	rendered := client.GET(path + "/render").Do().ExpectStatus(http.StatusOK)
+	assert.Contains(t, rendered.Body(), "<h1>Updated</h1>")
+
+	client.DELETE(path).Do().ExpectStatus(http.StatusOK)
+	client.GET(path).Do().ExpectStatus(http.StatusNotFound)
+
+	var posts []models.Post
+	client.GET("/api/v1/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	assert.Empty(t, posts)
+}
+
+// 更新で省略したフィールドは保存済みの値を維持する (フロントエンドは title、content、author だけを送る)
+func TestUpdatePost_KeepsOmittedFields(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+
+	var created models.Post
+	client.POST("/api/v1/posts").
+		JSON(map[string]any{"Title": "Draft", "Content": "---\nslug: my-draft\ndescription: Summary\ndraft: true\nseries_order: 2\n---\nbody"}).
+		Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&created)
+	require.True(t, created.Draft)
+
+	var updated models.Post
+	client.PUT(fmt.Sprintf("/api/v1/posts/%d", created.ID)).
+		JSON(map[string]string{"title": "Renamed"}).
+		Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&updated)
+	assert.Equal(t, "Renamed", updated.Title)
+
+	var stored models.Post
+	require.NoError(t, srv.DB.First(&stored, created.ID).Error)
+	assert.Equal(t, "Renamed", stored.Title)
+	assert.True(t, stored.Draft)
+	assert.Equal(t, "my-draft", stored.Slug)
+	assert.Equal(t, "Summary", stored.Description)
+	assert.Equal(t, "body", stored.Content)
+	assert.Equal(t, created.Metadata, stored.Metadata)
+}
+
+// バージョンなしのパスは /api/v1 と同じハンドラーで、廃止予定のヘッダーを付ける
+func TestLegacyAPIAliases(t *testing.T) {
+	srv := apitest.NewServer(t, apitest.WithConfig(func(cfg *config.Config) {
+		cfg.LegacyAPI.DeprecatedAt = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+		cfg.LegacyAPI.Sunset = time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC)
+	}))
+	posts := srv.SeedPosts(models.Post{Title: "Hello"})
+	client := srv.Client()
+
+	var current, legacy models.Post
+	resp := client.GET(fmt.Sprintf("/api/v1/posts/%d", posts[0].ID)).Do().ExpectStatus(http.StatusOK).DecodeJSON(&current)
+	assert.Empty(t, resp.Header("Deprecation"))
+	assert.Empty(t, resp.Header("Sunset"))
+
+	client.GET(fmt.Sprintf("/api/posts/%d", posts[0].ID)).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Deprecation", "@1792368000").
+		ExpectHeader("Sunset", "Mon, 19 Apr 2027 00:00:00 GMT").
+		ExpectHeader("Link", fmt.Sprintf(`</api/v1/posts/%d>; rel="successor-version"`, posts[0].ID)).
+		DecodeJSON(&legacy)
+	assert.Equal(t, current, legacy)
+
+	// 管理 API も同じ
+	admin := srv.AdminClient()
+	admin.GET("/api/v1/admin/export").Do().ExpectStatus(http.StatusOK)
+	admin.GET("/api/admin/export").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Link", `</api/v1/admin/export>; rel="successor-version"`)
+}
+
+func TestGetAllPosts_Seeded(t *testing.T) {
+	srv := apitest.NewServer(t)
+	srv.SeedPosts(
+		models.Post{Title: "First", Content: "one"},
+		models.Post{Title: "Second", Content: "two"},
+	)
+
+	var posts []models.Post
+	srv.Client().GET("/api/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	assert.Len(t, posts, 2)
+}
+
+func TestGetAllPosts_SparseFields(t *testing.T) {
+	srv := apitest.NewServer(t)
+	srv.SeedPosts(models.Post{Title: "First", Content: "one", Author: "alice"})
+	client := srv.Client()
+
+	var posts []map[string]any
+	client.GET("/api/posts").Query("fields", "title").Query("include", "author,tags").Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&posts)
+	require.Len(t, posts, 1)
+	assert.Len(t, posts[0], 4)
+	assert.Equal(t, "First", posts[0]["Title"])
+	assert.Equal(t, []any{}, posts[0]["Tags"])
+	assert.Equal(t, "alice", posts[0]["Author"])
+
+	for _, query := range [][2]string{
+		{"fields", "password"},
+		{"fields", "title;drop table posts"},
+		{"include", "comments"},
+		{"include", "comment_count"},
+	} {
+		client.GET("/api/posts").Query(query[0], query[1]).Do().ExpectStatus(http.StatusBadRequest)
+	}
+}
+
+func TestPostFrontMatter(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+
+	var created models.Post
+	client.POST("/api/posts").
+		JSON(map[string]string{"content": "---\ntitle: Pasted\ntags: [go]\nslug: pasted\ndraft: true\nemoji: 🐹\n---\n# Hello"}).
+		Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&created)
+	path := fmt.Sprintf("/api/posts/%d", created.ID)
+
+	// 下書きは公開の読み取りに出さない
+	var stored models.Post
+	require.NoError(t, srv.DB.First(&stored, created.ID).Error)
+	assert.True(t, stored.Draft)
+	client.GET(path).Do().ExpectStatus(http.StatusNotFound)
+	client.GET(path + "/render").Do().ExpectStatus(http.StatusNotFound)
+	// 管理トークンを持つ編集画面には下書きも返し、共有キャッシュには保存させない
+	var draft models.Post
+	srv.AdminClient().GET(path).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", "private, no-cache").
+		DecodeJSON(&draft)
+	assert.True(t, draft.Draft)
+	srv.Client().WithBearerToken("wrong").GET(path).Do().ExpectStatus(http.StatusNotFound)
+	var drafts []models.Post
+	client.GET("/api/posts").Do().ExpectStatus(http.StatusOK).DecodeJSON(&drafts)
+	assert.Empty(t, drafts)
+
+	client.PUT(path).JSON(map[string]any{"Draft": false}).Do().ExpectStatus(http.StatusOK)
+	var fetched models.Post
+	client.GET(path).Do().ExpectStatus(http.StatusOK).DecodeJSON(&fetched)
+	assert.Equal(t, "Pasted", fetched.Title)
+	assert.Equal(t, "# Hello", fetched.Content)
+	assert.Equal(t, "pasted", fetched.Slug)
+	assert.False(t, fetched.Draft)
+	assert.Equal(t, models.PostMetadata{"emoji": "🐹"}, fetched.Metadata)
+	require.Len(t, fetched.Tags, 1)
+	assert.Equal(t, "go", fetched.Tags[0].Name)
+
+	var posts []map[string]any
+	client.GET("/api/posts").Query("fields", "slug,metadata").Do().ExpectStatus(http.StatusOK).DecodeJSON(&posts)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "pasted", posts[0]["Slug"])
+	assert.Equal(t, map[string]any{"emoji": "🐹"}, posts[0]["Metadata"])
+
+	client.POST("/api/posts").
+		JSON(map[string]string{"title": "Broken", "content": "---\ntitle: [\n---\nBody"}).
+		Do().
+		ExpectStatus(http.StatusBadRequest)
+
+	// 保存時に取り除かれる前の投稿も、表示ではフロントマターを出さない
+	legacy := srv.SeedPosts(models.Post{Title: "Legacy", Content: "---\ntitle: Legacy\n---\n# Legacy"})[0]
+	rendered := client.GET(fmt.Sprintf("/api/posts/%d/render", legacy.ID)).Do().ExpectStatus(http.StatusOK)
+	assert.Equal(t, "<h1>Legacy</h1>\n", rendered.Body())
+}
+
+// 更新では本文のフロントマターが保存済みの値を上書きし、draft: false で公開できる
+func TestPostFrontMatter_Update(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+
+	var created models.Post
+	client.POST("/api/posts").
+		JSON(map[string]string{"content": "---\ntitle: First\nslug: first\ndraft: true\n---\nbody"}).
+		Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&created)
+	path := fmt.Sprintf("/api/posts/%d", created.ID)
+
+	var updated models.Post
+	client.PUT(path).
+		JSON(map[string]string{"Content": "---\ntitle: Second\nslug: second\ndescription: Edited\ndraft: false\n---\nedited"}).
+		Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&updated)
+	assert.Equal(t, "Second", updated.Title)
+	assert.Equal(t, "second", updated.Slug)
+	assert.Equal(t, "Edited", updated.Description)
+	assert.Equal(t, "edited", updated.Content)
+	assert.False(t, updated.Draft)
+	client.GET(path).Do().ExpectStatus(http.StatusOK)
+
+	client.PUT(path).
+		JSON(map[string]string{"Content": "---\ndraft: true\n---\nedited"}).
+		Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&updated)
+	assert.True(t, updated.Draft)
+	assert.Equal(t, "Second", updated.Title)
+	client.GET(path).Do().ExpectStatus(http.StatusNotFound)
+}
+
+func TestConditionalGet(t *testing.T) {
+	srv := apitest.NewServer(t)
+	post := srv.SeedPosts(models.Post{Title: "Cached", Content: "body"})[0]
+	client := srv.Client()
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	first := client.GET(path).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", middlewares.PublishedContentCache.Public)
+	etag := first.Header("ETag")
+	require.NotEmpty(t, etag)
+
+	client.GET(path).Header("If-None-Match", etag).Do().
+		ExpectStatus(http.StatusNotModified)
+
+	client.PUT(path).JSON(map[string]string{"title": "Changed", "content": "body"}).Do().
+		ExpectStatus(http.StatusOK)
+
+	client.GET(path).Header("If-None-Match", etag).Do().
+		ExpectStatus(http.StatusOK)
+}
+
+// 削除で残った投稿の更新日時は進まないため、一覧は Last-Modified を返さず ETag で判定する
+func TestConditionalGet_ListAfterDelete(t *testing.T) {
+	srv := apitest.NewServer(t)
+	posts := srv.SeedPosts(models.Post{Title: "Kept", Content: "body"}, models.Post{Title: "Deleted", Content: "body"})
+	client := srv.Client()
+
+	first := client.GET("/api/posts").Do().ExpectStatus(http.StatusOK)
+	etag := first.Header("ETag")
+	require.NotEmpty(t, etag)
+	assert.Empty(t, first.Header("Last-Modified"))
+
+	client.DELETE(fmt.Sprintf("/api/posts/%d", posts[1].ID)).Do().ExpectStatus(http.StatusOK)
+
+	since := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	var list []models.Post
+	client.GET("/api/posts").Header("If-Modified-Since", since).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&list)
+	require.Len(t, list, 1)
+	assert.Equal(t, "Kept", list[0].Title)
+	client.GET("/api/posts").Header("If-None-Match", etag).Do().
+		ExpectStatus(http.StatusOK)
+}
+
+func TestAuthenticatedRequestsArePrivate(t *testing.T) {
+	srv := apitest.NewServer(t)
+
+	srv.Client().WithBearerToken("token").GET("/api/posts").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", middlewares.PublishedContentCache.Private)
+}
+
+func TestRequestIDIsEchoed(t *testing.T) {
+	srv := apitest.NewServer(t)
+
+	srv.Client().GET("/api/posts").Header(middlewares.RequestIDHeader, "req-1").Do().
+		ExpectHeader(middlewares.RequestIDHeader, "req-1")
+}
+
+func TestWriteRateLimit(t *testing.T) {
+	srv := apitest.NewServer(t, apitest.WithConfig(func(cfg *config.Config) {
+		cfg.RateLimit.Write = "2/1h"
+	}))
+	client := srv.Client()
+
+	for i := 0; i < 2; i++ {
+		client.POST("/api/posts").JSON(map[string]string{"title": "Post"}).Do().
+			ExpectStatus(http.StatusCreated)
+	}
+	resp := client.POST("/api/posts").JSON(map[string]string{"title": "Post"}).Do().
+		ExpectStatus(http.StatusTooManyRequests)
+	assert.NotEmpty(t, resp.Header("Retry-After"))
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.Client()
+	post := srv.SeedPosts(models.Post{Title: "Metrics"})[0]
+
+	client.GET(fmt.Sprintf("/api/posts/%d", post.ID)).Do().ExpectStatus(http.StatusOK)
+
+	body := client.GET("/metrics").Do().ExpectStatus(http.StatusOK).Body()
+	assert.True(t, strings.Contains(body, `http_requests_total{method="GET",route="/api/posts/:id",status="200"}`))
+	assert.Contains(t, body, "db_query_duration_seconds")
+	assert.Contains(t, body, "go_sql_open_connections")
+}
+
+// RegisterRoutes は NewApp のルーターをそのまま返す互換のための入口
+func TestRegisterRoutes(t *testing.T) {
+	db, err := database.Open(config.DatabaseConfig{Driver: "sqlite", Path: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+
+	router, err := api.RegisterRoutes(db, &config.Config{
+		Site:    config.SiteConfig{BaseURL: "https://blog.example.com", Title: "Test Blog", PageSize: 10},
+		GraphQL: config.GraphQLConfig{MaxDepth: 8, MaxComplexity: 2000, PersistedMaxEntries: 100, PersistedTTL: time.Hour},
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/posts", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,259 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/middlewares"
+	"blog/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 3 回の連載を作成し、連載と各回の投稿を返す
+func seedSeries(t *testing.T, srv *apitest.Server) (models.Series, []models.Post) {
+	posts := srv.SeedPosts(
+		models.Post{Title: "Part 1", Content: "# One"},
+		models.Post{Title: "Part 2", Content: "# Two"},
+		models.Post{Title: "Part 3", Content: "# Three"},
+	)
+	client := srv.Client()
+
+	var series models.Series
+	client.POST("/api/series").
+		JSON(map[string]string{"title": "Go Tutorial", "description": "A multi-part tutorial"}).
+		Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&series)
+	require.NotZero(t, series.ID)
+
+	client.PUT(fmt.Sprintf("/api/series/%d/posts", series.ID)).
+		JSON(map[string][]uint{"post_ids": {posts[0].ID, posts[1].ID, posts[2].ID}}).
+		Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&series)
+	require.Len(t, series.Entries, 3)
+
+	return series, posts
+}
+
+func TestSeries_Navigation(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	client := srv.Client()
+
+	assert.Equal(t, "Part 1", series.Entries[0].Post.Title)
+	assert.Equal(t, "Part 3", series.Entries[2].Post.Title)
+
+	var post struct {
+		models.Post
+		Series *models.SeriesNavigation
+	}
+	client.GET(fmt.Sprintf("/api/posts/%d", posts[1].ID)).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&post)
+	require.NotNil(t, post.Series)
+	assert.Equal(t, "Go Tutorial", post.Series.Title)
+	assert.Equal(t, 2, post.Series.Position)
+	assert.Equal(t, 3, post.Series.Total)
+	assert.Equal(t, posts[0].ID, post.Series.Previous.ID)
+	assert.Equal(t, posts[2].ID, post.Series.Next.ID)
+
+	client.GET(fmt.Sprintf("/api/posts/%d", posts[0].ID)).Do().DecodeJSON(&post)
+	assert.Nil(t, post.Series.Previous)
+}
+
+func TestSeries_RenderNavigation(t *testing.T) {
+	srv := apitest.NewServer(t)
+	_, posts := seedSeries(t, srv)
+	client := srv.Client()
+	path := fmt.Sprintf("/api/posts/%d/render", posts[1].ID)
+
+	plain := client.GET(path).Do().ExpectStatus(http.StatusOK).Body()
+	assert.NotContains(t, plain, "series-nav")
+
+	withNav := client.GET(path).Query("series_nav", "true").Do().ExpectStatus(http.StatusOK).Body()
+	assert.Contains(t, withNav, `<nav class="series-nav">`)
+	assert.Contains(t, withNav, fmt.Sprintf(`href="/posts/%d"`, posts[0].ID))
+	assert.Contains(t, withNav, fmt.Sprintf(`href="/posts/%d"`, posts[2].ID))
+	assert.Contains(t, withNav, "<h1>Two</h1>")
+}
+
+func TestSeries_DeletedPostLeavesSeries(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	client := srv.Client()
+
+	client.DELETE(fmt.Sprintf("/api/posts/%d", posts[1].ID)).Do().ExpectStatus(http.StatusOK)
+
+	var post struct {
+		models.Post
+		Series *models.SeriesNavigation
+	}
+	client.GET(fmt.Sprintf("/api/posts/%d", posts[0].ID)).Do().DecodeJSON(&post)
+	require.NotNil(t, post.Series)
+	assert.Equal(t, 2, post.Series.Total)
+	assert.Equal(t, posts[2].ID, post.Series.Next.ID)
+
+	client.GET(fmt.Sprintf("/api/series/%d", series.ID)).Do().DecodeJSON(&series)
+	assert.Len(t, series.Entries, 2)
+}
+
+func TestSeries_Validation(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	client := srv.Client()
+	path := fmt.Sprintf("/api/series/%d/posts", series.ID)
+
+	client.PUT(path).JSON(map[string][]uint{"post_ids": {posts[0].ID, posts[0].ID}}).Do().
+		ExpectStatus(http.StatusBadRequest)
+	client.PUT(path).JSON(map[string][]uint{"post_ids": {9999}}).Do().
+		ExpectStatus(http.StatusBadRequest)
+	client.PUT("/api/series/9999/posts").JSON(map[string][]uint{"post_ids": {}}).Do().
+		ExpectStatus(http.StatusNotFound)
+	client.POST("/api/series").JSON(map[string]string{"description": "no title"}).Do().
+		ExpectStatus(http.StatusBadRequest)
+}
+
+func TestSeries_UpdateAndDelete(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	client := srv.Client()
+	path := fmt.Sprintf("/api/series/%d", series.ID)
+
+	client.PUT(path).JSON(map[string]string{"title": "Renamed"}).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&series)
+	assert.Equal(t, "Renamed", series.Title)
+
+	client.DELETE(path).Do().ExpectStatus(http.StatusOK)
+	client.GET(path).Do().ExpectStatus(http.StatusNotFound)
+
+	var post struct {
+		models.Post
+		Series *models.SeriesNavigation
+	}
+	client.GET(fmt.Sprintf("/api/posts/%d", posts[0].ID)).Do().DecodeJSON(&post)
+	assert.Nil(t, post.Series)
+}
+
+func TestSeries_ListInclude(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	srv.SeedPosts(models.Post{Title: "Standalone", Content: "alone"})
+
+	var list []map[string]any
+	srv.Client().GET("/api/posts").Query("fields", "title").Query("include", "series").Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&list)
+	require.Len(t, list, 4)
+	assert.Equal(t, map[string]any{"ID": float64(series.ID), "Title": "Go Tutorial", "Position": float64(2)}, list[1]["Series"])
+	assert.Equal(t, float64(posts[1].ID), list[1]["ID"])
+	assert.Contains(t, list[3], "Series")
+	assert.Nil(t, list[3]["Series"])
+}
+
+// 連載の途中の回を下書きに戻すと、その回は連載の一覧とナビゲーションから外れる
+func TestSeries_SkipsDraftEntries(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	client := srv.Client()
+
+	client.PUT(fmt.Sprintf("/api/posts/%d", posts[1].ID)).JSON(map[string]any{"Draft": true}).Do().
+		ExpectStatus(http.StatusOK)
+
+	client.GET(fmt.Sprintf("/api/series/%d", series.ID)).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&series)
+	require.Len(t, series.Entries, 2)
+	assert.Equal(t, posts[2].ID, series.Entries[1].PostID)
+	assert.Equal(t, 2, series.Entries[1].Position)
+
+	var post struct {
+		models.Post
+		Series *models.SeriesNavigation
+	}
+	client.GET(fmt.Sprintf("/api/posts/%d", posts[0].ID)).Do().DecodeJSON(&post)
+	require.NotNil(t, post.Series)
+	assert.Equal(t, 2, post.Series.Total)
+	assert.Equal(t, posts[2].ID, post.Series.Next.ID)
+
+	client.GET(fmt.Sprintf("/api/posts/%d", posts[2].ID)).Do().DecodeJSON(&post)
+	require.NotNil(t, post.Series)
+	assert.Equal(t, 2, post.Series.Position)
+	assert.Equal(t, posts[0].ID, post.Series.Previous.ID)
+
+	withNav := client.GET(fmt.Sprintf("/api/posts/%d/render", posts[0].ID)).Query("series_nav", "true").Do().
+		ExpectStatus(http.StatusOK).Body()
+	assert.NotContains(t, withNav, fmt.Sprintf(`href="/posts/%d"`, posts[1].ID))
+	assert.NotContains(t, withNav, "Part 2")
+
+	var list []map[string]any
+	client.GET("/api/posts").Query("fields", "title").Query("include", "series").Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&list)
+	require.Len(t, list, 2)
+	assert.Equal(t, map[string]any{"ID": float64(series.ID), "Title": "Go Tutorial", "Position": float64(2)}, list[1]["Series"])
+}
+
+func TestSeries_ConditionalGet(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	client := srv.Client()
+	path := fmt.Sprintf("/api/series/%d", series.ID)
+
+	first := client.GET(path).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", middlewares.PublishedContentCache.Public)
+	etag := first.Header("ETag")
+	require.NotEmpty(t, etag)
+	client.GET(path).Header("If-None-Match", etag).Do().ExpectStatus(http.StatusNotModified)
+
+	// 各回の投稿の変更でも ETag が変わる
+	client.PUT(fmt.Sprintf("/api/posts/%d", posts[0].ID)).JSON(map[string]string{"Title": "Part One"}).Do().
+		ExpectStatus(http.StatusOK)
+	client.GET(path).Header("If-None-Match", etag).Do().ExpectStatus(http.StatusOK)
+
+	list := client.GET("/api/series").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", middlewares.PublishedContentCache.Public)
+	listETag := list.Header("ETag")
+	require.NotEmpty(t, listETag)
+	assert.Empty(t, list.Header("Last-Modified"))
+	client.GET("/api/series").Header("If-None-Match", listETag).Do().ExpectStatus(http.StatusNotModified)
+
+	client.DELETE(path).Do().ExpectStatus(http.StatusOK)
+	client.GET("/api/series").Header("If-None-Match", listETag).Do().ExpectStatus(http.StatusOK)
+}
+
+// 投稿の一覧のキャッシュは連載の変更で無効になる
+func TestSeries_InvalidatesPostListCache(t *testing.T) {
+	srv := apitest.NewServer(t)
+	series, posts := seedSeries(t, srv)
+	client := srv.Client()
+
+	listSeries := func() []map[string]any {
+		var list []map[string]any
+		client.GET("/api/posts").Query("fields", "title").Query("include", "series").Do().
+			ExpectStatus(http.StatusOK).
+			DecodeJSON(&list)
+		require.Len(t, list, 3)
+		return list
+	}
+	assert.Equal(t, "Go Tutorial", listSeries()[0]["Series"].(map[string]any)["Title"])
+
+	client.PUT(fmt.Sprintf("/api/series/%d", series.ID)).JSON(map[string]string{"title": "Renamed"}).Do().
+		ExpectStatus(http.StatusOK)
+	assert.Equal(t, "Renamed", listSeries()[0]["Series"].(map[string]any)["Title"])
+
+	client.PUT(fmt.Sprintf("/api/series/%d/posts", series.ID)).JSON(map[string][]uint{"post_ids": {posts[2].ID}}).Do().
+		ExpectStatus(http.StatusOK)
+	list := listSeries()
+	assert.Nil(t, list[0]["Series"])
+	assert.Equal(t, float64(1), list[2]["Series"].(map[string]any)["Position"])
+
+	client.DELETE(fmt.Sprintf("/api/series/%d", series.ID)).Do().ExpectStatus(http.StatusOK)
+	assert.Nil(t, listSeries()[2]["Series"])
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"blog/config"
+	"blog/controllers"
+	"blog/middlewares"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 各バージョンの API のパス。バージョンなしの /api は v1 の非推奨の別名
+const (
+	legacyPrefix = "/api"
+	v1Prefix     = "/api/v1"
+)
+
+// handlers は API のバージョンの間で共有するコントローラーとミドルウェア。
+// v2 を追加する場合は、同じサービスを使う v2 のコントローラーをここに加え、registerV2 で /api/v2 に登録する。
+// 変更のないエンドポイントは v1 のコントローラーをそのまま登録してよい。
+type handlers struct {
+	posts  *controllers.PostController
+	series *controllers.SeriesController
+	// adminToken が空の場合は管理 API (投稿の閲覧数、imports、backups と webhooks) を登録しない
+	adminToken string
+	imports    *controllers.ImportController
+	backups    *controllers.BackupController
+	webhooks   *controllers.WebhookController
+	limiters   *rateLimiters
+	countView  gin.HandlersChain
+	// identifyAdmin は管理トークンを持つリクエストに下書きも返す読み取りのルートに付ける
+	identifyAdmin gin.HandlersChain
+}
+
+// registerV1 は API v1 のルートを g に登録する
+func registerV1(g *gin.RouterGroup, h *handlers) {
+	posts := g.Group("/posts")
+	{
+		posts.GET("", middlewares.CacheControl(middlewares.PublishedContentCache), h.posts.GetAllPosts)
+		posts.GET("/popular", middlewares.CacheControl(middlewares.PublishedContentCache), h.posts.GetPopularPosts)
+		posts.GET("/:id", with(slices.Concat(h.identifyAdmin, h.countView), middlewares.CacheControl(middlewares.PublishedContentCache), h.posts.GetPostByID)...)
+		posts.POST("", with(h.limiters.write, h.posts.CreatePost)...)
+		posts.PUT("/:id", with(h.limiters.write, h.posts.UpdatePost)...)
+		posts.DELETE("/:id", with(h.limiters.write, h.posts.DeletePost)...)
+		posts.GET("/:id/related", middlewares.CacheControl(middlewares.PublishedContentCache), h.posts.GetRelatedPosts)
+		posts.GET("/:id/render", with(slices.Concat(h.identifyAdmin, h.limiters.render, h.countView), middlewares.CacheControl(middlewares.PublishedContentCache), h.posts.RenderMarkdown)...)
+	}
+
+	series := g.Group("/series")
+	{
+		series.GET("", middlewares.CacheControl(middlewares.PublishedContentCache), h.series.GetAllSeries)
+		series.GET("/:id", middlewares.CacheControl(middlewares.PublishedContentCache), h.series.GetSeriesByID)
+		series.POST("", with(h.limiters.write, h.series.CreateSeries)...)
+		series.PUT("/:id", with(h.limiters.write, h.series.UpdateSeries)...)
+		series.DELETE("/:id", with(h.limiters.write, h.series.DeleteSeries)...)
+		series.PUT("/:id/posts", with(h.limiters.write, h.series.SetSeriesPosts)...)
+	}
+
+	if h.adminToken != "" {
+		admin := g.Group("", middlewares.RequireAdminToken(h.adminToken), middlewares.CacheControl(middlewares.NoStoreCache))
+		{
+			// 閲覧数は著者向けのため公開しない
+			admin.GET("/posts/:id/stats", h.posts.GetPostStats)
+			admin.POST("/import", h.imports.Import)
+			admin.GET("/admin/export", h.backups.Export)
+			admin.GET("/admin/webhooks", h.webhooks.GetAllSubscriptions)
+			admin.POST("/admin/webhooks", h.webhooks.CreateSubscription)
+			admin.GET("/admin/webhooks/:id", h.webhooks.GetSubscription)
+			admin.PUT("/admin/webhooks/:id", h.webhooks.UpdateSubscription)
+			admin.DELETE("/admin/webhooks/:id", h.webhooks.DeleteSubscription)
+			admin.GET("/admin/webhooks/:id/deliveries", h.webhooks.GetDeliveries)
+			admin.GET("/admin/webhooks/:id/deliveries/:delivery", h.webhooks.GetDelivery)
+			admin.POST("/admin/webhooks/:id/deliveries/:delivery/redeliver", h.webhooks.Redeliver)
+		}
+	}
+}
+
+// legacyGroup はバージョンなしのパスのグループ。レスポンスに廃止予定と後継の /api/v1 のパスを付ける
+func legacyGroup(r *gin.Engine, cfg config.LegacyAPIConfig) *gin.RouterGroup {
+	successor := func(path string) string {
+		return v1Prefix + strings.TrimPrefix(path, legacyPrefix)
+	}
+	return r.Group(legacyPrefix, middlewares.Deprecated(cfg.DeprecatedAt, cfg.Sunset, successor))
+}
@@ -0,0 +1,80 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// visitor はブラウザの User-Agent を持つクライアントを返す。テストでは IP が同じため User-Agent で訪問者を区別する
+func visitor(srv *apitest.Server, name string) *apitest.Client {
+	return srv.Client().WithHeader("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) Firefox/131.0 "+name)
+}
+
+func TestPostViews_PopularAndStats(t *testing.T) {
+	srv := apitest.NewServer(t)
+	posts := srv.SeedPosts(
+		models.Post{Title: "Quiet", Content: "a"},
+		models.Post{Title: "Popular", Content: "b"},
+		models.Post{Title: "Deleted", Content: "c"},
+	)
+	alice, bob := visitor(srv, "alice"), visitor(srv, "bob")
+
+	for _, client := range []*apitest.Client{alice, bob} {
+		client.GET(fmt.Sprintf("/api/posts/%d", posts[1].ID)).Do().ExpectStatus(http.StatusOK)
+		client.GET(fmt.Sprintf("/api/posts/%d/render", posts[1].ID)).Do().ExpectStatus(http.StatusOK)
+		client.GET(fmt.Sprintf("/api/posts/%d", posts[2].ID)).Do().ExpectStatus(http.StatusOK)
+	}
+	alice.GET(fmt.Sprintf("/api/posts/%d", posts[0].ID)).Do().ExpectStatus(http.StatusOK)
+	// ボットと存在しない投稿は数えない
+	srv.Client().WithHeader("User-Agent", "Googlebot/2.1").GET(fmt.Sprintf("/api/posts/%d", posts[0].ID)).Do().ExpectStatus(http.StatusOK)
+	alice.GET("/api/posts/999").Do().ExpectStatus(http.StatusNotFound)
+	alice.DELETE(fmt.Sprintf("/api/posts/%d", posts[2].ID)).Do().ExpectStatus(http.StatusOK)
+
+	var popular []models.PopularPost
+	require.Eventually(t, func() bool {
+		popular = nil
+		alice.GET("/api/posts/popular").Query("window", "7d").Do().ExpectStatus(http.StatusOK).DecodeJSON(&popular)
+		return len(popular) == 2 && popular[0].Views == 2
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, posts[1].ID, popular[0].ID)
+	assert.Equal(t, "Popular", popular[0].Title)
+	assert.Equal(t, posts[0].ID, popular[1].ID)
+	assert.Equal(t, int64(1), popular[1].Views)
+
+	// 下書きに戻した投稿は人気の投稿に出さない
+	require.NoError(t, srv.DB.Model(&models.Post{}).Where("id = ?", posts[0].ID).Update("draft", true).Error)
+	alice.GET("/api/posts/popular").Query("window", "7d").Do().ExpectStatus(http.StatusOK).DecodeJSON(&popular)
+	require.Len(t, popular, 1)
+	assert.Equal(t, posts[1].ID, popular[0].ID)
+
+	// 閲覧数は管理トークンがないと取得できない
+	alice.GET(fmt.Sprintf("/api/posts/%d/stats", posts[1].ID)).Do().ExpectStatus(http.StatusUnauthorized)
+	var stats models.PostStats
+	srv.AdminClient().GET(fmt.Sprintf("/api/posts/%d/stats", posts[1].ID)).Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Cache-Control", "no-store").
+		DecodeJSON(&stats)
+	assert.Equal(t, int64(2), stats.Views)
+	require.Len(t, stats.Daily, 1)
+	assert.Equal(t, time.Now().UTC().Format("2006-01-02"), stats.Daily[0].Day)
+}
+
+func TestPostViews_Validation(t *testing.T) {
+	srv := apitest.NewServer(t)
+	posts := srv.SeedPosts(models.Post{Title: "Post", Content: "a"})
+	client := srv.Client()
+
+	client.GET("/api/posts/popular").Query("window", "7").Do().ExpectStatus(http.StatusBadRequest)
+	client.GET("/api/posts/popular").Query("window", "0d").Do().ExpectStatus(http.StatusBadRequest)
+	client.GET("/api/posts/popular").Query("limit", "100").Do().ExpectStatus(http.StatusBadRequest)
+	admin := srv.AdminClient()
+	admin.GET(fmt.Sprintf("/api/posts/%d/stats", posts[0].ID)).Query("window", "1000d").Do().ExpectStatus(http.StatusBadRequest)
+	admin.GET("/api/posts/999/stats").Do().ExpectStatus(http.StatusNotFound)
+}
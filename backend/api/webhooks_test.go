@@ -0,0 +1,218 @@
+package api_test
+
+import (
+	"blog/apitest"
+	"blog/jobs"
+	"blog/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedWebhook は受信側が受け取った Webhook
+type receivedWebhook struct {
+	header  http.Header
+	body    []byte
+	payload models.WebhookPayload
+}
+
+// webhookReceiver は受け取った Webhook を記録する送信先
+type webhookReceiver struct {
+	mu       sync.Mutex
+	received []receivedWebhook
+	// status は返すステータス
+	status int
+}
+
+func newWebhookReceiver(t *testing.T) (*webhookReceiver, string) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	srv := httptest.NewServer(receiver)
+	t.Cleanup(srv.Close)
+	return receiver, srv.URL
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	var payload models.WebhookPayload
+	json.Unmarshal(body, &payload)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, receivedWebhook{header: req.Header, body: body, payload: payload})
+	w.WriteHeader(r.status)
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// wait は n 件の Webhook を受け取るまで待って返す
+func (r *webhookReceiver) wait(t *testing.T, n int) []receivedWebhook {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.received) >= n
+	}, 5*time.Second, 10*time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.received...)
+}
+
+// webhookSubscription は登録のレスポンス
+type webhookSubscription struct {
+	models.WebhookSubscription
+	Secret string
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.AdminClient()
+
+	srv.Client().GET("/api/v1/admin/webhooks").Do().ExpectStatus(http.StatusUnauthorized)
+	client.POST("/api/v1/admin/webhooks").JSON(map[string]any{"URL": "ftp://example.com"}).Do().ExpectStatus(http.StatusBadRequest)
+	client.POST("/api/v1/admin/webhooks").JSON(map[string]any{"URL": "https://example.com", "Events": []string{"post.viewed"}}).Do().ExpectStatus(http.StatusBadRequest)
+
+	// 鍵を省略すると生成し、登録のレスポンスでだけ返す
+	var created webhookSubscription
+	client.POST("/api/v1/admin/webhooks").JSON(map[string]any{"URL": "https://example.com/hook", "Events": []string{"post.deleted", "post.created", "post.created"}}).Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&created)
+	assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, created.Secret)
+	assert.Equal(t, models.WebhookEvents{"post.created", "post.deleted"}, created.Events)
+	assert.True(t, created.Active)
+
+	path := fmt.Sprintf("/api/v1/admin/webhooks/%d", created.ID)
+	resp := client.GET(path).Do().ExpectStatus(http.StatusOK)
+	assert.NotContains(t, resp.Body(), created.Secret)
+
+	var updated models.WebhookSubscription
+	client.PUT(path).JSON(map[string]any{"URL": "https://example.com/v2", "Active": false}).Do().
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&updated)
+	assert.Equal(t, "https://example.com/v2", updated.URL)
+	assert.Empty(t, updated.Events)
+	assert.False(t, updated.Active)
+
+	var all []models.WebhookSubscription
+	client.GET("/api/v1/admin/webhooks").Do().ExpectStatus(http.StatusOK).DecodeJSON(&all)
+	require.Len(t, all, 1)
+	assert.Equal(t, created.ID, all[0].ID)
+
+	client.DELETE(path).Do().ExpectStatus(http.StatusOK)
+	client.GET(path).Do().ExpectStatus(http.StatusNotFound)
+	client.GET(path + "/deliveries").Do().ExpectStatus(http.StatusNotFound)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.AdminClient()
+	receiver, url := newWebhookReceiver(t)
+
+	var subscription webhookSubscription
+	client.POST("/api/v1/admin/webhooks").JSON(map[string]any{"URL": url, "Secret": "whsec_test"}).Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&subscription)
+	// post.deleted だけを受け取る送信先には作成を送らない
+	client.POST("/api/v1/admin/webhooks").JSON(map[string]any{"URL": url, "Events": []string{"post.deleted"}}).Do().
+		ExpectStatus(http.StatusCreated)
+
+	var post models.Post
+	client.POST("/api/v1/posts").JSON(map[string]any{"Title": "Hello", "Content": "body", "Draft": true}).Do().
+		ExpectStatus(http.StatusCreated).
+		DecodeJSON(&post)
+	client.PUT(fmt.Sprintf("/api/v1/posts/%d", post.ID)).JSON(map[string]any{"Title": "Hello", "Content": "body", "Draft": false}).Do().
+		ExpectStatus(http.StatusOK)
+
+	// 作成、更新、下書きの公開の順に送る
+	received := receiver.wait(t, 3)
+	require.Len(t, received, 3)
+	var types []string
+	for _, webhook := range received {
+		types = append(types, webhook.payload.Type)
+		assert.Equal(t, webhook.payload.Type, webhook.header.Get(jobs.WebhookEventHeader))
+		assert.Equal(t, webhook.payload.ID, webhook.header.Get(jobs.WebhookIDHeader))
+		assert.Equal(t, post.ID, webhook.payload.Post.ID)
+		timestamp := webhook.header.Get(jobs.WebhookTimestampHeader)
+		assert.Equal(t, jobs.Sign("whsec_test", timestamp, webhook.body), webhook.header.Get(jobs.WebhookSignatureHeader))
+	}
+	assert.ElementsMatch(t, []string{"post.created", "post.updated", "post.published"}, types)
+	assert.False(t, received[len(received)-1].payload.Post.Draft)
+
+	// 配信と配信ログ
+	deliveriesPath := fmt.Sprintf("/api/v1/admin/webhooks/%d/deliveries", subscription.ID)
+	var deliveries []models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		client.GET(deliveriesPath).Do().ExpectStatus(http.StatusOK).DecodeJSON(&deliveries)
+		for _, delivery := range deliveries {
+			if delivery.Status != models.WebhookSucceeded {
+				return false
+			}
+		}
+		return len(deliveries) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	deliveryPath := fmt.Sprintf("%s/%d", deliveriesPath, deliveries[0].ID)
+	var delivery models.WebhookDelivery
+	client.GET(deliveryPath).Do().ExpectStatus(http.StatusOK).DecodeJSON(&delivery)
+	require.Len(t, delivery.AttemptLog, 1)
+	assert.Equal(t, http.StatusOK, delivery.AttemptLog[0].StatusCode)
+
+	// 送り直すと同じイベントをもう一度送り、失敗した場合は再試行の上限まで再試行する
+	receiver.setStatus(http.StatusServiceUnavailable)
+	client.POST(deliveryPath + "/redeliver").Do().ExpectStatus(http.StatusAccepted)
+	redelivered := receiver.wait(t, 6)
+	assert.Len(t, redelivered, 6)
+	for _, webhook := range redelivered[3:] {
+		assert.Equal(t, delivery.EventID, webhook.header.Get(jobs.WebhookIDHeader))
+	}
+	require.Eventually(t, func() bool {
+		client.GET(deliveryPath).Do().ExpectStatus(http.StatusOK).DecodeJSON(&delivery)
+		return delivery.Status == models.WebhookFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+	assert.Len(t, delivery.AttemptLog, 4)
+
+	client.POST(fmt.Sprintf("%s/%d/redeliver", deliveriesPath, 999)).Do().ExpectStatus(http.StatusNotFound)
+}
+
+// 取り込みで作成・更新した投稿も Webhook で通知する
+func TestWebhookDelivery_Import(t *testing.T) {
+	srv := apitest.NewServer(t)
+	client := srv.AdminClient()
+	receiver, url := newWebhookReceiver(t)
+	client.POST("/api/v1/admin/webhooks").JSON(map[string]any{"URL": url}).Do().ExpectStatus(http.StatusCreated)
+
+	importPost := func(content string) models.ImportReport {
+		var report models.ImportReport
+		client.POST("/api/v1/import").
+			File("file", "2024-01-01-hello.md", []byte(content), map[string]string{"format": "jekyll"}).
+			Do().
+			ExpectStatus(http.StatusOK).
+			DecodeJSON(&report)
+		return report
+	}
+	created := importPost("---\ntitle: Hello\n---\nbody\n")
+	require.Equal(t, 1, created.Created, created.Results)
+	updated := importPost("---\ntitle: Hello again\n---\nbody\n")
+	require.Equal(t, 1, updated.Updated, updated.Results)
+
+	received := receiver.wait(t, 3)
+	require.Len(t, received, 3)
+	var types []string
+	for _, webhook := range received {
+		types = append(types, webhook.payload.Type)
+		assert.Equal(t, created.Results[0].PostID, webhook.payload.Post.ID)
+	}
+	assert.ElementsMatch(t, []string{"post.created", "post.published", "post.updated"}, types)
+}
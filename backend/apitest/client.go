@@ -0,0 +1,203 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Client はテストサーバーに対する流れるようなインターフェースの HTTP クライアント。
+// Cookie を保持するため、ログインしてから API を呼ぶようなセッションのフローも書ける。
+type Client struct {
+	t       testing.TB
+	baseURL string
+	http    *http.Client
+	headers http.Header
+}
+
+func newClient(t testing.TB, srv *httptest.Server) *Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("apitest: failed to create cookie jar: %v", err)
+	}
+	httpClient := srv.Client()
+	httpClient.Jar = jar
+	return &Client{t: t, baseURL: srv.URL, http: httpClient, headers: http.Header{}}
+}
+
+// WithHeader は以降の全リクエストにヘッダを付与するクライアントを返す
+func (c *Client) WithHeader(key, value string) *Client {
+	clone := *c
+	clone.headers = c.headers.Clone()
+	clone.headers.Set(key, value)
+	return &clone
+}
+
+// WithBearerToken は以降の全リクエストに Authorization: Bearer を付与するクライアントを返す
+func (c *Client) WithBearerToken(token string) *Client {
+	return c.WithHeader("Authorization", "Bearer "+token)
+}
+
+func (c *Client) GET(path string) *Request    { return c.newRequest(http.MethodGet, path) }
+func (c *Client) POST(path string) *Request   { return c.newRequest(http.MethodPost, path) }
+func (c *Client) PUT(path string) *Request    { return c.newRequest(http.MethodPut, path) }
+func (c *Client) DELETE(path string) *Request { return c.newRequest(http.MethodDelete, path) }
+
+func (c *Client) newRequest(method, path string) *Request {
+	return &Request{client: c, method: method, path: path, headers: c.headers.Clone(), query: url.Values{}}
+}
+
+// Request は送信前の HTTP リクエスト
+type Request struct {
+	client  *Client
+	method  string
+	path    string
+	headers http.Header
+	query   url.Values
+	body    io.Reader
+}
+
+// Header はリクエストヘッダを設定する
+func (r *Request) Header(key, value string) *Request {
+	r.headers.Set(key, value)
+	return r
+}
+
+// Query はクエリパラメータを追加する
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// JSON は値を JSON にエンコードしてボディに設定する
+func (r *Request) JSON(v any) *Request {
+	r.client.t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.client.t.Fatalf("apitest: failed to encode request body: %v", err)
+	}
+	r.body = bytes.NewReader(data)
+	r.headers.Set("Content-Type", "application/json")
+	return r
+}
+
+// File は multipart/form-data のボディに field のファイルと fields のフォーム値を設定する
+func (r *Request) File(field, filename string, data []byte, fields map[string]string) *Request {
+	r.client.t.Helper()
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	for key, value := range fields {
+		if err := form.WriteField(key, value); err != nil {
+			r.client.t.Fatalf("apitest: failed to encode form field: %v", err)
+		}
+	}
+	w, err := form.CreateFormFile(field, filename)
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		r.client.t.Fatalf("apitest: failed to encode multipart body: %v", err)
+	}
+	return r.Body(form.FormDataContentType(), &buf)
+}
+
+// Body は生のボディと Content-Type を設定する
+func (r *Request) Body(contentType string, body io.Reader) *Request {
+	r.body = body
+	r.headers.Set("Content-Type", contentType)
+	return r
+}
+
+// Do はリクエストを送信し、レスポンスを読み込んで返す
+func (r *Request) Do() *Response {
+	t := r.client.t
+	t.Helper()
+
+	target := r.client.baseURL + r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(r.path, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+
+	req, err := http.NewRequest(r.method, target, r.body)
+	if err != nil {
+		t.Fatalf("apitest: failed to build request: %v", err)
+	}
+	req.Header = r.headers
+
+	resp, err := r.client.http.Do(req)
+	if err != nil {
+		t.Fatalf("apitest: %s %s failed: %v", r.method, r.path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("apitest: failed to read response body: %v", err)
+	}
+	validateResponse(t, resp, body)
+	return &Response{t: t, req: r, Raw: resp, body: body}
+}
+
+// Response は受信済みの HTTP レスポンス
+type Response struct {
+	t    testing.TB
+	req  *Request
+	Raw  *http.Response
+	body []byte
+}
+
+// ExpectStatus はステータスコードを検証する
+func (r *Response) ExpectStatus(code int) *Response {
+	r.t.Helper()
+	if r.Raw.StatusCode != code {
+		r.t.Errorf("apitest: %s %s: expected status %d, got %d: %s", r.req.method, r.req.path, code, r.Raw.StatusCode, r.body)
+	}
+	return r
+}
+
+// ExpectHeader はレスポンスヘッダの値を検証する
+func (r *Response) ExpectHeader(key, value string) *Response {
+	r.t.Helper()
+	if got := r.Raw.Header.Get(key); got != value {
+		r.t.Errorf("apitest: %s %s: expected header %s=%q, got %q", r.req.method, r.req.path, key, value, got)
+	}
+	return r
+}
+
+// DecodeJSON はボディを JSON としてデコードする
+func (r *Response) DecodeJSON(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Fatalf("apitest: %s %s: failed to decode response %q: %v", r.req.method, r.req.path, r.body, err)
+	}
+	return r
+}
+
+// Status はステータスコードを返す
+func (r *Response) Status() int {
+	return r.Raw.StatusCode
+}
+
+// Header はレスポンスヘッダの値を返す
+func (r *Response) Header(key string) string {
+	return r.Raw.Header.Get(key)
+}
+
+// Body はボディを文字列で返す
+func (r *Response) Body() string {
+	return string(r.body)
+}
@@ -0,0 +1,186 @@
+// Package apitest は api.NewApp で構築したルーターとバックグラウンドジョブを実データベースに対して起動し、
+// ブラックボックスの API テストを書くためのハーネスを提供する。
+//
+// データベースは環境変数 TEST_DB で選択する。
+//   - "sqlite" (既定): インメモリの SQLite
+//   - "postgres": TEST_POSTGRES_DSN があればそのデータベースにテストごとのスキーマを作って使い、なければ
+//     PATH 上の initdb / pg_ctl で一時的な Postgres クラスタを起動する (見つからない場合はテストをスキップ)。
+//     TEST_POSTGRES_DSN のデータベース名には "test" を含める (blog_test など)
+package apitest
+
+import (
+	"blog/api"
+	"blog/config"
+	"blog/database"
+	"blog/models"
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminToken はテスト用サーバーの管理 API の Bearer トークン
+const AdminToken = "test-admin-token"
+
+// Server はテスト用に起動した API サーバー
+type Server struct {
+	t      testing.TB
+	DB     *gorm.DB
+	Config *config.Config
+	HTTP   *httptest.Server
+	// Router は api.NewApp が構築したルーター。登録したルートの確認に使う
+	Router *gin.Engine
+}
+
+// Option は Server の設定を変更する
+type Option func(*config.Config)
+
+// WithConfig は起動前に設定を変更する
+func WithConfig(fn func(cfg *config.Config)) Option {
+	return fn
+}
+
+// WithDriver はデータベースドライバ ("sqlite" または "postgres") を指定する
+func WithDriver(driver string) Option {
+	return func(cfg *config.Config) {
+		cfg.Database.Driver = driver
+	}
+}
+
+// NewServer はデータベースを用意してマイグレーションを適用し、ルーターとバックグラウンドジョブを起動する。
+// 起動したサーバーとデータベースはテスト終了時に破棄される。
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	switch cfg.Database.Driver {
+	case "sqlite":
+		cfg.Database.Path = ":memory:"
+	case "postgres":
+		if cfg.Database.URL == "" {
+			cfg.Database.URL = os.Getenv("TEST_POSTGRES_DSN")
+		}
+		if cfg.Database.URL == "" {
+			cfg.Database.URL = startPostgres(t)
+		} else {
+			cfg.Database.URL = IsolatePostgres(t, cfg.Database.URL)
+		}
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		t.Fatalf("apitest: failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("apitest: %v", err)
+	}
+
+	app, err := api.NewApp(db, cfg)
+	if err != nil {
+		t.Fatalf("apitest: failed to register routes: %v", err)
+	}
+
+	// バックグラウンドジョブはデータベースを閉じる前に停止する
+	ctx, cancel := context.WithCancel(context.Background())
+	app.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		app.Wait()
+	})
+
+	srv := httptest.NewServer(app.Router)
+	t.Cleanup(srv.Close)
+
+	return &Server{t: t, DB: db, Config: cfg, HTTP: srv, Router: app.Router}
+}
+
+// defaultConfig はテスト向けの設定を返す。レート制限は無効にしている
+func defaultConfig() *config.Config {
+	driver := os.Getenv("TEST_DB")
+	if driver == "" {
+		driver = "sqlite"
+	}
+	return &config.Config{
+		Database:  config.DatabaseConfig{Driver: driver},
+		DBTimeout: 5 * time.Second,
+		LogFormat: "text",
+		LogLevel:  "error",
+		Cache: config.CacheConfig{
+			Enabled:    true,
+			PostTTL:    time.Minute,
+			ListTTL:    time.Minute,
+			MaxEntries: 1000,
+		},
+		RelatedPosts: config.RelatedPostsConfig{
+			Enabled:  true,
+			Limit:    10,
+			Interval: 10 * time.Millisecond,
+		},
+		Views: config.ViewsConfig{
+			Enabled:       true,
+			FlushInterval: 10 * time.Millisecond,
+			MaxBuffered:   1000,
+		},
+		Admin: config.AdminConfig{
+			Token:         AdminToken,
+			Timeout:       time.Minute,
+			MaxUploadSize: 1 << 20,
+		},
+		Site: config.SiteConfig{
+			BaseURL:  "https://blog.example.com",
+			Title:    "Test Blog",
+			PageSize: 10,
+		},
+		GraphQL: config.GraphQLConfig{
+			MaxDepth:            8,
+			MaxComplexity:       2000,
+			PersistedMaxEntries: 100,
+			PersistedTTL:        time.Hour,
+		},
+		Webhooks: config.WebhooksConfig{
+			Enabled:      true,
+			PollInterval: 10 * time.Millisecond,
+			Timeout:      5 * time.Second,
+			MaxAttempts:  3,
+			BackoffBase:  10 * time.Millisecond,
+			BackoffMax:   50 * time.Millisecond,
+			BatchSize:    20,
+		},
+	}
+}
+
+// Client はサーバーに対する HTTP クライアントを返す
+func (s *Server) Client() *Client {
+	return newClient(s.t, s.HTTP)
+}
+
+// AdminClient は管理 API のトークンを付与するクライアントを返す
+func (s *Server) AdminClient() *Client {
+	return s.Client().WithBearerToken(AdminToken)
+}
+
+// SeedPosts はフィクスチャの投稿をデータベースに直接登録し、ID が割り当てられた投稿を返す
+func (s *Server) SeedPosts(posts ...models.Post) []models.Post {
+	s.t.Helper()
+	for i := range posts {
+		if err := s.DB.Create(&posts[i]).Error; err != nil {
+			s.t.Fatalf("apitest: failed to seed post %q: %v", posts[i].Title, err)
+		}
+	}
+	return posts
+}
@@ -0,0 +1,30 @@
+package apitest
+
+import (
+	"blog/openapi"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+var loadDocument = sync.OnceValues(openapi.Load)
+
+// noRouteBody はルートのないパスに Gin が返す 404 のボディ
+const noRouteBody = "404 page not found"
+
+// validateResponse はレスポンスが OpenAPI ドキュメント (openapi/openapi.yaml) に合うかを検証する。
+// Client の全てのリクエストで呼ぶため、ドキュメントにないステータスやフィールドを返すとテストが失敗する。
+// ルートが登録されていないパス (管理 API を無効にした場合など) への Gin の 404 は検証しない。
+func validateResponse(t testing.TB, resp *http.Response, body []byte) {
+	t.Helper()
+	if resp.StatusCode == http.StatusNotFound && string(body) == noRouteBody {
+		return
+	}
+	doc, err := loadDocument()
+	if err != nil {
+		t.Fatalf("apitest: %v", err)
+	}
+	if err := doc.ValidateResponse(resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, resp.Header.Get("Content-Type"), body); err != nil {
+		t.Errorf("apitest: response does not match the OpenAPI document: %v", err)
+	}
+}
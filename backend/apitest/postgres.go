@@ -0,0 +1,121 @@
+package apitest
+
+import (
+	"blog/config"
+	"blog/database"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// startPostgres は initdb / pg_ctl で一時的な Postgres クラスタを起動し、接続文字列を返す。
+// クラスタはテストごとに作って捨てるため、スキーマを分けずにそのまま使う
+func startPostgres(t testing.TB) string {
+	t.Helper()
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		t.Skip("apitest: initdb not found in PATH; set TEST_POSTGRES_DSN or TEST_DB=sqlite")
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		t.Skip("apitest: pg_ctl not found in PATH; set TEST_POSTGRES_DSN or TEST_DB=sqlite")
+	}
+
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput(); err != nil {
+		t.Fatalf("apitest: initdb failed: %v\n%s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("apitest: failed to find a free port: %v", err)
+	}
+
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -F", port, dir)
+	if out, err := exec.Command(pgCtl, "-D", dataDir, "-o", opts, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput(); err != nil {
+		t.Fatalf("apitest: pg_ctl start failed: %v\n%s", err, out)
+	}
+	t.Cleanup(func() {
+		exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "stop").Run()
+	})
+
+	return fmt.Sprintf("host=127.0.0.1 port=%d user=postgres dbname=postgres sslmode=disable", port)
+}
+
+// testDatabaseMarker は共有の Postgres の接続文字列のデータベース名に含める必要がある文字列。
+// 開発用や本番のデータベースを誤って指定した場合にテストを始めない
+const testDatabaseMarker = "test"
+
+// IsolatePostgres は dsn のデータベースにテストごとの一意なスキーマを作り、そのスキーマを search_path にした
+// 接続文字列を返す。スキーマはテスト終了時に削除し、他のスキーマのデータには触れない。
+// データベース名に "test" を含まない dsn はテストを失敗させる。
+func IsolatePostgres(t testing.TB, dsn string) string {
+	t.Helper()
+
+	db, err := database.Open(config.DatabaseConfig{Driver: "postgres", URL: dsn})
+	if err != nil {
+		t.Fatalf("apitest: failed to open database: %v", err)
+	}
+	closeDB := func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+
+	var name string
+	if err := db.Raw("SELECT current_database()").Scan(&name).Error; err != nil {
+		closeDB()
+		t.Fatalf("apitest: failed to get database name: %v", err)
+	}
+	if !strings.Contains(strings.ToLower(name), testDatabaseMarker) {
+		closeDB()
+		t.Fatalf("apitest: refusing to use database %q: the database name must contain %q", name, testDatabaseMarker)
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		closeDB()
+		t.Fatalf("apitest: failed to generate schema name: %v", err)
+	}
+	schema := "apitest_" + hex.EncodeToString(suffix)
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		closeDB()
+		t.Fatalf("apitest: failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		defer closeDB()
+		if err := db.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("apitest: failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	return withSearchPath(dsn, schema)
+}
+
+// withSearchPath は URL 形式とキー・値形式の接続文字列に search_path を設定する
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		query := u.Query()
+		query.Set("search_path", schema)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	return dsn + " search_path=" + schema
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,181 @@
+package backup
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ArchiveFormat はアーカイブの形式
+type ArchiveFormat string
+
+const (
+	Zip   ArchiveFormat = "zip"
+	TarGz ArchiveFormat = "tar.gz"
+)
+
+// ParseArchiveFormat は形式の名前を ArchiveFormat に変換する。空の場合は zip
+func ParseArchiveFormat(name string) (ArchiveFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "zip":
+		return Zip, nil
+	case "tar.gz", "tgz":
+		return TarGz, nil
+	}
+	return "", fmt.Errorf("%w: format %q", ErrUnsupportedArchive, name)
+}
+
+// ContentType は HTTP レスポンスの Content-Type
+func (f ArchiveFormat) ContentType() string {
+	if f == TarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// FileName はバックアップの既定のファイル名
+func FileName(format ArchiveFormat, createdAt time.Time) string {
+	return "blog-backup-" + createdAt.UTC().Format("20060102-150405") + "." + string(format)
+}
+
+// archiveWriter は zip と tar.gz の書き込みの違いを吸収する
+type archiveWriter interface {
+	write(name string, data []byte, modified time.Time) error
+	Close() error
+}
+
+func newArchiveWriter(w io.Writer, format ArchiveFormat) (*jsonArchiveWriter, error) {
+	switch format {
+	case Zip:
+		return &jsonArchiveWriter{&zipWriter{zip.NewWriter(w)}}, nil
+	case TarGz:
+		gz := gzip.NewWriter(w)
+		return &jsonArchiveWriter{&tarGzWriter{gz: gz, tar: tar.NewWriter(gz)}}, nil
+	}
+	return nil, fmt.Errorf("%w: format %q", ErrUnsupportedArchive, format)
+}
+
+// jsonArchiveWriter は JSON のファイルも書ける archiveWriter
+type jsonArchiveWriter struct {
+	archiveWriter
+}
+
+func (w *jsonArchiveWriter) writeJSON(name string, value any, modified time.Time) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return w.write(name, append(data, '\n'), modified)
+}
+
+type zipWriter struct {
+	zip *zip.Writer
+}
+
+func (w *zipWriter) write(name string, data []byte, modified time.Time) error {
+	f, err := w.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (w *zipWriter) Close() error {
+	return w.zip.Close()
+}
+
+type tarGzWriter struct {
+	gz  *gzip.Writer
+	tar *tar.Writer
+}
+
+func (w *tarGzWriter) write(name string, data []byte, modified time.Time) error {
+	err := w.tar.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  modified,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.tar.Write(data)
+	return err
+}
+
+func (w *tarGzWriter) Close() error {
+	return errors.Join(w.tar.Close(), w.gz.Close())
+}
+
+// readArchive はアーカイブ内の全てのファイルをパスごとに読み込む
+func readArchive(data []byte) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	switch {
+	case bytes.HasPrefix(data, []byte("PK")):
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnsupportedArchive, err)
+		}
+		for _, f := range archive.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+			}
+			files[f.Name] = content
+		}
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnsupportedArchive, err)
+		}
+		archive := tar.NewReader(gz)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrUnsupportedArchive, err)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			content, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+			}
+			files[header.Name] = content
+		}
+	default:
+		return nil, fmt.Errorf("%w: not a zip or tar.gz file", ErrUnsupportedArchive)
+	}
+	return files, nil
+}
+
+func decodeJSON(files map[string][]byte, name string, value any) error {
+	data, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: missing %s", ErrUnsupportedArchive, name)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Package backup はブログ全体のバックアップをアーカイブ (zip または tar.gz) に書き出し、読み込む。
+//
+// アーカイブの構成 (FormatVersion 1):
+//
+//	manifest.json        形式のバージョン、作成日時、件数
+//	posts/<ID>.md        投稿。Hugo と同じキーの YAML フロントマターと Markdown の本文
+//	tags.json            タグ
+//	series.json          連載と各回
+//	post_views.json      日別の閲覧数
+//	imported_posts.json  取り込み元の ID と投稿の対応
+//
+// JSON は API のレスポンスと同じく models の構造体をそのままエンコードする。
+// コメント、ユーザー、メディアファイルはこのブログに存在しないため含めない。
+package backup
+
+import (
+	"blog/frontmatter"
+	"blog/models"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVersion はアーカイブの構成のバージョン。互換性のない変更をしたら上げる
+const FormatVersion = 1
+
+const (
+	manifestFile = "manifest.json"
+	postsDir     = "posts/"
+	tagsFile     = "tags.json"
+	seriesFile   = "series.json"
+	viewsFile    = "post_views.json"
+	importsFile  = "imported_posts.json"
+)
+
+// ErrUnsupportedArchive は読み込めないアーカイブ (形式の違い、新しすぎるバージョン) を表す
+var ErrUnsupportedArchive = errors.New("unsupported backup archive")
+
+// Manifest はアーカイブの内容の説明
+type Manifest struct {
+	Version   int
+	CreatedAt time.Time
+	Posts     int
+	Tags      int
+	Series    int
+	Views     int
+	Imports   int
+}
+
+// postFrontMatter は投稿のフロントマター。posts/ をそのまま Hugo の importer でも読めるようにキーを揃えている
+type postFrontMatter struct {
+	ID          uint                `yaml:"id"`
+	Title       string              `yaml:"title"`
+	Author      string              `yaml:"author,omitempty"`
+	Tags        []string            `yaml:"tags,omitempty"`
+	Slug        string              `yaml:"slug,omitempty"`
+	Description string              `yaml:"description,omitempty"`
+	CoverImage  string              `yaml:"cover_image,omitempty"`
+	Draft       bool                `yaml:"draft,omitempty"`
+	Metadata    models.PostMetadata `yaml:"metadata,omitempty"`
+	Date        time.Time           `yaml:"date"`
+	Lastmod     time.Time           `yaml:"lastmod"`
+}
+
+// seriesRecord は series.json の連載。各回は投稿の ID と並び順だけを持つ
+type seriesRecord struct {
+	ID          uint
+	Title       string
+	Description string
+	Entries     []seriesEntryRecord
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type seriesEntryRecord struct {
+	PostID   uint
+	Position int
+}
+
+func toSeriesRecords(series []models.Series) []seriesRecord {
+	records := make([]seriesRecord, len(series))
+	for i, s := range series {
+		records[i] = seriesRecord{ID: s.ID, Title: s.Title, Description: s.Description, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt}
+		records[i].Entries = make([]seriesEntryRecord, len(s.Entries))
+		for j, entry := range s.Entries {
+			records[i].Entries[j] = seriesEntryRecord{PostID: entry.PostID, Position: entry.Position}
+		}
+	}
+	return records
+}
+
+func fromSeriesRecords(records []seriesRecord) []models.Series {
+	series := make([]models.Series, len(records))
+	for i, r := range records {
+		series[i] = models.Series{ID: r.ID, Title: r.Title, Description: r.Description, CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt}
+		for _, entry := range r.Entries {
+			series[i].Entries = append(series[i].Entries, models.SeriesEntry{SeriesID: r.ID, PostID: entry.PostID, Position: entry.Position})
+		}
+	}
+	return series
+}
+
+// Write は backup を format のアーカイブとして w に書き出す
+func Write(w io.Writer, format ArchiveFormat, backup *models.Backup, createdAt time.Time) error {
+	archive, err := newArchiveWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		Version:   FormatVersion,
+		CreatedAt: createdAt.UTC(),
+		Posts:     len(backup.Posts),
+		Tags:      len(backup.Tags),
+		Series:    len(backup.Series),
+		Views:     len(backup.Views),
+		Imports:   len(backup.Imports),
+	}
+	files := []struct {
+		name  string
+		value any
+	}{
+		{manifestFile, manifest},
+		{tagsFile, backup.Tags},
+		{seriesFile, toSeriesRecords(backup.Series)},
+		{viewsFile, backup.Views},
+		{importsFile, backup.Imports},
+	}
+	for _, file := range files {
+		if err := archive.writeJSON(file.name, file.value, createdAt); err != nil {
+			return err
+		}
+	}
+
+	for _, post := range backup.Posts {
+		matter := postFrontMatter{
+			ID:          post.ID,
+			Title:       post.Title,
+			Author:      post.Author,
+			Slug:        post.Slug,
+			Description: post.Description,
+			CoverImage:  post.CoverImage,
+			Draft:       post.Draft,
+			Metadata:    post.Metadata,
+			Date:        post.CreatedAt.UTC(),
+			Lastmod:     post.UpdatedAt.UTC(),
+		}
+		for _, tag := range post.Tags {
+			matter.Tags = append(matter.Tags, tag.Name)
+		}
+		src, err := frontmatter.Format(matter, post.Content)
+		if err != nil {
+			return err
+		}
+		if err := archive.write(postFileName(post.ID), []byte(src), post.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func postFileName(id uint) string {
+	return postsDir + strconv.FormatUint(uint64(id), 10) + ".md"
+}
+
+// Read はアーカイブを読み込む。形式 (zip または tar.gz) は中身から判断する
+func Read(data []byte) (*models.Backup, *Manifest, error) {
+	files, err := readArchive(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var manifest Manifest
+	if err := decodeJSON(files, manifestFile, &manifest); err != nil {
+		return nil, nil, err
+	}
+	if manifest.Version < 1 || manifest.Version > FormatVersion {
+		return nil, nil, fmt.Errorf("%w: version %d is not supported (supported up to %d)", ErrUnsupportedArchive, manifest.Version, FormatVersion)
+	}
+
+	var (
+		backup models.Backup
+		series []seriesRecord
+	)
+	for _, file := range []struct {
+		name  string
+		value any
+	}{
+		{tagsFile, &backup.Tags},
+		{seriesFile, &series},
+		{viewsFile, &backup.Views},
+		{importsFile, &backup.Imports},
+	} {
+		if err := decodeJSON(files, file.name, file.value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	backup.Series = fromSeriesRecords(series)
+
+	tags := make(map[string]models.Tag, len(backup.Tags))
+	for _, tag := range backup.Tags {
+		tags[tag.Name] = tag
+	}
+	for name, data := range files {
+		if !strings.HasPrefix(name, postsDir) || path.Ext(name) != ".md" {
+			continue
+		}
+		post, err := readPost(name, string(data), tags)
+		if err != nil {
+			return nil, nil, err
+		}
+		backup.Posts = append(backup.Posts, *post)
+	}
+	slices.SortFunc(backup.Posts, func(a, b models.Post) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	if len(backup.Posts) != manifest.Posts {
+		return nil, nil, fmt.Errorf("%w: manifest lists %d posts but the archive contains %d", ErrUnsupportedArchive, manifest.Posts, len(backup.Posts))
+	}
+	return &backup, &manifest, nil
+}
+
+// readPost は posts/<ID>.md を投稿に戻す。タグは tags.json の ID に対応付ける
+func readPost(name, src string, tags map[string]models.Tag) (*models.Post, error) {
+	matter, body, err := frontmatter.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if matter == nil {
+		return nil, fmt.Errorf("%s: missing front matter", name)
+	}
+	id, err := strconv.ParseUint(matter.String("id"), 10, 0)
+	if err != nil || id == 0 {
+		return nil, fmt.Errorf("%s: invalid id %q", name, matter.String("id"))
+	}
+
+	// Matter.String は前後の空白を除くため、保存されていた値をそのまま戻せるよう文字列は直接取り出す
+	title, _ := matter["title"].(string)
+	author, _ := matter["author"].(string)
+	slug, _ := matter["slug"].(string)
+	description, _ := matter["description"].(string)
+	coverImage, _ := matter["cover_image"].(string)
+	post := &models.Post{
+		ID:          uint(id),
+		Title:       title,
+		Author:      author,
+		Slug:        slug,
+		Description: description,
+		CoverImage:  coverImage,
+		Content:     body,
+	}
+	post.Draft, _ = matter.Bool("draft")
+	if metadata, ok := matter["metadata"].(map[string]any); ok {
+		post.Metadata = metadata
+	}
+	post.CreatedAt, _ = matter.Time("date")
+	post.UpdatedAt, _ = matter.Time("lastmod")
+	for _, tagName := range matter.Strings("tags") {
+		tag, ok := tags[tagName]
+		if !ok {
+			return nil, fmt.Errorf("%s: tag %q is not in %s", name, tagName, tagsFile)
+		}
+		post.Tags = append(post.Tags, tag)
+	}
+	return post, nil
+}
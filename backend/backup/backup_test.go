@@ -0,0 +1,149 @@
+package backup_test
+
+import (
+	"archive/zip"
+	"blog/backup"
+	"blog/config"
+	"blog/database"
+	"blog/importer"
+	"blog/models"
+	"blog/repositories"
+	"blog/services"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupSQLite(t *testing.T) *gorm.DB {
+	db, err := database.Open(config.DatabaseConfig{Driver: "sqlite", Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate SQLite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// seed は投稿・タグ・連載・閲覧数・取り込みの対応を一通り作成する
+func seed(t *testing.T, uow repositories.UnitOfWork) {
+	ctx := context.Background()
+	posts := services.NewPostServiceWithUnitOfWork(uow)
+
+	first := &models.Post{Title: "First", Content: "# Hello\n\nWorld", Author: "taro", Tags: []models.Tag{{Name: "Go"}, {Name: "web"}}}
+	second := &models.Post{Title: "  2024  ", Content: "---\nslug: second\ndescription: Second post\ncover_image: /c.png\ndraft: true\nextra: {nested: [1, 2]}\n---\n\n\nstarts with blank lines\n"}
+	deleted := &models.Post{Title: "Deleted", Content: "gone", Tags: []models.Tag{{Name: "go"}}}
+	for _, post := range []*models.Post{first, second, deleted} {
+		require.NoError(t, posts.CreatePost(ctx, post))
+	}
+	require.NoError(t, uow.Repositories().Views.Increment(ctx, []models.PostView{
+		{PostID: first.ID, Day: "2024-01-01", Views: 3},
+		{PostID: deleted.ID, Day: "2024-01-01", Views: 1},
+	}))
+	require.NoError(t, posts.DeletePost(ctx, deleted.ID))
+
+	series := services.NewSeriesService(uow)
+	s := &models.Series{Title: "Series", Description: "A series"}
+	require.NoError(t, series.CreateSeries(ctx, s))
+	_, err := series.SetSeriesPosts(ctx, s.ID, []uint{second.ID, first.ID})
+	require.NoError(t, err)
+
+	report, err := services.NewImportService(uow).Import(ctx, importer.FormatHugo, []importer.Entry{
+		{SourceID: "posts/imported", Title: "Imported", Content: "imported", CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Created)
+}
+
+// normalize は比較のために日時を UTC のマイクロ秒に揃える
+func normalize(b *models.Backup) *models.Backup {
+	ts := func(t time.Time) time.Time { return t.UTC().Truncate(time.Microsecond) }
+	for i := range b.Posts {
+		b.Posts[i].CreatedAt, b.Posts[i].UpdatedAt = ts(b.Posts[i].CreatedAt), ts(b.Posts[i].UpdatedAt)
+	}
+	for i := range b.Series {
+		b.Series[i].CreatedAt, b.Series[i].UpdatedAt = ts(b.Series[i].CreatedAt), ts(b.Series[i].UpdatedAt)
+	}
+	for i := range b.Imports {
+		b.Imports[i].ImportedAt = ts(b.Imports[i].ImportedAt)
+	}
+	return b
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []backup.ArchiveFormat{backup.Zip, backup.TarGz} {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := context.Background()
+			source := repositories.NewUnitOfWork(setupSQLite(t))
+			seed(t, source)
+
+			exported, err := services.NewBackupService(source).Export(ctx)
+			require.NoError(t, err)
+			require.Len(t, exported.Posts, 3, "deleted posts are not exported")
+			require.Len(t, exported.Views, 1)
+			require.Equal(t, "second", exported.Posts[1].Slug)
+			require.NotEmpty(t, exported.Posts[1].Metadata)
+
+			var archive bytes.Buffer
+			require.NoError(t, backup.Write(&archive, format, exported, time.Now()))
+
+			read, manifest, err := backup.Read(archive.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, backup.FormatVersion, manifest.Version)
+			assert.Equal(t, 3, manifest.Posts)
+
+			target := repositories.NewUnitOfWork(setupSQLite(t))
+			require.NoError(t, services.NewBackupService(target).Restore(ctx, read))
+
+			restored, err := services.NewBackupService(target).Export(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, normalize(exported), normalize(restored))
+
+			// 復元した後に作成した投稿は既存の ID と重ならない
+			post := &models.Post{Title: "After restore"}
+			require.NoError(t, services.NewPostServiceWithUnitOfWork(target).CreatePost(ctx, post))
+			assert.Greater(t, post.ID, exported.Posts[len(exported.Posts)-1].ID)
+
+			// 取り込みの対応も復元されるため、同じ取り込みを再実行しても重複しない
+			report, err := services.NewImportService(target).Import(ctx, importer.FormatHugo, []importer.Entry{
+				{SourceID: "posts/imported", Title: "Imported", Content: "imported"},
+			}, true)
+			require.NoError(t, err)
+			assert.Equal(t, 1, report.Unchanged)
+		})
+	}
+}
+
+func TestRestore_RequiresEmptyDatabase(t *testing.T) {
+	uow := repositories.NewUnitOfWork(setupSQLite(t))
+	seed(t, uow)
+
+	err := services.NewBackupService(uow).Restore(context.Background(), &models.Backup{})
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	_, _, err := backup.Read([]byte("not an archive"))
+	assert.ErrorIs(t, err, backup.ErrUnsupportedArchive)
+
+	// 新しいバージョンのアーカイブは読み込まない
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("manifest.json")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(`{"Version": 99}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, _, err = backup.Read(buf.Bytes())
+	assert.ErrorIs(t, err, backup.ErrUnsupportedArchive)
+}
@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Backend はキャッシュの保存先。
+// 複数レプリカで共有する場合は Redis や Memcached などでこのインターフェースを実装する。
+type Backend interface {
+	// Get はキーに対応する値を返す。存在しない場合は ok が false になる
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set は値を TTL 付きで保存する
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete は指定したキーを削除する
+	Delete(ctx context.Context, keys ...string) error
+	// Incr はキーの整数値に delta を足して返す (Redis の INCRBY)。存在しない場合は 0 から足す。
+	// delta が 0 の場合は値を読むだけになる。カウンターは期限なしで保存し、容量の上限による削除の対象にしない
+	Incr(ctx context.Context, key string, delta int64) (int64, error)
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory はプロセス内に値を保持する Backend。単一インスタンス向け。
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	counters   map[string]int64
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemory は Memory を生成する。maxEntries が 0 以下の場合は上限なし
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		entries:    make(map[string]memoryEntry),
+		counters:   make(map[string]int64),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.entries[key]; !exists && m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		m.evict()
+	}
+	m.entries[key] = memoryEntry{value: value, expiresAt: m.now().Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *Memory) Incr(_ context.Context, key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[key] += delta
+	return m.counters[key], nil
+}
+
+// evict は期限切れのエントリを削除し、それでも上限に達している場合は任意の 1 件を削除する
+func (m *Memory) evict() {
+	now := m.now()
+	for key, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+	if len(m.entries) < m.maxEntries {
+		return
+	}
+	for key := range m.entries {
+		delete(m.entries, key)
+		return
+	}
+}
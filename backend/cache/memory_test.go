@@ -0,0 +1,72 @@
+package cache_test
+
+import (
+	"blog/cache"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := cache.NewMemory(0)
+
+	assert.NoError(t, m.Set(ctx, "post:1", []byte("value"), time.Minute))
+	value, ok, err := m.Get(ctx, "post:1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), value)
+
+	assert.NoError(t, m.Delete(ctx, "post:1"))
+	_, ok, _ = m.Get(ctx, "post:1")
+	assert.False(t, ok)
+}
+
+func TestMemory_Expiry(t *testing.T) {
+	ctx := context.Background()
+	m := cache.NewMemory(0)
+
+	assert.NoError(t, m.Set(ctx, "post:1", []byte("value"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok, err := m.Get(ctx, "post:1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMemory_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	m := cache.NewMemory(2)
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.NoError(t, m.Set(ctx, key, []byte(key), time.Minute))
+	}
+
+	count := 0
+	for _, key := range []string{"a", "b", "c"} {
+		if _, ok, _ := m.Get(ctx, key); ok {
+			count++
+		}
+	}
+	assert.Equal(t, 2, count)
+}
+
+func TestMemory_Incr(t *testing.T) {
+	ctx := context.Background()
+	m := cache.NewMemory(1)
+
+	value, err := m.Incr(ctx, "generation", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), value)
+	m.Incr(ctx, "generation", 1)
+	value, _ = m.Incr(ctx, "generation", 1)
+	assert.Equal(t, int64(2), value)
+
+	// カウンターは容量の上限で消えない
+	assert.NoError(t, m.Set(ctx, "post:1", []byte("value"), time.Minute))
+	assert.NoError(t, m.Set(ctx, "post:2", []byte("value"), time.Minute))
+	value, _ = m.Incr(ctx, "generation", 0)
+	assert.Equal(t, int64(2), value)
+}
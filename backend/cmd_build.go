@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"blog/config"
+	"blog/repositories"
+	"blog/services"
+	"blog/site"
+)
+
+// runBuild は build サブコマンドを実行する。
+//
+//	blog build [-o <出力ディレクトリ>] [-full]
+//
+// 公開されている投稿を静的サイトとして書き出す。URL やタイトルは SITE_* の環境変数で設定する。
+// 前回と同じディレクトリに書き出す場合は変更のあった投稿だけを描画し直す。
+func runBuild(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	output := flags.String("o", "public", "output directory")
+	full := flags.Bool("full", false, "render every post even if it has not changed since the last build")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: blog build [-o <directory>] [-full]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := openMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	builder, err := site.NewBuilder(services.NewPostServiceWithUnitOfWork(repositories.NewUnitOfWork(db)), cfg.Site, *output)
+	if err != nil {
+		return err
+	}
+	result, err := builder.Build(ctx, *full)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d posts rendered, %d unchanged; %d files written, %d removed in %s\n",
+		result.Rendered, result.Unchanged, result.Written, result.Removed, *output)
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"blog/backup"
+	"blog/config"
+	"blog/models"
+	"blog/repositories"
+	"blog/services"
+)
+
+// runExport は export サブコマンドを実行する。
+//
+//	blog export [-format zip|tar.gz] [-o <ファイル>]
+//
+// -o を省略した場合は blog-backup-<日時>.<形式> に、- の場合は標準出力に書き出す。
+// 書き出したアーカイブは blog import -from-backup で空のデータベースに復元できる。
+func runExport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := flags.String("format", string(backup.Zip), "archive format (zip or tar.gz)")
+	output := flags.String("o", "", "output file, or - for stdout (default blog-backup-<timestamp>.<format>)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: blog export [-format zip|tar.gz] [-o <file>]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	format, err := backup.ParseArchiveFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := openMigrated(cfg)
+	if err != nil {
+		return err
+	}
+
+	data, err := services.NewBackupService(repositories.NewUnitOfWork(db)).Export(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if *output == "-" {
+		return backup.Write(os.Stdout, format, data, now)
+	}
+	name := *output
+	if name == "" {
+		name = backup.FileName(format, now)
+	}
+	if err := writeBackupFile(name, format, data, now); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d posts, %d tags, %d series to %s\n", len(data.Posts), len(data.Tags), len(data.Series), name)
+	return nil
+}
+
+// writeBackupFile はアーカイブをファイルに書き出す。失敗した場合は途中まで書いたファイルを残さない
+func writeBackupFile(name string, format backup.ArchiveFormat, data *models.Backup, createdAt time.Time) (err error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = errors.Join(err, f.Close()); err != nil {
+			os.Remove(name)
+		}
+	}()
+	return backup.Write(f, format, data, createdAt)
+}
+
+// restoreBackup はアーカイブを空のデータベースに復元する
+func restoreBackup(ctx context.Context, service services.BackupService, path string, w io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	restored, manifest, err := backup.Read(data)
+	if err != nil {
+		return err
+	}
+	if err := service.Restore(ctx, restored); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "restored %d posts, %d tags, %d series, %d view counts, %d import mappings (archive created at %s)\n",
+		manifest.Posts, manifest.Tags, manifest.Series, manifest.Views, manifest.Imports, manifest.CreatedAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"blog/config"
+	"blog/database"
+	"blog/importer"
+	"blog/models"
+	"blog/repositories"
+	"blog/services"
+
+	"gorm.io/gorm"
+)
+
+// runImport は import サブコマンドを実行する。
+//
+//	blog import -format wordpress [-dry-run] [-json] <ファイル、ディレクトリまたは zip>
+//	blog import -from-backup <blog export で書き出したアーカイブ>
+//
+// 実行中のサーバーのキャッシュと関連記事には通知しないため、キャッシュの TTL の経過か
+// サーバーの再起動 (関連記事は起動時に全て再計算する) の後に反映される。
+func runImport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatName := flags.String("format", "", fmt.Sprintf("export format %v", importer.Formats))
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without saving anything")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	fromBackup := flags.Bool("from-backup", false, "restore an archive written by blog export into an empty database")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: blog import -format <format> [-dry-run] [-json] <file, directory or zip>")
+		fmt.Fprintln(flags.Output(), "       blog import -from-backup <archive>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one path, got %d", flags.NArg())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *fromBackup {
+		if *formatName != "" || *dryRun {
+			return fmt.Errorf("-format and -dry-run cannot be used with -from-backup")
+		}
+		db, err := openMigrated(cfg)
+		if err != nil {
+			return err
+		}
+		return restoreBackup(ctx, services.NewBackupService(repositories.NewUnitOfWork(db)), flags.Arg(0), os.Stdout)
+	}
+
+	format, err := importer.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	entries, err := readImportPath(format, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	db, err := openMigrated(cfg)
+	if err != nil {
+		return err
+	}
+
+	report, err := services.NewImportService(repositories.NewUnitOfWork(db)).Import(ctx, format, entries, *dryRun)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printImportReport(os.Stdout, report)
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d posts failed to import", report.Failed)
+	}
+	return nil
+}
+
+// openMigrated はデータベースに接続し、マイグレーションを実行する
+func openMigrated(cfg *config.Config) (*gorm.DB, error) {
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.Migrate(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// readImportPath はファイル、ディレクトリまたは zip を読み込む
+func readImportPath(format importer.Format, path string) ([]importer.Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return importer.ReadFS(format, os.DirFS(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return importer.ReadFile(format, path, data)
+}
+
+func printImportReport(w io.Writer, report *models.ImportReport) {
+	for _, result := range report.Results {
+		line := fmt.Sprintf("%-9s %s %q", result.Action, result.SourceID, result.Title)
+		if result.PostID != 0 {
+			line += fmt.Sprintf(" (post %d)", result.PostID)
+		}
+		if result.Reason != "" {
+			line += ": " + result.Reason
+		}
+		fmt.Fprintln(w, line)
+	}
+	if report.DryRun {
+		fmt.Fprint(w, "dry run: ")
+	}
+	fmt.Fprintf(w, "%d created, %d updated, %d unchanged, %d skipped, %d failed\n",
+		report.Created, report.Updated, report.Unchanged, report.Skipped, report.Failed)
+}
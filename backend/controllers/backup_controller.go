@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"blog/backup"
+	"blog/services"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BackupController struct {
+	service services.BackupService
+	// timeout はエクスポート全体のタイムアウト。リクエストの DBTimeout より長い時間がかかるため別に設定する
+	timeout time.Duration
+}
+
+func NewBackupController(service services.BackupService, timeout time.Duration) *BackupController {
+	return &BackupController{service: service, timeout: timeout}
+}
+
+// ブログ全体をアーカイブとしてダウンロードする。format に zip (既定) または tar.gz を指定する
+func (c *BackupController) Export(ctx *gin.Context) {
+	format, err := backup.ParseArchiveFormat(ctx.Query("format"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format: " + ctx.Query("format")})
+		return
+	}
+
+	// リクエストの DBTimeout ではなく管理操作のタイムアウトで読み込む
+	exportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx.Request.Context()), c.timeout)
+	defer cancel()
+	data, err := c.service.Export(exportCtx)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, "Failed to export", err)
+		return
+	}
+
+	// 書き出しに失敗した場合にエラーを返せるよう、メモリ上で作成してから送る
+	now := time.Now()
+	var archive bytes.Buffer
+	if err := backup.Write(&archive, format, data, now); err != nil {
+		respondError(ctx, http.StatusInternalServerError, "Failed to export", err)
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", backup.FileName(format, now)))
+	ctx.Data(http.StatusOK, format.ContentType(), archive.Bytes())
+}
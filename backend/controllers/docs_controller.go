@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"blog/httpcache"
+	"blog/openapi"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsController は API の OpenAPI ドキュメントとその UI を返す
+type DocsController struct {
+	doc *openapi.Document
+}
+
+func NewDocsController(doc *openapi.Document) *DocsController {
+	return &DocsController{doc: doc}
+}
+
+// OpenAPI 3.1 ドキュメント (/openapi.json)
+func (c *DocsController) Spec(ctx *gin.Context) {
+	data := c.doc.JSON()
+	if httpcache.NotModified(ctx, httpcache.StrongETag(data), time.Time{}) {
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// ドキュメントを表示する UI (/docs)
+func (c *DocsController) UI(ctx *gin.Context) {
+	ctx.Header("Content-Security-Policy", openapi.DocsContentSecurityPolicy)
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", openapi.DocsPage())
+}
+
+// UI のスクリプト (/docs.js)
+func (c *DocsController) Script(ctx *gin.Context) {
+	data := openapi.DocsScript()
+	if httpcache.NotModified(ctx, httpcache.StrongETag(data), time.Time{}) {
+		return
+	}
+	ctx.Data(http.StatusOK, "text/javascript; charset=utf-8", data)
+}
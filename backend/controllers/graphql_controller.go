@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"blog/graph"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGraphQLRequestSize は GraphQL のリクエストのボディの最大サイズ (バイト)
+const maxGraphQLRequestSize = 1 << 20
+
+// GraphQLController は GraphQL のリクエストを実行する
+type GraphQLController struct {
+	executor *graph.Executor
+}
+
+func NewGraphQLController(executor *graph.Executor) *GraphQLController {
+	return &GraphQLController{executor: executor}
+}
+
+// GraphQL のクエリを実行する (/graphql)。
+// POST は JSON ({"query", "operationName", "variables", "extensions"}) で、GET は同じ名前のクエリパラメータで受け付ける
+// (variables と extensions は JSON)。GET で永続化クエリのハッシュだけを送ると、レスポンスを CDN でキャッシュできる。
+// クエリの誤りやリゾルバのエラーは 200 の errors で返す。
+func (c *GraphQLController) Query(ctx *gin.Context) {
+	var req graph.Request
+	if ctx.Request.Method == http.MethodGet {
+		req.Query = ctx.Query("query")
+		req.OperationName = ctx.Query("operationName")
+		for name, dest := range map[string]any{"variables": &req.Variables, "extensions": &req.Extensions} {
+			if value := ctx.Query(name); value != "" {
+				if err := json.Unmarshal([]byte(value), dest); err != nil {
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+					return
+				}
+			}
+		}
+	} else {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxGraphQLRequestSize)
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request is too large"})
+				return
+			}
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	if req.Query == "" && req.Extensions.PersistedQuery == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing query"})
+		return
+	}
+
+	result := c.executor.Execute(ctx.Request.Context(), req)
+	if result.HasErrors() {
+		// 永続化クエリの未登録やリゾルバの一時的なエラーをキャッシュさせない
+		ctx.Header("Cache-Control", "no-store")
+	}
+	ctx.JSON(http.StatusOK, result)
+}
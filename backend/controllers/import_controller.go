@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"blog/importer"
+	"blog/services"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportController struct {
+	service services.ImportService
+	// timeout は取り込み全体のタイムアウト。リクエストの DBTimeout より長い時間がかかるため別に設定する
+	timeout       time.Duration
+	maxUploadSize int64
+}
+
+func NewImportController(service services.ImportService, timeout time.Duration, maxUploadSize int64) *ImportController {
+	return &ImportController{service: service, timeout: timeout, maxUploadSize: maxUploadSize}
+}
+
+// 他のブログのエクスポートを取り込む。
+// multipart/form-data の file に WXR の XML、Qiita API の JSON、Markdown、またはそれらをまとめた zip を、
+// format に形式 (wordpress, hugo, jekyll, qiita, zenn) を指定する。dry_run=true の場合は保存せずに結果だけを返す。
+func (c *ImportController) Import(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxUploadSize)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Uploaded file is too large"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing file"})
+		return
+	}
+
+	format, err := importer.ParseFormat(ctx.Request.FormValue("format"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format: " + ctx.Request.FormValue("format")})
+		return
+	}
+	dryRun := false
+	if value := ctx.Request.FormValue("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run"})
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, "Failed to read file", err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, "Failed to read file", err)
+		return
+	}
+
+	// zip のファイル数と展開後のサイズは importer.DefaultLimits で制限する
+	entries, err := importer.ReadFile(format, header.Filename, data)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", services.ErrInvalidInput, err)
+		respondError(ctx, writeErrorStatus(err), err.Error(), err)
+		return
+	}
+
+	// リクエストの DBTimeout ではなく管理操作のタイムアウトで取り込む
+	importCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx.Request.Context()), c.timeout)
+	defer cancel()
+	report, err := c.service.Import(importCtx, format, entries, dryRun)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
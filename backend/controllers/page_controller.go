@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"blog/httpcache"
+	"blog/logging"
+	"blog/middlewares"
+	"blog/models"
+	"blog/repositories"
+	"blog/services"
+	"blog/site"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageController は公開サイトのページ (一覧、投稿、フィード) をテーマで描画して返す。
+// URL は静的サイトの書き出し (site.Builder) と同じ。
+type PageController struct {
+	service  services.PostService
+	renderer *site.Renderer
+}
+
+func NewPageController(service services.PostService, renderer *site.Renderer) *PageController {
+	return &PageController{service: service, renderer: renderer}
+}
+
+// pageListQuery は一覧とパスの決定に読み込むフィールドと関連。本文は投稿のページでだけ読み込む
+var pageListQuery = services.PostQuery{
+	Fields:  slices.DeleteFunc(slices.Clone(services.PostFields), func(field string) bool { return field == "content" || field == "metadata" }),
+	Include: []string{repositories.IncludeTags, repositories.IncludeSeries},
+}
+
+// 公開されている投稿を新しい順に読み込み、投稿ごとのパスを決める
+func (c *PageController) published(ctx *gin.Context) ([]models.Post, site.Paths, bool) {
+	posts, err := c.service.GetAllPosts(ctx.Request.Context(), pageListQuery)
+	if err != nil {
+		respondPageError(ctx, http.StatusInternalServerError, err)
+		return nil, site.Paths{}, false
+	}
+	posts = site.Published(posts)
+	return posts, site.NewPaths(posts), true
+}
+
+// トップページ (/, /page/:page)
+func (c *PageController) Index(ctx *gin.Context) {
+	posts, paths, ok := c.published(ctx)
+	if !ok {
+		return
+	}
+	c.renderList(ctx, paths, site.TopList(posts))
+}
+
+// タグの一覧 (/tags/:tag, /tags/:tag/page/:page)
+func (c *PageController) Tag(ctx *gin.Context) {
+	posts, paths, ok := c.published(ctx)
+	if !ok {
+		return
+	}
+	list, ok := site.TagList(posts, ctx.Param("tag"))
+	if !ok {
+		respondPageError(ctx, http.StatusNotFound, nil)
+		return
+	}
+	c.renderList(ctx, paths, list)
+}
+
+// 月別アーカイブ (/archive/:year/:month, /archive/:year/:month/page/:page)
+func (c *PageController) Archive(ctx *gin.Context) {
+	year, yearErr := strconv.Atoi(ctx.Param("year"))
+	month, monthErr := strconv.Atoi(ctx.Param("month"))
+	if yearErr != nil || monthErr != nil || month < 1 || month > 12 {
+		respondPageError(ctx, http.StatusNotFound, nil)
+		return
+	}
+	posts, paths, ok := c.published(ctx)
+	if !ok {
+		return
+	}
+	list, ok := site.ArchiveList(posts, year, time.Month(month))
+	if !ok {
+		respondPageError(ctx, http.StatusNotFound, nil)
+		return
+	}
+	// /archive/2024/3 のような表記は /archive/2024/03 にまとめる
+	if ctx.Param("year") != fmt.Sprintf("%04d", year) || ctx.Param("month") != fmt.Sprintf("%02d", month) {
+		ctx.Redirect(http.StatusMovedPermanently, c.renderer.URLs().Link(site.PagePath(list.Path, 1)))
+		return
+	}
+	c.renderList(ctx, paths, list)
+}
+
+// 一覧の :page ページ目を返す。1 ページ目は :page のない URL に転送する
+func (c *PageController) renderList(ctx *gin.Context, paths site.Paths, list site.List) {
+	page := 1
+	if param := ctx.Param("page"); param != "" {
+		var err error
+		page, err = strconv.Atoi(param)
+		if err != nil || page < 1 || page > c.renderer.PageCount(list) {
+			respondPageError(ctx, http.StatusNotFound, err)
+			return
+		}
+		if page == 1 {
+			ctx.Redirect(http.StatusMovedPermanently, c.renderer.URLs().Link(list.Path))
+			return
+		}
+	}
+	var buf bytes.Buffer
+	if err := c.renderer.RenderList(&buf, paths, list, page); err != nil {
+		respondPageError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	respondHTML(ctx, buf.Bytes())
+}
+
+// 投稿 (/posts/:slug)。slug のある投稿を ID で開いた場合は slug の URL に転送する。下書きは 404
+func (c *PageController) Post(ctx *gin.Context) {
+	posts, paths, ok := c.published(ctx)
+	if !ok {
+		return
+	}
+	id, ok := paths.Find(ctx.Param("slug"))
+	if !ok {
+		respondPageError(ctx, http.StatusNotFound, nil)
+		return
+	}
+	if canonical := paths.Post(id); canonical != "/posts/"+ctx.Param("slug") {
+		ctx.Redirect(http.StatusMovedPermanently, c.renderer.URLs().Link(canonical))
+		return
+	}
+
+	post, err := c.service.GetPostByID(ctx.Request.Context(), id)
+	if err != nil {
+		// 一覧を読み込んだ後に削除された
+		respondPageError(ctx, http.StatusNotFound, err)
+		return
+	}
+	// 連載は一覧で読み込んだものを使う
+	if i := slices.IndexFunc(posts, func(p models.Post) bool { return p.ID == id }); i >= 0 {
+		post.Series = posts[i].Series
+	}
+
+	var buf bytes.Buffer
+	if err := c.renderer.RenderPost(&buf, paths, post); err != nil {
+		respondPageError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Set(middlewares.ViewedPostKey, id)
+	respondHTML(ctx, buf.Bytes())
+}
+
+// Feed は site.FeedFiles のファイル (feed.xml, atom.xml, sitemap.xml) を返すハンドラ
+func (c *PageController) Feed(file string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		posts, paths, ok := c.published(ctx)
+		if !ok {
+			return
+		}
+		feeds, err := c.renderer.Feeds(paths, posts)
+		if err != nil {
+			respondPageError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		data, ok := feeds[file]
+		if !ok {
+			respondPageError(ctx, http.StatusNotFound, nil)
+			return
+		}
+		if httpcache.NotModified(ctx, httpcache.StrongETag(data), time.Time{}) {
+			return
+		}
+		ctx.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+	}
+}
+
+// 描画したページを返す。内容から ETag を求め、条件付きリクエストには 304 を返す
+func respondHTML(ctx *gin.Context, body []byte) {
+	if httpcache.NotModified(ctx, httpcache.StrongETag(body), time.Time{}) {
+		return
+	}
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", body)
+}
+
+// ページのエラーを返す。API と違い JSON ではなくステータスのテキストを返す
+func respondPageError(ctx *gin.Context, status int, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx.Request.Context()).ErrorContext(ctx.Request.Context(), "page request failed", "status", status, "error", err)
+	}
+	ctx.String(status, http.StatusText(status))
+}
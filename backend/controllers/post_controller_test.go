@@ -2,9 +2,13 @@ package controllers_test
 
 import (
 	"blog/controllers"
+	"blog/middlewares"
 	"blog/models"
+	"blog/services"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,28 +23,28 @@ type MockPostService struct {
 	mock.Mock
 }
 
-func (m *MockPostService) GetAllPosts() ([]models.Post, error) {
-	args := m.Called()
+func (m *MockPostService) GetAllPosts(ctx context.Context, query services.PostQuery) ([]models.Post, error) {
+	args := m.Called(ctx, query)
 	return args.Get(0).([]models.Post), args.Error(1)
 }
 
-func (m *MockPostService) GetPostByID(id uint) (*models.Post, error) {
-	args := m.Called(id)
+func (m *MockPostService) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
-func (m *MockPostService) CreatePost(post *models.Post) error {
-	args := m.Called(post)
+func (m *MockPostService) CreatePost(ctx context.Context, post *models.Post) error {
+	args := m.Called(ctx, post)
 	return args.Error(0)
 }
 
-func (m *MockPostService) UpdatePost(id uint, postData models.Post) error {
-	args := m.Called(id, postData)
+func (m *MockPostService) UpdatePost(ctx context.Context, id uint, postData models.Post) error {
+	args := m.Called(ctx, id, postData)
 	return args.Error(0)
 }
 
-func (m *MockPostService) DeletePost(id uint) error {
-	args := m.Called(id)
+func (m *MockPostService) DeletePost(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
@@ -50,8 +54,9 @@ func TestGetAllPosts(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts", nil)
 
-	service.On("GetAllPosts").Return([]models.Post{
+	service.On("GetAllPosts", mock.Anything, mock.Anything).Return([]models.Post{
 		{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}}, nil)
 
 	controller.GetAllPosts(ctx)
@@ -59,14 +64,56 @@ func TestGetAllPosts(t *testing.T) {
 	assert.Equal(t, http.StatusOK, recorder.Code)
 }
 
+func TestGetAllPosts_Fields(t *testing.T) {
+	service := new(MockPostService)
+	controller := controllers.NewPostController(service)
+	gin.SetMode(gin.TestMode)
+	post := models.Post{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", Excerpt: "Test Content", WordCount: 2,
+		Tags: []models.Tag{{ID: 3, Name: "go"}}}
+	service.On("GetAllPosts", mock.Anything, services.PostQuery{Fields: []string{"password"}, Published: true}).
+		Return([]models.Post(nil), services.ErrInvalidInput)
+	service.On("GetAllPosts", mock.Anything, mock.Anything).Return([]models.Post{post}, nil)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		controller.GetAllPosts(ctx)
+		return recorder
+	}
+
+	// 既定では本文を含めない
+	summary := get("/api/posts")
+	assert.Equal(t, http.StatusOK, summary.Code)
+	assert.NotContains(t, summary.Body.String(), `"Content"`)
+	assert.Contains(t, summary.Body.String(), `"Excerpt":"Test Content"`)
+	assert.Contains(t, summary.Body.String(), `"WordCount":2`)
+	assert.NotContains(t, summary.Body.String(), `"Tags"`)
+
+	full := get("/api/posts?fields=content")
+	assert.Equal(t, http.StatusOK, full.Code)
+	assert.JSONEq(t, `[{"ID":1,"Content":"Test Content"}]`, full.Body.String())
+	assert.NotEqual(t, summary.Header().Get("ETag"), full.Header().Get("ETag"))
+
+	sparse := get("/api/posts?fields=Title,%20excerpt&include=author,tags")
+	assert.Equal(t, http.StatusOK, sparse.Code)
+	assert.JSONEq(t, `[{"ID":1,"Title":"Test Post","Excerpt":"Test Content","Author":"Test Author","Tags":[{"ID":3,"Name":"go"}]}]`,
+		sparse.Body.String())
+	service.AssertCalled(t, "GetAllPosts", mock.Anything,
+		services.PostQuery{Fields: []string{"title", "excerpt"}, Include: []string{"author", "tags"}, Published: true})
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/posts?fields=password").Code)
+}
+
 func TestGetAllPosts_Error(t *testing.T) {
 	service := new(MockPostService)
 	controller := controllers.NewPostController(service)
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts", nil)
 
-	service.On("GetAllPosts").Return([]models.Post{}, errors.New("database error"))
+	service.On("GetAllPosts", mock.Anything, mock.Anything).Return([]models.Post{}, errors.New("database error"))
 
 	controller.GetAllPosts(ctx)
 
@@ -79,9 +126,10 @@ func TestGetPostByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
 
-	service.On("GetPostByID", uint(1)).Return(&models.Post{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}, nil)
+	service.On("GetPostByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}, nil)
 
 	controller.GetPostByID(ctx)
 
@@ -94,21 +142,49 @@ func TestGetPostByID_NotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/999", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "999"})
 
-	service.On("GetPostByID", uint(999)).Return((*models.Post)(nil), errors.New("not found"))
+	service.On("GetPostByID", mock.Anything, uint(999)).Return((*models.Post)(nil), errors.New("not found"))
 
 	controller.GetPostByID(ctx)
 
 	assert.Equal(t, http.StatusNotFound, recorder.Code)
 }
 
+func TestGetPostByID_Draft(t *testing.T) {
+	service := new(MockPostService)
+	controller := controllers.NewPostController(service)
+	gin.SetMode(gin.TestMode)
+	service.On("GetPostByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "Draft", Draft: true}, nil)
+
+	get := func(userID string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
+		ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
+		if userID != "" {
+			ctx.Set(middlewares.UserIDKey, userID)
+		}
+		controller.GetPostByID(ctx)
+		return recorder
+	}
+
+	// 下書きは管理者にだけ返す
+	assert.Equal(t, http.StatusNotFound, get("").Code)
+	assert.Equal(t, http.StatusNotFound, get("someone").Code)
+	recorder := get(middlewares.AdminUserID)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"Draft":true`)
+}
+
 func TestGetPostByID_InvalidID(t *testing.T) {
 	service := new(MockPostService)
 	controller := controllers.NewPostController(service)
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/invalid", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "invalid"})
 
 	controller.GetPostByID(ctx)
@@ -129,7 +205,7 @@ func TestCreatePost(t *testing.T) {
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/posts", bytes.NewBufferString(postJSON))
 	ctx.Request.Header.Set("Content-Type", "application/json")
 
-	service.On("CreatePost", mock.Anything).Return(nil)
+	service.On("CreatePost", mock.Anything, mock.Anything).Return(nil)
 
 	controller.CreatePost(ctx)
 
@@ -148,7 +224,7 @@ func TestCreatePost_Error(t *testing.T) {
 	ctx.Request = httptest.NewRequest(http.MethodPost, "/posts", bytes.NewBufferString(postJSON))
 	ctx.Request.Header.Set("Content-Type", "application/json")
 
-	service.On("CreatePost", mock.Anything).Return(errors.New("insert error"))
+	service.On("CreatePost", mock.Anything, mock.Anything).Return(errors.New("insert error"))
 
 	controller.CreatePost(ctx)
 
@@ -185,11 +261,17 @@ func TestUpdatePost(t *testing.T) {
 	ctx.Request = httptest.NewRequest(http.MethodPut, "/posts/1", bytes.NewBufferString(postJSON))
 	ctx.Request.Header.Set("Content-Type", "application/json")
 
-	service.On("UpdatePost", uint(1), mock.Anything).Return(nil)
+	// 省略したフィールド (Slug, Draft) は保存済みの値を維持する
+	stored := &models.Post{ID: 1, Title: "Title", Content: "Content", Slug: "kept", Draft: true}
+	service.On("GetPostByID", mock.Anything, uint(1)).Return(stored, nil)
+	service.On("UpdatePost", mock.Anything, uint(1), mock.MatchedBy(func(post models.Post) bool {
+		return post.Title == "Updated Title" && post.Content == "Updated Content" && post.Slug == "kept" && post.Draft
+	})).Return(nil)
 
 	controller.UpdatePost(ctx)
 
 	assert.Equal(t, http.StatusOK, recorder.Code)
+	service.AssertExpectations(t)
 }
 
 func TestUpdatePost_Error(t *testing.T) {
@@ -205,7 +287,8 @@ func TestUpdatePost_Error(t *testing.T) {
 	ctx.Request = httptest.NewRequest(http.MethodPut, "/posts/1", bytes.NewBufferString(postJSON))
 	ctx.Request.Header.Set("Content-Type", "application/json")
 
-	service.On("UpdatePost", uint(1), mock.Anything).Return(errors.New("update error"))
+	service.On("GetPostByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1}, nil)
+	service.On("UpdatePost", mock.Anything, uint(1), mock.Anything).Return(errors.New("update error"))
 
 	controller.UpdatePost(ctx)
 
@@ -242,7 +325,8 @@ func TestUpdatePost_NotFound(t *testing.T) {
 	ctx.Request = httptest.NewRequest(http.MethodPut, "/posts/999", bytes.NewBufferString(postJSON))
 	ctx.Request.Header.Set("Content-Type", "application/json")
 
-	service.On("UpdatePost", uint(999), mock.Anything).Return(errors.New("not found"))
+	service.On("GetPostByID", mock.Anything, uint(999)).Return(&models.Post{ID: 999}, nil)
+	service.On("UpdatePost", mock.Anything, uint(999), mock.Anything).Return(errors.New("not found"))
 
 	controller.UpdatePost(ctx)
 
@@ -256,9 +340,10 @@ func TestDeletePost(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
 
-	service.On("DeletePost", uint(1)).Return(nil)
+	service.On("DeletePost", mock.Anything, uint(1)).Return(nil)
 
 	controller.DeletePost(ctx)
 
@@ -272,9 +357,10 @@ func TestDeletePost_NotFound(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodDelete, "/api/posts/999", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "999"})
 
-	service.On("DeletePost", uint(999)).Return(errors.New("not found"))
+	service.On("DeletePost", mock.Anything, uint(999)).Return(errors.New("not found"))
 
 	controller.DeletePost(ctx)
 
@@ -288,6 +374,7 @@ func TestDeletePost_InvalidID(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodDelete, "/api/posts/invalid", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "invalid"})
 
 	controller.DeletePost(ctx)
@@ -302,9 +389,10 @@ func TestDeletePost_Error(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
 
-	service.On("DeletePost", uint(1)).Return(errors.New("delete error"))
+	service.On("DeletePost", mock.Anything, uint(1)).Return(errors.New("delete error"))
 
 	controller.DeletePost(ctx)
 
@@ -318,6 +406,7 @@ func TestRenderMarkdown(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1/render", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
 
 	post := &models.Post{
@@ -326,7 +415,7 @@ func TestRenderMarkdown(t *testing.T) {
 		Content: "# Hello\nThis is a test",
 	}
 
-	service.On("GetPostByID", uint(1)).Return(post, nil)
+	service.On("GetPostByID", mock.Anything, uint(1)).Return(post, nil)
 
 	controller.RenderMarkdown(ctx)
 
@@ -341,9 +430,10 @@ func TestRenderMarkdown_NotFound(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/999/render", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "999"})
 
-	service.On("GetPostByID", uint(999)).Return((*models.Post)(nil), errors.New("not found"))
+	service.On("GetPostByID", mock.Anything, uint(999)).Return((*models.Post)(nil), errors.New("not found"))
 
 	controller.RenderMarkdown(ctx)
 
@@ -358,6 +448,7 @@ func TestRenderMarkdown_InvalidID(t *testing.T) {
 
 	recorder := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/abc/render", nil)
 	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "abc"})
 
 	controller.RenderMarkdown(ctx)
@@ -365,3 +456,69 @@ func TestRenderMarkdown_InvalidID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
 	assert.Contains(t, recorder.Body.String(), "Invalid ID")
 }
+
+func TestGetPostByID_Timeout(t *testing.T) {
+	service := new(MockPostService)
+	controller := controllers.NewPostController(service)
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
+	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
+
+	service.On("GetPostByID", mock.Anything, uint(1)).Return((*models.Post)(nil), fmt.Errorf("post not found: %w", context.DeadlineExceeded))
+
+	controller.GetPostByID(ctx)
+
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+}
+
+func TestGetPostByID_NotModified(t *testing.T) {
+	service := new(MockPostService)
+	controller := controllers.NewPostController(service)
+	gin.SetMode(gin.TestMode)
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	service.On("GetPostByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "Test Post", UpdatedAt: updatedAt}, nil)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
+	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
+	controller.GetPostByID(ctx)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "Tue, 02 Jan 2024 03:04:05 GMT", recorder.Header().Get("Last-Modified"))
+
+	recorder = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
+	ctx.Request.Header.Set("If-None-Match", etag)
+	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
+	controller.GetPostByID(ctx)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestRenderMarkdown_NotModified(t *testing.T) {
+	service := new(MockPostService)
+	controller := controllers.NewPostController(service)
+	gin.SetMode(gin.TestMode)
+	service.On("GetPostByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Content: "# Hello"}, nil)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1/render", nil)
+	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
+	controller.RenderMarkdown(ctx)
+	etag := recorder.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]+"$`, etag)
+
+	recorder = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/posts/1/render", nil)
+	ctx.Request.Header.Set("If-None-Match", etag)
+	ctx.Params = append(ctx.Params, gin.Param{Key: "id", Value: "1"})
+	controller.RenderMarkdown(ctx)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+}
@@ -1,31 +1,94 @@
 package controllers
 
 import (
+	"blog/frontmatter"
+	"blog/httpcache"
+	"blog/logging"
+	"blog/metrics"
+	"blog/middlewares"
 	"blog/models"
 	"blog/services"
+	"blog/site"
+	"blog/tracing"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gomarkdown/markdown"
 )
 
 type PostController struct {
 	service services.PostService
+	series  services.SeriesService
+	related services.RelatedPostService
+	stats   services.PostStatsService
 }
 
 func NewPostController(service services.PostService) *PostController {
 	return &PostController{service: service}
 }
 
-// 全ての投稿を取得
+// WithSeries は投稿の取得・表示に連載のナビゲーションを含めるようにする
+func (c *PostController) WithSeries(series services.SeriesService) *PostController {
+	c.series = series
+	return c
+}
+
+// WithRelated は関連記事の取得を有効にする
+func (c *PostController) WithRelated(related services.RelatedPostService) *PostController {
+	c.related = related
+	return c
+}
+
+// WithStats は閲覧数のランキングと統計の取得を有効にする
+func (c *PostController) WithStats(stats services.PostStatsService) *PostController {
+	c.stats = stats
+	return c
+}
+
+// defaultRelatedLimit は limit を省略した場合に返す関連記事の数
+const defaultRelatedLimit = 5
+
+// 関連記事のレスポンス。本文は含めない
+type relatedPostResponse struct {
+	ID        uint
+	Title     string
+	Author    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Score     float64
+}
+
+// 連載情報を含む投稿のレスポンス
+type postResponse struct {
+	models.Post
+	Series *models.SeriesNavigation `json:",omitempty"`
+}
+
+// 全ての投稿を取得。
+// ?fields= で返すフィールドを、?include= で一緒に返す関連 (author, tags, series) をカンマ区切りで指定できる。
+// fields を省略した場合は本文 (content) 以外のフィールドを返す。
 func (c *PostController) GetAllPosts(ctx *gin.Context) {
-	posts, err := c.service.GetAllPosts()
+	query := parsePostQuery(ctx)
+	posts, err := c.service.GetAllPosts(ctx.Request.Context(), query)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(ctx, writeErrorStatus(err), err.Error(), err)
 		return
 	}
-	ctx.JSON(http.StatusOK, posts)
+
+	if httpcache.NotModified(ctx, postsVersion(posts, query), time.Time{}) {
+		return
+	}
+
+	response := make([]map[string]any, len(posts))
+	for i := range posts {
+		response[i] = sparsePost(&posts[i], query)
+	}
+	ctx.JSON(http.StatusOK, response)
 }
 
 // ID から投稿を取得
@@ -36,13 +99,42 @@ func (c *PostController) GetPostByID(ctx *gin.Context) {
 		return
 	}
 
-	post, err := c.service.GetPostByID(id)
+	post, err := c.readablePost(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		respondError(ctx, http.StatusNotFound, "Post not found", err)
+		return
+	}
+
+	nav, err := c.seriesNavigation(ctx, id)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	etag := httpcache.WeakETag(post.ID, post.UpdatedAt, seriesVersion(nav))
+	if httpcache.NotModified(ctx, etag, lastModified(post, nav)) {
 		return
 	}
+	ctx.JSON(http.StatusOK, postResponse{Post: *post, Series: nav})
+}
+
+// readablePost は読み取り用に投稿を取得する。下書きは管理者 (middlewares.IdentifyAdmin で認証したリクエスト) にだけ返し、
+// それ以外には存在しないものとして ErrNotFound を返す
+func (c *PostController) readablePost(ctx *gin.Context, id uint) (*models.Post, error) {
+	post, err := c.service.GetPostByID(ctx.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if post.Draft && !isAdmin(ctx) {
+		return nil, services.ErrNotFound
+	}
+	return post, nil
+}
 
-	ctx.JSON(http.StatusOK, post)
+// isAdmin はリクエストが管理トークンで認証されているかを返す
+func isAdmin(ctx *gin.Context) bool {
+	userID, _ := ctx.Get(middlewares.UserIDKey)
+	return userID == middlewares.AdminUserID
 }
 
 // 新規投稿を作成
@@ -53,8 +145,8 @@ func (c *PostController) CreatePost(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.service.CreatePost(&post); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.service.CreatePost(ctx.Request.Context(), &post); err != nil {
+		respondError(ctx, writeErrorStatus(err), err.Error(), err)
 		return
 	}
 
@@ -69,18 +161,62 @@ func (c *PostController) UpdatePost(ctx *gin.Context) {
 		return
 	}
 
-	var post models.Post
-	if err := ctx.ShouldBindJSON(&post); err != nil {
+	var req updatePostRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
 
-	if err := c.service.UpdatePost(id, post); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// 省略したフィールドは保存済みの値を維持する (gRPC の update_mask と同じ)
+	post, err := c.service.GetPostByID(ctx.Request.Context(), id)
+	if err != nil {
+		respondError(ctx, http.StatusNotFound, "Post not found", err)
+		return
+	}
+	if err := c.service.UpdatePost(ctx.Request.Context(), id, req.apply(*post)); err != nil {
+		respondError(ctx, writeErrorStatus(err), err.Error(), err)
+		return
+	}
+
+	updated, err := c.service.GetPostByID(ctx.Request.Context(), id)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// 投稿を更新するリクエスト。nil のフィールドは更新しない
+type updatePostRequest struct {
+	Title       *string
+	Content     *string
+	Slug        *string
+	Description *string
+	CoverImage  *string
+	Draft       *bool
+	Metadata    *models.PostMetadata
+	// Tags が nil の場合は既存のタグを維持し、空配列の場合は全て外す
+	Tags []models.Tag
+}
+
+// apply は保存済みの投稿にリクエストのフィールドを上書きした PostService.UpdatePost の入力を返す。
+// 本文のフロントマターのキーはリクエストで指定していないフィールドの保存済みの値を上書きする (PostService.UpdatePost が反映する)
+func (r updatePostRequest) apply(post models.Post) models.Post {
+	set(&post.Title, r.Title)
+	set(&post.Content, r.Content)
+	set(&post.Slug, r.Slug)
+	set(&post.Description, r.Description)
+	set(&post.CoverImage, r.CoverImage)
+	set(&post.Draft, r.Draft)
+	set(&post.Metadata, r.Metadata)
+	post.Tags = r.Tags
+	return post
+}
 
-	ctx.JSON(http.StatusOK, post)
+func set[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
 }
 
 // 投稿を削除
@@ -91,14 +227,103 @@ func (c *PostController) DeletePost(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.service.DeletePost(id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.service.DeletePost(ctx.Request.Context(), id); err != nil {
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted"})
 }
 
+// 投稿に類似する投稿を取得
+func (c *PostController) GetRelatedPosts(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	limit := defaultRelatedLimit
+	if v := ctx.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	related, err := c.related.GetRelatedPosts(ctx.Request.Context(), id, limit)
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		respondError(ctx, http.StatusBadRequest, err.Error(), err)
+		return
+	case errors.Is(err, services.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, "Post not found", err)
+		return
+	case err != nil:
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	response := make([]relatedPostResponse, len(related))
+	for i, r := range related {
+		response[i] = relatedPostResponse{
+			ID:        r.Related.ID,
+			Title:     r.Related.Title,
+			Author:    r.Related.Author,
+			CreatedAt: r.Related.CreatedAt,
+			UpdatedAt: r.Related.UpdatedAt,
+			Score:     r.Score,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// 直近の閲覧数が多い投稿を取得 (?window=7d&limit=10)
+func (c *PostController) GetPopularPosts(ctx *gin.Context) {
+	days, err := parseWindow(ctx.DefaultQuery("window", "7d"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	popular, err := c.stats.GetPopularPosts(ctx.Request.Context(), days, limit)
+	if err != nil {
+		respondError(ctx, writeErrorStatus(err), err.Error(), err)
+		return
+	}
+	ctx.JSON(http.StatusOK, popular)
+}
+
+// 投稿の閲覧数と日別の内訳を取得 (?window=30d)
+func (c *PostController) GetPostStats(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	days, err := parseWindow(ctx.DefaultQuery("window", "30d"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window"})
+		return
+	}
+
+	stats, err := c.stats.GetPostStats(ctx.Request.Context(), id, days)
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, "Post not found", err)
+		return
+	case err != nil:
+		respondError(ctx, writeErrorStatus(err), err.Error(), err)
+		return
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
+
 // Markdown を HTML に変換して表示
 func (c *PostController) RenderMarkdown(ctx *gin.Context) {
 	id, err := parseID(ctx)
@@ -107,16 +332,91 @@ func (c *PostController) RenderMarkdown(ctx *gin.Context) {
 		return
 	}
 
-	post, err := c.service.GetPostByID(id)
+	post, err := c.readablePost(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		respondError(ctx, http.StatusNotFound, "Post not found", err)
 		return
 	}
 
-	htmlContent := markdown.ToHTML([]byte(post.Content), nil, nil)
+	// フロントマターは保存時に取り除くが、それ以前に保存された投稿の分もここで除く
+	content := post.Content
+	if _, body, err := frontmatter.Parse(content); err == nil {
+		content = body
+	}
+
+	_, span := tracing.Tracer().Start(ctx.Request.Context(), "markdown.render")
+	htmlContent := site.MarkdownToHTML(content)
+	span.End()
+	metrics.MarkdownRendersTotal.Inc()
+
+	// ?series_nav=true の場合は連載ナビゲーションを先頭に付ける
+	var nav *models.SeriesNavigation
+	if includeNav, _ := strconv.ParseBool(ctx.Query("series_nav")); includeNav {
+		nav, err = c.seriesNavigation(ctx, id)
+		if err != nil {
+			respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+			return
+		}
+	}
+	if nav != nil {
+		navHTML, err := renderSeriesNav(nav)
+		if err != nil {
+			respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+			return
+		}
+		htmlContent = append(navHTML, htmlContent...)
+	}
+
+	if httpcache.NotModified(ctx, httpcache.StrongETag(htmlContent), lastModified(post, nav)) {
+		return
+	}
 	ctx.Data(http.StatusOK, "text/html", htmlContent)
 }
 
+// 投稿一覧の ETag を求める。
+// 削除でも ETag が変わるよう、全投稿の ID と更新日時から生成する。
+// 返すフィールドや関連によって表現が変わるため、それらと関連の内容も含める。
+// 残った投稿の更新日時は削除や下書きへの変更で進まないため、一覧には Last-Modified を付けない。
+func postsVersion(posts []models.Post, query services.PostQuery) string {
+	parts := make([]any, 0, len(posts)*2+1)
+	parts = append(parts, query.String())
+	for _, p := range posts {
+		parts = append(parts, p.ID, p.UpdatedAt)
+		for _, tag := range p.Tags {
+			parts = append(parts, tag.ID)
+		}
+		if p.Series != nil {
+			parts = append(parts, p.Series.ID, p.Series.Title, p.Series.Position)
+		}
+	}
+	return httpcache.WeakETag(parts...)
+}
+
+// 連載サービスが設定されていれば投稿の連載ナビゲーションを取得する
+func (c *PostController) seriesNavigation(ctx *gin.Context, postID uint) (*models.SeriesNavigation, error) {
+	if c.series == nil {
+		return nil, nil
+	}
+	return c.series.GetNavigation(ctx.Request.Context(), postID)
+}
+
+// 投稿と連載のうち新しい方の更新日時を返す
+func lastModified(post *models.Post, nav *models.SeriesNavigation) time.Time {
+	if nav != nil && nav.UpdatedAt.After(post.UpdatedAt) {
+		return nav.UpdatedAt
+	}
+	return post.UpdatedAt
+}
+
+// "7d" 形式の集計期間を日数に変換する
+func parseWindow(window string) (int, error) {
+	days, ok := strings.CutSuffix(window, "d")
+	if !ok {
+		return 0, fmt.Errorf("window %q must be in days (e.g. 7d)", window)
+	}
+	return strconv.Atoi(days)
+}
+
 // Gin のパスパラメータから ID を取得
 func parseID(ctx *gin.Context) (uint, error) {
 	idStr := ctx.Param("id")
@@ -126,3 +426,22 @@ func parseID(ctx *gin.Context) (uint, error) {
 	}
 	return uint(id), nil
 }
+
+// 投稿の作成・更新のエラーのステータスコード。入力が不正な場合は 400 を返す
+func writeErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidInput) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// サービス層のエラーをレスポンスに変換する。DB タイムアウトの場合は 504 を返す
+func respondError(ctx *gin.Context, status int, message string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		status, message = http.StatusGatewayTimeout, "Request timed out"
+	}
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx.Request.Context()).ErrorContext(ctx.Request.Context(), "request failed", "status", status, "error", err)
+	}
+	ctx.JSON(status, gin.H{"error": message})
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/services"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// summaryFields は ?fields= を省略した場合に一覧で返すフィールド (本文以外)
+var summaryFields = slices.DeleteFunc(slices.Clone(services.PostFields), func(field string) bool {
+	return field == "content"
+})
+
+// ?fields= と ?include= から一覧の PostQuery を作る。値の検証はサービス層で行う
+func parsePostQuery(ctx *gin.Context) services.PostQuery {
+	query := services.PostQuery{
+		Fields:  splitList(ctx.Query("fields")),
+		Include: splitList(ctx.Query("include")),
+		// 下書きは公開の API に出さない
+		Published: true,
+	}
+	if len(query.Fields) == 0 {
+		query.Fields = summaryFields
+	}
+	return query
+}
+
+// カンマ区切りの値を小文字にして分割する。空の要素は除く
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// sparsePost は query で指定したフィールドと関連だけを含むレスポンスに変換する。キーは models.Post の JSON と同じ
+func sparsePost(post *models.Post, query services.PostQuery) map[string]any {
+	response := map[string]any{"ID": post.ID}
+	fields := query.Fields
+	if query.Includes("author") {
+		fields = append(slices.Clone(fields), "author")
+	}
+	for _, field := range fields {
+		switch field {
+		case "title":
+			response["Title"] = post.Title
+		case "content":
+			response["Content"] = post.Content
+		case "author":
+			response["Author"] = post.Author
+		case "slug":
+			response["Slug"] = post.Slug
+		case "description":
+			response["Description"] = post.Description
+		case "cover_image":
+			response["CoverImage"] = post.CoverImage
+		case "draft":
+			response["Draft"] = post.Draft
+		case "metadata":
+			response["Metadata"] = post.Metadata
+		case "word_count":
+			response["WordCount"] = post.WordCount
+		case "char_count":
+			response["CharCount"] = post.CharCount
+		case "reading_time_minutes":
+			response["ReadingTimeMinutes"] = post.ReadingTimeMinutes
+		case "excerpt":
+			response["Excerpt"] = post.Excerpt
+		case "created_at":
+			response["CreatedAt"] = post.CreatedAt
+		case "updated_at":
+			response["UpdatedAt"] = post.UpdatedAt
+		}
+	}
+	if query.Includes("tags") {
+		tags := post.Tags
+		if tags == nil {
+			tags = []models.Tag{}
+		}
+		response["Tags"] = tags
+	}
+	if query.Includes("series") {
+		// 連載に属していない投稿は null
+		response["Series"] = post.Series
+	}
+	return response
+}
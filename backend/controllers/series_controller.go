@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"blog/httpcache"
+	"blog/models"
+	"blog/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SeriesController struct {
+	service services.SeriesService
+}
+
+func NewSeriesController(service services.SeriesService) *SeriesController {
+	return &SeriesController{service: service}
+}
+
+// 連載の各回を設定するリクエスト
+type setSeriesPostsRequest struct {
+	PostIDs []uint `json:"post_ids"`
+}
+
+// 全ての連載を取得
+func (c *SeriesController) GetAllSeries(ctx *gin.Context) {
+	series, err := c.service.GetAllSeries(ctx.Request.Context())
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	// 削除で更新日時が進まないため、投稿の一覧と同じく Last-Modified は付けない
+	parts := make([]any, 0, len(series)*2)
+	for _, s := range series {
+		parts = append(parts, s.ID, s.UpdatedAt)
+	}
+	if httpcache.NotModified(ctx, httpcache.WeakETag(parts...), time.Time{}) {
+		return
+	}
+	ctx.JSON(http.StatusOK, series)
+}
+
+// ID から連載を取得
+func (c *SeriesController) GetSeriesByID(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	series, err := c.service.GetSeriesByID(ctx.Request.Context(), id)
+	if err != nil {
+		respondSeriesError(ctx, err)
+		return
+	}
+
+	if httpcache.NotModified(ctx, seriesETag(series), time.Time{}) {
+		return
+	}
+	ctx.JSON(http.StatusOK, series)
+}
+
+// 新規連載を作成
+func (c *SeriesController) CreateSeries(ctx *gin.Context) {
+	var series models.Series
+	if err := ctx.ShouldBindJSON(&series); err != nil || series.Title == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := c.service.CreateSeries(ctx.Request.Context(), &series); err != nil {
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, series)
+}
+
+// 連載を更新
+func (c *SeriesController) UpdateSeries(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var seriesData models.Series
+	if err := ctx.ShouldBindJSON(&seriesData); err != nil || seriesData.Title == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	series, err := c.service.UpdateSeries(ctx.Request.Context(), id, seriesData)
+	if err != nil {
+		respondSeriesError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, series)
+}
+
+// 連載を削除
+func (c *SeriesController) DeleteSeries(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := c.service.DeleteSeries(ctx.Request.Context(), id); err != nil {
+		respondSeriesError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Series deleted"})
+}
+
+// 連載の各回と並び順を設定
+func (c *SeriesController) SetSeriesPosts(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req setSeriesPostsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	series, err := c.service.SetSeriesPosts(ctx.Request.Context(), id, req.PostIDs)
+	if err != nil {
+		respondSeriesError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, series)
+}
+
+// seriesETag は連載とその各回の ETag を求める。
+// 各回の投稿の変更や下書きへの変更では連載の更新日時が進まないため、Last-Modified は付けずに各回の投稿の更新日時を含める
+func seriesETag(series *models.Series) string {
+	parts := []any{series.ID, series.UpdatedAt}
+	for _, entry := range series.Entries {
+		parts = append(parts, entry.PostID, entry.Position, entry.Post.UpdatedAt)
+	}
+	return httpcache.WeakETag(parts...)
+}
+
+// 連載のエラーを 400 / 404 / 500 に振り分ける
+func respondSeriesError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		respondError(ctx, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, services.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, "Series not found", err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+	}
+}
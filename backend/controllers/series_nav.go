@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"blog/models"
+	"bytes"
+	"fmt"
+	"html/template"
+)
+
+var seriesNavTemplate = template.Must(template.New("series-nav").Parse(`<nav class="series-nav">
+<p class="series-nav-title">{{.Title}} ({{.Position}} / {{.Total}})</p>
+{{- if .Previous}}
+<a class="series-nav-prev" rel="prev" href="/posts/{{.Previous.ID}}">&larr; {{.Previous.Title}}</a>
+{{- end}}
+{{- if .Next}}
+<a class="series-nav-next" rel="next" href="/posts/{{.Next.ID}}">{{.Next.Title}} &rarr;</a>
+{{- end}}
+</nav>
+`))
+
+// renderSeriesNav は連載ナビゲーションの HTML を生成する
+func renderSeriesNav(nav *models.SeriesNavigation) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := seriesNavTemplate.Execute(&buf, nav); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// seriesVersion は ETag に含める連載ナビゲーションの内容
+func seriesVersion(nav *models.SeriesNavigation) string {
+	if nav == nil {
+		return ""
+	}
+	link := func(l *models.SeriesLink) string {
+		if l == nil {
+			return ""
+		}
+		return fmt.Sprintf("%d:%s", l.ID, l.Title)
+	}
+	return fmt.Sprintf("%d:%s:%d/%d:%s:%s", nav.ID, nav.Title, nav.Position, nav.Total, link(nav.Previous), link(nav.Next))
+}
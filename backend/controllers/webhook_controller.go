@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	service services.WebhookService
+}
+
+func NewWebhookController(service services.WebhookService) *WebhookController {
+	return &WebhookController{service: service}
+}
+
+// Webhook の送信先を登録・更新するリクエスト
+type webhookSubscriptionRequest struct {
+	URL string
+	// Secret は署名の鍵。登録で省略した場合は生成し、更新で省略した場合は変更しない
+	Secret string
+	// Events は通知するイベントの種類。空の場合は全ての種類を通知する
+	Events []string
+	// Active は省略した場合 true
+	Active *bool
+}
+
+func (r webhookSubscriptionRequest) subscription() models.WebhookSubscription {
+	active := r.Active == nil || *r.Active
+	return models.WebhookSubscription{URL: r.URL, Secret: r.Secret, Events: r.Events, Active: active}
+}
+
+// createdWebhookSubscription は登録した送信先のレスポンス。署名の鍵はこのレスポンスでだけ返す
+type createdWebhookSubscription struct {
+	models.WebhookSubscription
+	Secret string
+}
+
+// 全ての Webhook の送信先を取得
+func (c *WebhookController) GetAllSubscriptions(ctx *gin.Context) {
+	subscriptions, err := c.service.GetAllSubscriptions(ctx.Request.Context())
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+// ID から Webhook の送信先を取得
+func (c *WebhookController) GetSubscription(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	subscription, err := c.service.GetSubscription(ctx.Request.Context(), id)
+	if err != nil {
+		respondWebhookError(ctx, err, "Webhook not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+// Webhook の送信先を登録。署名の鍵 (secret) はこのレスポンスでだけ返す
+func (c *WebhookController) CreateSubscription(ctx *gin.Context) {
+	var req webhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	subscription := req.subscription()
+	if err := c.service.CreateSubscription(ctx.Request.Context(), &subscription); err != nil {
+		respondWebhookError(ctx, err, "Webhook not found")
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdWebhookSubscription{WebhookSubscription: subscription, Secret: subscription.Secret})
+}
+
+// Webhook の送信先を更新
+func (c *WebhookController) UpdateSubscription(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req webhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	subscription, err := c.service.UpdateSubscription(ctx.Request.Context(), id, req.subscription())
+	if err != nil {
+		respondWebhookError(ctx, err, "Webhook not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+// Webhook の送信先を削除。配信の記録も削除する
+func (c *WebhookController) DeleteSubscription(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := c.service.DeleteSubscription(ctx.Request.Context(), id); err != nil {
+		respondWebhookError(ctx, err, "Webhook not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// 送信先の最近の配信を新しい順に取得
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	id, err := parseID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	deliveries, err := c.service.GetDeliveries(ctx.Request.Context(), id)
+	if err != nil {
+		respondWebhookError(ctx, err, "Webhook not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// 配信を送信ごとの記録 (AttemptLog) を含めて取得
+func (c *WebhookController) GetDelivery(ctx *gin.Context) {
+	id, deliveryID, err := parseDeliveryID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	delivery, err := c.service.GetDelivery(ctx.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondWebhookError(ctx, err, "Delivery not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// 配信を送り直す。送信は非同期に行うため 202 を返す
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+	id, deliveryID, err := parseDeliveryID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	delivery, err := c.service.Redeliver(ctx.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondWebhookError(ctx, err, "Delivery not found")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+func parseDeliveryID(ctx *gin.Context) (uint, uint, error) {
+	id, err := parseID(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	deliveryID, err := strconv.Atoi(ctx.Param("delivery"))
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, uint(deliveryID), nil
+}
+
+// Webhook のエラーを 400 / 404 / 500 に振り分ける
+func respondWebhookError(ctx *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		respondError(ctx, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, services.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, notFound, err)
+	default:
+		respondError(ctx, http.StatusInternalServerError, err.Error(), err)
+	}
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"blog/config"
+	"blog/logging"
+	"blog/metrics"
+	"blog/models"
+	"blog/textstats"
+	"blog/tracing"
+	"fmt"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Open は設定に従ってデータベースに接続し、メトリクスとトレースのプラグインを登録する。
+// Driver が "sqlite" の場合は Postgres なしでローカル実行できる (Path に ":memory:" も指定可)。
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	var (
+		dialector gorm.Dialector
+		dbSystem  string
+	)
+	switch cfg.Driver {
+	case "", "postgres":
+		dialector, dbSystem = postgres.Open(cfg.DSN()), "postgresql"
+	case "sqlite":
+		dialector, dbSystem = sqlite.Open(sqliteDSN(cfg.Path)), "sqlite"
+	default:
+		return nil, fmt.Errorf("unknown database driver: %q", cfg.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logging.NewGormLogger(200 * time.Millisecond),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	if dbSystem == "sqlite" {
+		// SQLite は書き込みが直列化されるうえ、:memory: は接続ごとに別の DB になるため 1 接続に制限する
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	// クエリのメトリクス・トレースとコネクションプールのメトリクスを登録
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register metrics plugin: %w", err)
+	}
+	if err := db.Use(tracing.GormPlugin{DBSystem: dbSystem}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+	if err := metrics.RegisterDBStats(sqlDB); err != nil {
+		return nil, fmt.Errorf("failed to register database metrics: %w", err)
+	}
+
+	return db, nil
+}
+
+// Migrate は全てのモデルのテーブルを作成・更新する
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(
+		&models.Post{},
+		&models.Tag{},
+		&models.RelatedPost{},
+		&models.PostView{},
+		&models.Series{},
+		&models.SeriesEntry{},
+		&models.RateLimitBucket{},
+		&models.ImportedPost{},
+		&models.WebhookSubscription{},
+		&models.WebhookEvent{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
+	); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	if err := backfillTextStats(db); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
+
+// backfillTextStats は語数などの列が追加される前に保存された投稿の値を計算する
+func backfillTextStats(db *gorm.DB) error {
+	var batch []models.Post
+	return db.Select("id", "content").
+		Where("char_count = 0 AND content <> ''").
+		FindInBatches(&batch, 100, func(*gorm.DB, int) error {
+			for _, post := range batch {
+				stats := textstats.Compute(post.Content)
+				err := db.Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumns(map[string]any{
+					"word_count":           stats.Words,
+					"char_count":           stats.Characters,
+					"reading_time_minutes": stats.ReadingMinutes,
+					"excerpt":              stats.Excerpt,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func sqliteDSN(path string) string {
+	if path == "" {
+		path = ":memory:"
+	}
+	return path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
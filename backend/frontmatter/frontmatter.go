@@ -0,0 +1,171 @@
+// Package frontmatter は Markdown の先頭にある YAML (---) / TOML (+++) のフロントマターを扱う。
+package frontmatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Matter はフロントマターのキーと値。キーは小文字に揃える
+type Matter map[string]any
+
+// Parse は src の先頭のフロントマターを解析し、フロントマターと、それを取り除いた本文を返す。
+// 閉じる区切り行の直後の空行は 1 行だけ取り除く。フロントマターがない場合は nil と src をそのまま返す。
+func Parse(src string) (Matter, string, error) {
+	src = strings.TrimPrefix(src, "\ufeff")
+	first, rest, ok := cutLine(src)
+	if !ok {
+		return nil, src, nil
+	}
+	delimiter := strings.TrimSpace(first)
+	if delimiter != "---" && delimiter != "+++" {
+		return nil, src, nil
+	}
+
+	// 閉じる区切り行を探す
+	var header strings.Builder
+	for {
+		line, next, ok := cutLine(rest)
+		if !ok {
+			return nil, src, nil
+		}
+		rest = next
+		if strings.TrimSpace(line) == delimiter {
+			break
+		}
+		header.WriteString(line)
+		header.WriteByte('\n')
+	}
+
+	raw := map[string]any{}
+	var err error
+	if delimiter == "---" {
+		err = yaml.Unmarshal([]byte(header.String()), &raw)
+	} else {
+		err = toml.Unmarshal([]byte(header.String()), &raw)
+	}
+	if err != nil {
+		return nil, src, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	matter := make(Matter, len(raw))
+	for key, value := range raw {
+		matter[strings.ToLower(key)] = value
+	}
+	if line, next, ok := cutLine(rest); ok && strings.TrimSpace(line) == "" {
+		rest = next
+	}
+	return matter, rest, nil
+}
+
+// Format は matter を YAML のフロントマターとして body の前に付ける。Parse で元の body に戻せる
+func Format(matter any, body string) (string, error) {
+	header, err := yaml.Marshal(matter)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode front matter: %w", err)
+	}
+	return "---\n" + string(header) + "---\n\n" + body, nil
+}
+
+// 1 行を取り出す。改行のない最終行も 1 行として扱う
+func cutLine(s string) (string, string, bool) {
+	if s == "" {
+		return "", "", false
+	}
+	line, rest, found := strings.Cut(s, "\n")
+	if !found {
+		rest = ""
+	}
+	return strings.TrimSuffix(line, "\r"), rest, true
+}
+
+// String は keys のうち最初に値がある文字列を返す
+func (m Matter) String(keys ...string) string {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case int, int64, uint64, float64:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// Strings は文字列のリストを返す。値が文字列の場合はカンマまたは空白で区切る (Jekyll の tags: a b など)
+func (m Matter) Strings(key string) []string {
+	var list []string
+	switch v := m[key].(type) {
+	case []any:
+		for _, item := range v {
+			if s := strings.TrimSpace(fmt.Sprint(item)); item != nil && s != "" {
+				list = append(list, s)
+			}
+		}
+	case string:
+		list = strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+	}
+	return list
+}
+
+// Bool は真偽値を返す。文字列の "true" なども受け付ける
+func (m Matter) Bool(key string) (value bool, ok bool) {
+	switch v := m[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// timeLayouts は文字列の日時として受け付ける形式
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Time は keys のうち最初に解析できる日時を返す。タイムゾーンがない場合は UTC とみなす
+func (m Matter) Time(keys ...string) (time.Time, bool) {
+	return m.TimeIn(time.UTC, keys...)
+}
+
+// TimeIn は Time と同じだが、タイムゾーンがない場合は loc とみなす
+func (m Matter) TimeIn(loc *time.Location, keys ...string) (time.Time, bool) {
+	for _, key := range keys {
+		var s string
+		switch v := m[key].(type) {
+		case time.Time:
+			return v, true
+		case string:
+			s = v
+		case fmt.Stringer:
+			// TOML のローカル日付 / 日時
+			s = v.String()
+		default:
+			continue
+		}
+		s = strings.TrimSpace(s)
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
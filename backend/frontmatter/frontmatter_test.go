@@ -0,0 +1,68 @@
+package frontmatter_test
+
+import (
+	"blog/frontmatter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_YAML(t *testing.T) {
+	matter, body, err := frontmatter.Parse("---\nTitle: Hello\ntags: [go, web]\ndate: 2023-04-05T10:00:00+09:00\ndraft: false\n---\n\n# Body\n")
+	require.NoError(t, err)
+	assert.Equal(t, "# Body\n", body)
+	assert.Equal(t, "Hello", matter.String("title"))
+	assert.Equal(t, []string{"go", "web"}, matter.Strings("tags"))
+	date, ok := matter.Time("date")
+	require.True(t, ok)
+	assert.True(t, date.Equal(time.Date(2023, 4, 5, 1, 0, 0, 0, time.UTC)))
+	draft, ok := matter.Bool("draft")
+	assert.True(t, ok)
+	assert.False(t, draft)
+}
+
+func TestParse_TOML(t *testing.T) {
+	matter, body, err := frontmatter.Parse("+++\r\ntitle = \"Hello\"\r\ndate = 2023-04-05\r\ncategories = [\"go\"]\r\n+++\r\nBody")
+	require.NoError(t, err)
+	assert.Equal(t, "Body", body)
+	assert.Equal(t, "Hello", matter.String("title"))
+	assert.Equal(t, []string{"go"}, matter.Strings("categories"))
+	date, ok := matter.Time("date")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2023, 4, 5, 0, 0, 0, 0, time.UTC), date)
+}
+
+func TestFormat(t *testing.T) {
+	for _, body := range []string{"# Body\n", "\n\nstarts with blank lines", ""} {
+		src, err := frontmatter.Format(map[string]any{"title": "Hello", "tags": []string{"go"}}, body)
+		require.NoError(t, err)
+		matter, parsed, err := frontmatter.Parse(src)
+		require.NoError(t, err)
+		assert.Equal(t, body, parsed)
+		assert.Equal(t, "Hello", matter.String("title"))
+		assert.Equal(t, []string{"go"}, matter.Strings("tags"))
+	}
+}
+
+func TestParse_NoFrontMatter(t *testing.T) {
+	for _, src := range []string{"# Title\n", "---\nnot closed\n", ""} {
+		matter, body, err := frontmatter.Parse(src)
+		require.NoError(t, err)
+		assert.Nil(t, matter)
+		assert.Equal(t, src, body)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, _, err := frontmatter.Parse("---\ntitle: [unclosed\n---\nbody")
+	assert.Error(t, err)
+}
+
+func TestMatter_Strings(t *testing.T) {
+	matter := frontmatter.Matter{"tags": "go  web,db", "list": []any{"a", 1, nil, " "}}
+	assert.Equal(t, []string{"go", "web", "db"}, matter.Strings("tags"))
+	assert.Equal(t, []string{"a", "1"}, matter.Strings("list"))
+	assert.Nil(t, matter.Strings("missing"))
+}
@@ -0,0 +1,155 @@
+// Package graph は投稿、著者、タグの読み取り専用の GraphQL API を提供する。
+// リゾルバは services.PostService を使い、投稿の一覧はリクエストごとに 1 回だけ読み込む。
+// ID で引く投稿と関連記事はリクエストの間まとめて読み込み (DataLoader と同じ考え方)、N+1 のクエリにしない。
+// 実行前にクエリの深さと複雑さを見積もって制限し、ハッシュで参照する永続化クエリ (persisted queries) に対応する。
+package graph
+
+import (
+	"blog/cache"
+	"blog/services"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// 実行前に拒否したクエリのエラーの extensions.code
+const (
+	codeQueryTooDeep    = "QUERY_TOO_DEEP"
+	codeQueryTooComplex = "QUERY_TOO_COMPLEX"
+)
+
+// Config は GraphQL の実行の設定
+type Config struct {
+	// MaxDepth はクエリの深さ (フィールドの入れ子) の上限。0 以下の場合は制限しない
+	MaxDepth int
+	// MaxComplexity はクエリの複雑さ (一覧の件数を掛けたフィールドの数) の上限。0 以下の場合は制限しない
+	MaxComplexity int
+	// Manifest は事前に登録したクエリ (SHA-256 → クエリ)。LoadManifest で読み込む
+	Manifest map[string]string
+	// PersistedOnly の場合は Manifest にあるクエリだけを実行する
+	PersistedOnly bool
+	// PersistedStore はクライアントが自動で登録したクエリの保存先。nil の場合は自動の登録を受け付けない
+	PersistedStore cache.Backend
+	// PersistedTTL は自動で登録したクエリの保存期間
+	PersistedTTL time.Duration
+}
+
+// Request は GraphQL のリクエスト (GraphQL over HTTP の JSON の形式)
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *PersistedQuery `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// Executor はスキーマに対してリクエストを実行する
+type Executor struct {
+	schema    graphql.Schema
+	posts     services.PostService
+	related   services.RelatedPostService
+	persisted *persistedQueries
+	cfg       Config
+}
+
+func NewExecutor(posts services.PostService, related services.RelatedPostService, cfg Config) (*Executor, error) {
+	schema, err := newSchema()
+	if err != nil {
+		return nil, fmt.Errorf("invalid GraphQL schema: %w", err)
+	}
+	return &Executor{
+		schema:  schema,
+		posts:   posts,
+		related: related,
+		persisted: &persistedQueries{
+			manifest: cfg.Manifest,
+			only:     cfg.PersistedOnly,
+			store:    cfg.PersistedStore,
+			ttl:      cfg.PersistedTTL,
+		},
+		cfg: cfg,
+	}, nil
+}
+
+// requestError は実行前にリクエストを拒否した理由
+type requestError struct {
+	message string
+	// code は extensions.code。空の場合は付けない
+	code string
+}
+
+func (e *requestError) result() *graphql.Result {
+	err := gqlerrors.FormattedError{Message: e.message, Locations: []location.SourceLocation{}}
+	if e.code != "" {
+		err.Extensions = map[string]any{"code": e.code}
+	}
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{err}}
+}
+
+// Execute はリクエストを実行する。クエリの誤りやリゾルバのエラーは Result の Errors で返す
+func (e *Executor) Execute(ctx context.Context, req Request) *graphql.Result {
+	query, reqErr := e.persisted.lookup(ctx, req.Query, req.Extensions.PersistedQuery)
+	if reqErr != nil {
+		return reqErr.result()
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if validation := graphql.ValidateDocument(&e.schema, doc, nil); !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+	if operation := findOperation(doc, req.OperationName); operation != nil {
+		if reqErr := e.checkCost(measure(&e.schema, doc, operation, req.Variables)); reqErr != nil {
+			return reqErr.result()
+		}
+	}
+	e.persisted.register(ctx, query, req.Extensions.PersistedQuery)
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, newLoaders(ctx, e.posts, e.related)),
+	})
+}
+
+func (e *Executor) checkCost(c cost) *requestError {
+	if e.cfg.MaxDepth > 0 && c.Depth > e.cfg.MaxDepth {
+		return &requestError{message: fmt.Sprintf("query depth %d exceeds the limit of %d", c.Depth, e.cfg.MaxDepth), code: codeQueryTooDeep}
+	}
+	if e.cfg.MaxComplexity > 0 && c.Complexity > e.cfg.MaxComplexity {
+		return &requestError{message: fmt.Sprintf("query complexity %d exceeds the limit of %d", c.Complexity, e.cfg.MaxComplexity), code: codeQueryTooComplex}
+	}
+	return nil
+}
+
+// findOperation は実行する操作を返す。決まらない場合は nil を返し、エラーは graphql.Execute に任せる
+func findOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil
+			}
+			found = operation
+		} else if operation.Name != nil && operation.Name.Value == name {
+			return operation
+		}
+	}
+	return found
+}
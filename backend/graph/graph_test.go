@@ -0,0 +1,299 @@
+package graph_test
+
+import (
+	"blog/cache"
+	"blog/graph"
+	"blog/models"
+	"blog/services"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePostService は GetAllPosts の呼び出しを記録する PostService
+type fakePostService struct {
+	services.PostService
+	posts []models.Post
+
+	mu      sync.Mutex
+	queries []services.PostQuery
+}
+
+func (s *fakePostService) GetAllPosts(_ context.Context, query services.PostQuery) ([]models.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, query)
+	var posts []models.Post
+	for _, post := range s.posts {
+		if len(query.IDs) == 0 || slices.Contains(query.IDs, post.ID) {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// fakeRelatedService は GetRelatedPostsByPostIDs の呼び出しを記録する RelatedPostService
+type fakeRelatedService struct {
+	services.RelatedPostService
+	related map[uint][]models.RelatedPost
+
+	mu    sync.Mutex
+	calls [][]uint
+}
+
+func (s *fakeRelatedService) GetRelatedPostsByPostIDs(_ context.Context, postIDs []uint, _ int) (map[uint][]models.RelatedPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, slices.Clone(postIDs))
+	result := make(map[uint][]models.RelatedPost)
+	for _, id := range postIDs {
+		if related, ok := s.related[id]; ok {
+			result[id] = related
+		}
+	}
+	return result, nil
+}
+
+func fixture() (*fakePostService, *fakeRelatedService) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	posts := &fakePostService{posts: []models.Post{
+		{ID: 1, Title: "First", Slug: "first", Author: "alice", Tags: []models.Tag{{Name: "go"}}, CreatedAt: day, UpdatedAt: day},
+		{ID: 2, Title: "Second", Author: "bob", Tags: []models.Tag{{Name: "go"}, {Name: "web"}}, CreatedAt: day.AddDate(0, 0, 1), UpdatedAt: day},
+		{ID: 3, Title: "Third", Author: "alice", CreatedAt: day.AddDate(0, 0, 2), UpdatedAt: day,
+			Series: &models.PostSeries{ID: 7, Title: "Intro", Position: 2}},
+	}}
+	related := &fakeRelatedService{related: map[uint][]models.RelatedPost{
+		1: {{PostID: 1, RelatedPostID: 2, Score: 0.5, Rank: 1}, {PostID: 1, RelatedPostID: 3, Score: 0.25, Rank: 2}},
+		2: {{PostID: 2, RelatedPostID: 1, Score: 0.5, Rank: 1}},
+	}}
+	return posts, related
+}
+
+func newExecutor(t *testing.T, posts services.PostService, related services.RelatedPostService, cfg graph.Config) *graph.Executor {
+	t.Helper()
+	executor, err := graph.NewExecutor(posts, related, cfg)
+	require.NoError(t, err)
+	return executor
+}
+
+// execute はクエリを実行し、data を JSON にして返す。エラーがあればテストを失敗させる
+func execute(t *testing.T, executor *graph.Executor, req graph.Request) string {
+	t.Helper()
+	result := executor.Execute(context.Background(), req)
+	require.Empty(t, result.Errors)
+	data, err := json.Marshal(result.Data)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func errorCode(result *graphql.Result) any {
+	if len(result.Errors) == 0 {
+		return nil
+	}
+	return result.Errors[0].Extensions["code"]
+}
+
+func hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExecute(t *testing.T) {
+	posts, related := fixture()
+	executor := newExecutor(t, posts, related, graph.Config{})
+
+	data := execute(t, executor, graph.Request{Query: `{
+		posts(first: 2) { id title author { name postCount } tags { name } series { title position } }
+		bySlug: post(slug: "first") { title }
+		missing: post(id: "99") { title }
+		tag(name: "web") { posts { title } }
+		authors { name }
+	}`})
+	assert.JSONEq(t, `{
+		"posts": [
+			{"id": "3", "title": "Third", "author": {"name": "alice", "postCount": 2}, "tags": [], "series": {"title": "Intro", "position": 2}},
+			{"id": "2", "title": "Second", "author": {"name": "bob", "postCount": 1}, "tags": [{"name": "go"}, {"name": "web"}], "series": null}
+		],
+		"bySlug": {"title": "First"},
+		"missing": null,
+		"tag": {"posts": [{"title": "Second"}]},
+		"authors": [{"name": "alice"}, {"name": "bob"}]
+	}`, data)
+	// 一覧はリクエストで 1 回だけ読み込む。ID で引く投稿は一覧と別にまとめて読み込む
+	require.Len(t, posts.queries, 2)
+	assert.Empty(t, posts.queries[0].IDs)
+	assert.Equal(t, []uint{99}, posts.queries[1].IDs)
+
+	result := executor.Execute(context.Background(), graph.Request{Query: `{ posts(first: 1000) { id } }`})
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "first must be between 1 and 100")
+}
+
+func TestExecute_BatchesLoads(t *testing.T) {
+	posts, related := fixture()
+	executor := newExecutor(t, posts, related, graph.Config{})
+
+	// 個別に指定した投稿は 1 回の GetAllPosts にまとめる
+	data := execute(t, executor, graph.Request{Query: `{
+		a: post(id: "1") { title }
+		b: post(id: "2") { title }
+		c: post(id: "1") { title }
+	}`})
+	assert.JSONEq(t, `{"a": {"title": "First"}, "b": {"title": "Second"}, "c": {"title": "First"}}`, data)
+	require.Len(t, posts.queries, 1)
+	assert.ElementsMatch(t, []uint{1, 2}, posts.queries[0].IDs)
+
+	// 各投稿の関連記事は 1 回で読み込み、関連記事の投稿は一覧で読み込んだものを使う
+	posts.queries = nil
+	data = execute(t, executor, graph.Request{Query: `{
+		posts { id related(first: 1) { score post { title } } }
+	}`})
+	assert.JSONEq(t, `[
+		{"id": "3", "related": []},
+		{"id": "2", "related": [{"score": 0.5, "post": {"title": "First"}}]},
+		{"id": "1", "related": [{"score": 0.5, "post": {"title": "Second"}}]}
+	]`, mustField(t, data, "posts"))
+	assert.Len(t, posts.queries, 1)
+	require.Len(t, related.calls, 1)
+	assert.ElementsMatch(t, []uint{1, 2, 3}, related.calls[0])
+
+	// 一覧を読み込まない場合、関連記事の投稿はまとめて ID で読み込む
+	posts.queries, related.calls = nil, nil
+	data = execute(t, executor, graph.Request{Query: `{
+		post(id: "1") { related { post { title } } }
+	}`})
+	assert.JSONEq(t, `{"post": {"related": [{"post": {"title": "Second"}}, {"post": {"title": "Third"}}]}}`, data)
+	require.Len(t, posts.queries, 2)
+	assert.ElementsMatch(t, []uint{2, 3}, posts.queries[1].IDs)
+	assert.Len(t, related.calls, 1)
+}
+
+func mustField(t *testing.T, data, name string) string {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(data), &fields))
+	return string(fields[name])
+}
+
+func TestExecute_Limits(t *testing.T) {
+	posts, related := fixture()
+	executor := newExecutor(t, posts, related, graph.Config{MaxDepth: 4, MaxComplexity: 100})
+
+	// 深さ 3、複雑さ 1 + 20 * (1 + 1 + (1 + 1)) = 81
+	result := executor.Execute(context.Background(), graph.Request{Query: `{ posts { id title author { name } } }`})
+	assert.Empty(t, result.Errors)
+
+	result = executor.Execute(context.Background(), graph.Request{Query: `{
+		posts { related { post { author { name } } } }
+	}`})
+	assert.Equal(t, "QUERY_TOO_DEEP", errorCode(result))
+	assert.Equal(t, "query depth 5 exceeds the limit of 4", result.Errors[0].Message)
+
+	// フラグメントも展開して数え、first は変数の値を使う
+	result = executor.Execute(context.Background(), graph.Request{
+		Query:     `query Q($n: Int) { posts(first: $n) { ...F } } fragment F on Post { id tags { name } }`,
+		Variables: map[string]any{"n": float64(20)},
+	})
+	assert.Equal(t, "QUERY_TOO_COMPLEX", errorCode(result))
+	assert.Equal(t, "query complexity 241 exceeds the limit of 100", result.Errors[0].Message)
+
+	result = executor.Execute(context.Background(), graph.Request{
+		Query:     `query Q($n: Int) { posts(first: $n) { ...F } } fragment F on Post { id tags { name } }`,
+		Variables: map[string]any{"n": float64(5)},
+	})
+	assert.Empty(t, result.Errors)
+
+	// イントロスペクションは数えない
+	result = executor.Execute(context.Background(), graph.Request{Query: `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`})
+	assert.Empty(t, result.Errors)
+}
+
+func TestExecute_PersistedQueries(t *testing.T) {
+	posts, related := fixture()
+	executor := newExecutor(t, posts, related, graph.Config{PersistedStore: cache.NewMemory(10), PersistedTTL: time.Hour})
+
+	query := `{ post(id: "1") { title } }`
+	persisted := func(query, sha string) graph.Request {
+		req := graph.Request{Query: query}
+		req.Extensions.PersistedQuery = &graph.PersistedQuery{Version: 1, SHA256Hash: sha}
+		return req
+	}
+
+	// 未登録のハッシュだけを送るとクライアントにクエリを送り直させる
+	result := executor.Execute(context.Background(), persisted("", hash(query)))
+	assert.Equal(t, "PERSISTED_QUERY_NOT_FOUND", errorCode(result))
+
+	result = executor.Execute(context.Background(), persisted(query, hash("other")))
+	assert.Equal(t, "PERSISTED_QUERY_HASH_MISMATCH", errorCode(result))
+
+	assert.JSONEq(t, `{"post": {"title": "First"}}`, execute(t, executor, persisted(query, hash(query))))
+	assert.JSONEq(t, `{"post": {"title": "First"}}`, execute(t, executor, persisted("", hash(query))))
+
+	// 検証を通らないクエリは登録しない
+	invalid := `{ post(id: "1") { unknown } }`
+	assert.NotEmpty(t, executor.Execute(context.Background(), persisted(invalid, hash(invalid))).Errors)
+	assert.Equal(t, "PERSISTED_QUERY_NOT_FOUND", errorCode(executor.Execute(context.Background(), persisted("", hash(invalid)))))
+}
+
+func TestExecute_PersistedOnly(t *testing.T) {
+	posts, related := fixture()
+	query := `{ post(id: "2") { title } }`
+	path := filepath.Join(t.TempDir(), "queries.json")
+	data, err := json.Marshal(map[string]string{hash(query): query})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	manifest, err := graph.LoadManifest(path)
+	require.NoError(t, err)
+	executor := newExecutor(t, posts, related, graph.Config{Manifest: manifest, PersistedOnly: true, PersistedStore: cache.NewMemory(10), PersistedTTL: time.Hour})
+
+	req := graph.Request{}
+	req.Extensions.PersistedQuery = &graph.PersistedQuery{Version: 1, SHA256Hash: hash(query)}
+	assert.JSONEq(t, `{"post": {"title": "Second"}}`, execute(t, executor, req))
+	assert.JSONEq(t, `{"post": {"title": "Second"}}`, execute(t, executor, graph.Request{Query: query}))
+
+	// マニフェストにないクエリは実行も登録もしない
+	other := `{ post(id: "1") { title } }`
+	assert.Equal(t, "PERSISTED_QUERY_REQUIRED", errorCode(executor.Execute(context.Background(), graph.Request{Query: other})))
+	req.Query, req.Extensions.PersistedQuery.SHA256Hash = other, hash(other)
+	assert.Equal(t, "PERSISTED_QUERY_REQUIRED", errorCode(executor.Execute(context.Background(), req)))
+
+	// ハッシュの合わないマニフェストは読み込まない
+	require.NoError(t, os.WriteFile(path, []byte(`{"`+hash("x")+`": "{ posts { id } }"}`), 0o644))
+	_, err = graph.LoadManifest(path)
+	assert.Error(t, err)
+}
+
+func TestExecute_ServiceError(t *testing.T) {
+	failing := &failingPostService{err: errors.New("database is down")}
+	executor := newExecutor(t, failing, &fakeRelatedService{}, graph.Config{})
+
+	result := executor.Execute(context.Background(), graph.Request{Query: `{ posts { id } tags { name } }`})
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, "database is down", result.Errors[0].Message)
+	// 失敗した一覧の読み込みはリクエストの中で繰り返さない
+	assert.Equal(t, 1, failing.calls)
+}
+
+type failingPostService struct {
+	services.PostService
+	err   error
+	calls int
+}
+
+func (s *failingPostService) GetAllPosts(context.Context, services.PostQuery) ([]models.Post, error) {
+	s.calls++
+	return nil, s.err
+}
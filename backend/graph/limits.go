@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize は件数を指定する引数 (first) のない一覧のフィールド (Post.tags) の件数の見積もり
+const defaultListSize = 10
+
+// cost はクエリの深さと複雑さ
+type cost struct {
+	Depth      int
+	Complexity int
+}
+
+// measure は実行する操作の深さと複雑さを見積もる。フラグメントは展開して数える。
+// 複雑さは各フィールドを 1 とし、一覧のフィールドは子の複雑さに件数 (first、なければ defaultListSize) を掛ける。
+// イントロスペクション (__schema など) は数えない。
+// 検証 (graphql.ValidateDocument) が通った文書に対して呼ぶ。
+func measure(schema *graphql.Schema, doc *ast.Document, operation *ast.OperationDefinition, variables map[string]any) cost {
+	m := &measurer{schema: schema, variables: variables, fragments: make(map[string]*ast.FragmentDefinition)}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	return m.selectionSet(root, operation.SelectionSet)
+}
+
+type measurer struct {
+	schema    *graphql.Schema
+	variables map[string]any
+	fragments map[string]*ast.FragmentDefinition
+}
+
+func (m *measurer) selectionSet(parent *graphql.Object, set *ast.SelectionSet) cost {
+	var total cost
+	if parent == nil || set == nil {
+		return total
+	}
+	for _, selection := range set.Selections {
+		var c cost
+		switch s := selection.(type) {
+		case *ast.Field:
+			c = m.field(parent, s)
+		case *ast.InlineFragment:
+			c = m.selectionSet(m.typeCondition(parent, s.TypeCondition), s.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment, ok := m.fragments[s.Name.Value]; ok {
+				c = m.selectionSet(m.typeCondition(parent, fragment.TypeCondition), fragment.SelectionSet)
+			}
+		}
+		total.Depth = max(total.Depth, c.Depth)
+		total.Complexity += c.Complexity
+	}
+	return total
+}
+
+func (m *measurer) field(parent *graphql.Object, field *ast.Field) cost {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return cost{}
+	}
+	def, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		return cost{}
+	}
+
+	listSize := 1
+	fieldType := def.Type
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	if _, ok := fieldType.(*graphql.List); ok {
+		listSize = m.intArg(def, field, "first", defaultListSize)
+	}
+
+	child, _ := graphql.GetNamed(def.Type).(*graphql.Object)
+	c := m.selectionSet(child, field.SelectionSet)
+	return cost{Depth: c.Depth + 1, Complexity: 1 + listSize*c.Complexity}
+}
+
+// intArg はフィールドの整数の引数の値。指定されていなければ既定値、引数がなければ fallback を返す
+func (m *measurer) intArg(def *graphql.FieldDefinition, field *ast.Field, name string, fallback int) int {
+	value := fallback
+	for _, arg := range def.Args {
+		if arg.Name() == name {
+			if v, ok := arg.DefaultValue.(int); ok {
+				value = v
+			}
+		}
+	}
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != name {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				value = n
+			}
+		case *ast.Variable:
+			switch n := m.variables[v.Name.Value].(type) {
+			case int:
+				value = n
+			case float64:
+				value = int(n)
+			case json.Number:
+				if i, err := n.Int64(); err == nil {
+					value = int(i)
+				}
+			}
+		}
+	}
+	return max(value, 0)
+}
+
+func (m *measurer) typeCondition(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	if object, ok := m.schema.Type(condition.Name.Value).(*graphql.Object); ok {
+		return object
+	}
+	return parent
+}
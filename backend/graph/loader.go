@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"blog/services"
+	"context"
+	"sync"
+)
+
+// loader はキーごとの読み込み結果をリクエストの間保持し、まとめて読み込む (DataLoader と同じ考え方)。
+// Load はキーを登録して thunk を返し、最初に thunk が呼ばれた時にそれまでに登録された全てのキーを 1 回の batch で読み込む。
+// graphql-go は同じ深さの thunk を幅優先でまとめて呼ぶため、一覧の各要素のフィールドは 1 回の batch になる。
+type loader[K comparable, V any] struct {
+	batch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	loaded  map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](batch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{batch: batch, loaded: make(map[K]V), errs: make(map[K]error)}
+}
+
+// Load は key を次の batch に登録し、値を返す thunk を返す。batch の結果にないキーはゼロ値になる
+func (l *loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.loaded[key]; !ok {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.loaded[key]; !ok {
+			if _, failed := l.errs[key]; !failed {
+				l.flush(ctx)
+			}
+		}
+		return l.loaded[key], l.errs[key]
+	}
+}
+
+// Prime は読み込み済みの値を登録する。一覧で読み込んだ投稿を ID で引く場合に再度読み込まないようにする
+func (l *loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.loaded[key]; !ok {
+		l.loaded[key] = value
+	}
+}
+
+// flush は登録済みのキーを 1 回の batch で読み込む。l.mu を保持して呼ぶ
+func (l *loader[K, V]) flush(ctx context.Context) {
+	keys := make([]K, 0, len(l.pending))
+	seen := make(map[K]bool, len(l.pending))
+	for _, key := range l.pending {
+		if _, ok := l.loaded[key]; !ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	l.pending = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.loaded[key] = values[key]
+	}
+}
+
+// postQuery は GraphQL で読み込む投稿のフィールドと関連。一覧の投稿もそのまま ID で引けるよう全てのフィールドを読み込む。
+// 下書きは一覧にも ID の読み込みにも含めない
+var postQuery = services.PostQuery{Include: []string{repositories.IncludeTags, repositories.IncludeSeries}, Published: true}
+
+// loaders はリクエストごとの読み込み。投稿の一覧はリクエストで 1 回だけ読み込み、タグや著者ごとの投稿もそこから引く
+type loaders struct {
+	// all は全ての投稿 (新しい順)
+	all     func() ([]models.Post, error)
+	posts   *loader[uint, *models.Post]
+	related *loader[uint, []models.RelatedPost]
+}
+
+type loadersKey struct{}
+
+func newLoaders(ctx context.Context, posts services.PostService, related services.RelatedPostService) *loaders {
+	l := &loaders{
+		posts: newLoader(func(ctx context.Context, ids []uint) (map[uint]*models.Post, error) {
+			query := postQuery
+			query.IDs = ids
+			list, err := posts.GetAllPosts(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[uint]*models.Post, len(list))
+			for i := range list {
+				result[list[i].ID] = &list[i]
+			}
+			return result, nil
+		}),
+		related: newLoader(func(ctx context.Context, ids []uint) (map[uint][]models.RelatedPost, error) {
+			return related.GetRelatedPostsByPostIDs(ctx, ids, services.MaxRelatedPosts)
+		}),
+	}
+	l.all = sync.OnceValues(func() ([]models.Post, error) {
+		list, err := posts.GetAllPosts(ctx, postQuery)
+		if err != nil {
+			return nil, err
+		}
+		sortNewestFirst(list)
+		for i := range list {
+			l.posts.Prime(list[i].ID, &list[i])
+		}
+		return list, nil
+	})
+	return l
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
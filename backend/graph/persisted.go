@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"blog/cache"
+	"blog/logging"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// PersistedQuery は extensions.persistedQuery (Apollo の Automatic Persisted Queries と同じ形式)
+type PersistedQuery struct {
+	Version int `json:"version"`
+	// SHA256Hash はクエリ文字列の SHA-256 (16 進数)
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// 永続化クエリのエラーの extensions.code。クライアントは PERSISTED_QUERY_NOT_FOUND を受けたらクエリ文字列を付けて送り直す
+const (
+	codePersistedQueryNotFound = "PERSISTED_QUERY_NOT_FOUND"
+	codePersistedQueryMismatch = "PERSISTED_QUERY_HASH_MISMATCH"
+	codePersistedQueryRequired = "PERSISTED_QUERY_REQUIRED"
+)
+
+// persistedQueries はハッシュで参照できるクエリ。事前に登録したクエリ (マニフェスト) と、
+// クライアントがクエリ文字列を付けて送った時に自動で登録するクエリを扱う。
+type persistedQueries struct {
+	manifest map[string]string
+	// only の場合はマニフェストにあるクエリだけを実行し、自動の登録もしない
+	only  bool
+	store cache.Backend
+	ttl   time.Duration
+}
+
+// LoadManifest は事前に登録するクエリを JSON ファイル ({"<SHA-256>": "<クエリ>", ...}) から読み込む。
+// ハッシュがクエリと合わない場合はエラーを返す
+func LoadManifest(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persisted queries: %w", err)
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid persisted queries %s: %w", path, err)
+	}
+	for hash, query := range manifest {
+		if queryHash(query) != strings.ToLower(hash) {
+			return nil, fmt.Errorf("invalid persisted queries %s: hash %s does not match its query", path, hash)
+		}
+	}
+	return manifest, nil
+}
+
+// lookup はリクエストで実行するクエリ文字列を返す。クエリを実行できない場合は extensions.code 付きのエラーを返す
+func (p *persistedQueries) lookup(ctx context.Context, query string, persisted *PersistedQuery) (string, *requestError) {
+	if persisted == nil {
+		if p.only && p.manifest[queryHash(query)] == "" {
+			return "", &requestError{message: "only persisted queries are allowed", code: codePersistedQueryRequired}
+		}
+		return query, nil
+	}
+	if persisted.Version != 1 {
+		return "", &requestError{message: fmt.Sprintf("unsupported persisted query version %d", persisted.Version)}
+	}
+	hash := strings.ToLower(persisted.SHA256Hash)
+
+	if query != "" {
+		if queryHash(query) != hash {
+			return "", &requestError{message: "provided sha256Hash does not match query", code: codePersistedQueryMismatch}
+		}
+		return p.lookup(ctx, query, nil)
+	}
+
+	if query, ok := p.manifest[hash]; ok {
+		return query, nil
+	}
+	if p.store != nil && !p.only {
+		data, ok, err := p.store.Get(ctx, persistedKey(hash))
+		if err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "persisted query lookup failed", "hash", hash, "error", err)
+		}
+		if ok {
+			return string(data), nil
+		}
+	}
+	return "", &requestError{message: "PersistedQueryNotFound", code: codePersistedQueryNotFound}
+}
+
+// register は検証の通ったクエリを自動で登録する。マニフェストにあるクエリは登録しない
+func (p *persistedQueries) register(ctx context.Context, query string, persisted *PersistedQuery) {
+	if persisted == nil || p.store == nil || p.only {
+		return
+	}
+	hash := queryHash(query)
+	if _, ok := p.manifest[hash]; ok {
+		return
+	}
+	if err := p.store.Set(ctx, persistedKey(hash), []byte(query), p.ttl); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "persisted query registration failed", "hash", hash, "error", err)
+	}
+}
+
+func persistedKey(hash string) string {
+	return "graphql:query:" + hash
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
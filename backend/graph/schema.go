@@ -0,0 +1,364 @@
+package graph
+
+import (
+	"blog/models"
+	"blog/services"
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+)
+
+// MaxPageSize は一覧のフィールドの first に指定できる最大値
+const MaxPageSize = 100
+
+// tag と author は名前だけを持ち、投稿は全ての投稿の一覧から引く
+type (
+	tag    struct{ name string }
+	author struct{ name string }
+)
+
+// newSchema は投稿、著者、タグのスキーマを生成する。コメントはまだ実装されていないためスキーマにも含めない。
+// 読み取り専用で、投稿の作成や更新は REST API で行う。
+func newSchema() (graphql.Schema, error) {
+	postType := graphql.NewObject(graphql.ObjectConfig{Name: "Post", Description: "投稿", Fields: graphql.Fields{}})
+	tagType := graphql.NewObject(graphql.ObjectConfig{Name: "Tag", Description: "タグ", Fields: graphql.Fields{}})
+	authorType := graphql.NewObject(graphql.ObjectConfig{Name: "Author", Description: "著者。投稿の author に書かれた名前ごとにまとめる", Fields: graphql.Fields{}})
+	seriesType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "PostSeries",
+		Description: "投稿が属する連載と何回目か",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolveSeries(func(s *models.PostSeries) any { return formatID(s.ID) })},
+			"title":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolveSeries(func(s *models.PostSeries) any { return s.Title })},
+			"position": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolveSeries(func(s *models.PostSeries) any { return s.Position })},
+		},
+	})
+	relatedType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "RelatedPost",
+		Description: "類似する投稿とそのスコア",
+		Fields: graphql.Fields{
+			"score": &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(models.RelatedPost).Score, nil
+			}},
+			"post": &graphql.Field{Type: graphql.NewNonNull(postType), Resolve: func(p graphql.ResolveParams) (any, error) {
+				return thunk(loadersFrom(p.Context).posts.Load(p.Context, p.Source.(models.RelatedPost).RelatedPostID)), nil
+			}},
+		},
+	})
+
+	postFields := graphql.Fields{
+		"id":                 postField(graphql.NewNonNull(graphql.ID), func(post *models.Post) any { return formatID(post.ID) }),
+		"title":              postField(graphql.NewNonNull(graphql.String), func(post *models.Post) any { return post.Title }),
+		"slug":               postField(graphql.String, func(post *models.Post) any { return nullable(post.Slug) }),
+		"content":            postField(graphql.NewNonNull(graphql.String), func(post *models.Post) any { return post.Content }),
+		"excerpt":            postField(graphql.NewNonNull(graphql.String), func(post *models.Post) any { return post.Excerpt }),
+		"description":        postField(graphql.String, func(post *models.Post) any { return nullable(post.Description) }),
+		"coverImage":         postField(graphql.String, func(post *models.Post) any { return nullable(post.CoverImage) }),
+		"draft":              postField(graphql.NewNonNull(graphql.Boolean), func(post *models.Post) any { return post.Draft }),
+		"wordCount":          postField(graphql.NewNonNull(graphql.Int), func(post *models.Post) any { return post.WordCount }),
+		"readingTimeMinutes": postField(graphql.NewNonNull(graphql.Int), func(post *models.Post) any { return post.ReadingTimeMinutes }),
+		"createdAt":          postField(graphql.NewNonNull(graphql.DateTime), func(post *models.Post) any { return post.CreatedAt }),
+		"updatedAt":          postField(graphql.NewNonNull(graphql.DateTime), func(post *models.Post) any { return post.UpdatedAt }),
+		"author": postField(authorType, func(post *models.Post) any {
+			if post.Author == "" {
+				return nil
+			}
+			return author{name: post.Author}
+		}),
+		"tags": postField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))), func(post *models.Post) any {
+			tags := make([]tag, len(post.Tags))
+			for i, t := range post.Tags {
+				tags[i] = tag{name: t.Name}
+			}
+			return tags
+		}),
+		"series": postField(seriesType, func(post *models.Post) any {
+			if post.Series == nil {
+				return nil
+			}
+			return post.Series
+		}),
+		"related": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(relatedType))),
+			Description: "類似する投稿 (スコアの高い順)",
+			Args: graphql.FieldConfigArgument{
+				"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 5},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				first := p.Args["first"].(int)
+				if first < 1 || first > services.MaxRelatedPosts {
+					return nil, fmt.Errorf("%w: first must be between 1 and %d", services.ErrInvalidInput, services.MaxRelatedPosts)
+				}
+				load := loadersFrom(p.Context).related.Load(p.Context, p.Source.(*models.Post).ID)
+				return func() (any, error) {
+					related, err := load()
+					if err != nil {
+						return nil, err
+					}
+					return related[:min(first, len(related))], nil
+				}, nil
+			},
+		},
+	}
+	for name, field := range postFields {
+		postType.AddFieldConfig(name, field)
+	}
+
+	tagType.AddFieldConfig("name", &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+		return p.Source.(tag).name, nil
+	}})
+	tagType.AddFieldConfig("postCount", &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (any, error) {
+		posts, err := postsWhere(p, hasTag(p.Source.(tag).name))
+		return len(posts), err
+	}})
+	tagType.AddFieldConfig("posts", pagedPosts(postType, "タグの付いた投稿 (新しい順)", func(p graphql.ResolveParams) func(*models.Post) bool {
+		return hasTag(p.Source.(tag).name)
+	}))
+
+	authorType.AddFieldConfig("name", &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+		return p.Source.(author).name, nil
+	}})
+	authorType.AddFieldConfig("postCount", &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (any, error) {
+		posts, err := postsWhere(p, byAuthor(p.Source.(author).name))
+		return len(posts), err
+	}})
+	authorType.AddFieldConfig("posts", pagedPosts(postType, "著者の投稿 (新しい順)", func(p graphql.ResolveParams) func(*models.Post) bool {
+		return byAuthor(p.Source.(author).name)
+	}))
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type:        postType,
+				Description: "ID または slug で指定した投稿",
+				Args: graphql.FieldConfigArgument{
+					"id":   &graphql.ArgumentConfig{Type: graphql.ID},
+					"slug": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: resolvePost,
+			},
+			"posts": pagedPosts(postType, "投稿 (新しい順)。tag と author で絞り込める", func(p graphql.ResolveParams) func(*models.Post) bool {
+				tagName, _ := p.Args["tag"].(string)
+				authorName, _ := p.Args["author"].(string)
+				return func(post *models.Post) bool {
+					return (tagName == "" || hasTag(tagName)(post)) && (authorName == "" || post.Author == authorName)
+				}
+			}, "tag", "author"),
+			"tag": &graphql.Field{
+				Type: tagType,
+				Args: graphql.FieldConfigArgument{"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return findName(p, tagNames, p.Args["name"].(string), func(name string) any { return tag{name: name} })
+				},
+			},
+			"tags": namedList(tagType, "投稿に付いている全てのタグ (名前順)", tagNames, func(name string) any { return tag{name: name} }),
+			"author": &graphql.Field{
+				Type: authorType,
+				Args: graphql.FieldConfigArgument{"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return findName(p, authorNames, p.Args["name"].(string), func(name string) any { return author{name: name} })
+				},
+			},
+			"authors": namedList(authorType, "投稿のある全ての著者 (名前順)", authorNames, func(name string) any { return author{name: name} }),
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func resolvePost(p graphql.ResolveParams) (any, error) {
+	l := loadersFrom(p.Context)
+	if id, ok := p.Args["id"].(string); ok {
+		parsed, err := strconv.ParseUint(id, 10, 64)
+		if err != nil || parsed == 0 {
+			return nil, nil
+		}
+		return thunk(l.posts.Load(p.Context, uint(parsed))), nil
+	}
+	if slug, ok := p.Args["slug"].(string); ok {
+		posts, err := l.all()
+		if err != nil {
+			return nil, err
+		}
+		for i := range posts {
+			if posts[i].Slug == slug {
+				return &posts[i], nil
+			}
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w: either id or slug is required", services.ErrInvalidInput)
+}
+
+// pagedPosts は first と offset で区切る投稿の一覧のフィールド。filterArgs は絞り込みに使う文字列の引数
+func pagedPosts(postType *graphql.Object, description string, filter func(p graphql.ResolveParams) func(*models.Post) bool, filterArgs ...string) *graphql.Field {
+	args := graphql.FieldConfigArgument{
+		"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+		"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+	}
+	for _, name := range filterArgs {
+		args[name] = &graphql.ArgumentConfig{Type: graphql.String}
+	}
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+		Description: description,
+		Args:        args,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			first, offset, err := pageArgs(p)
+			if err != nil {
+				return nil, err
+			}
+			posts, err := postsWhere(p, filter(p))
+			if err != nil {
+				return nil, err
+			}
+			offset = min(offset, len(posts))
+			return posts[offset:min(offset+first, len(posts))], nil
+		},
+	}
+}
+
+// namedList は投稿から集めた名前 (タグや著者) の一覧のフィールド
+func namedList(itemType *graphql.Object, description string, names func([]models.Post) []string, item func(string) any) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType))),
+		Description: description,
+		Args: graphql.FieldConfigArgument{
+			"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: MaxPageSize},
+			"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+		},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			first, offset, err := pageArgs(p)
+			if err != nil {
+				return nil, err
+			}
+			posts, err := loadersFrom(p.Context).all()
+			if err != nil {
+				return nil, err
+			}
+			all := names(posts)
+			offset = min(offset, len(all))
+			items := make([]any, 0, first)
+			for _, name := range all[offset:min(offset+first, len(all))] {
+				items = append(items, item(name))
+			}
+			return items, nil
+		},
+	}
+}
+
+func findName(p graphql.ResolveParams, names func([]models.Post) []string, name string, item func(string) any) (any, error) {
+	posts, err := loadersFrom(p.Context).all()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(names(posts), name) {
+		return nil, nil
+	}
+	return item(name), nil
+}
+
+func pageArgs(p graphql.ResolveParams) (first, offset int, err error) {
+	first, offset = p.Args["first"].(int), p.Args["offset"].(int)
+	if first < 1 || first > MaxPageSize {
+		return 0, 0, fmt.Errorf("%w: first must be between 1 and %d", services.ErrInvalidInput, MaxPageSize)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("%w: offset must not be negative", services.ErrInvalidInput)
+	}
+	return first, offset, nil
+}
+
+// postsWhere は全ての投稿のうち match に合うものを新しい順に返す
+func postsWhere(p graphql.ResolveParams, match func(*models.Post) bool) ([]*models.Post, error) {
+	posts, err := loadersFrom(p.Context).all()
+	if err != nil {
+		return nil, err
+	}
+	var matched []*models.Post
+	for i := range posts {
+		if match(&posts[i]) {
+			matched = append(matched, &posts[i])
+		}
+	}
+	return matched, nil
+}
+
+func hasTag(name string) func(*models.Post) bool {
+	return func(post *models.Post) bool {
+		return slices.ContainsFunc(post.Tags, func(t models.Tag) bool { return t.Name == name })
+	}
+}
+
+func byAuthor(name string) func(*models.Post) bool {
+	return func(post *models.Post) bool { return post.Author == name }
+}
+
+func tagNames(posts []models.Post) []string {
+	var names []string
+	for _, post := range posts {
+		for _, t := range post.Tags {
+			names = append(names, t.Name)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+func authorNames(posts []models.Post) []string {
+	var names []string
+	for _, post := range posts {
+		if post.Author != "" {
+			names = append(names, post.Author)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// sortNewestFirst は投稿を作成日時の新しい順 (同じ場合は ID の大きい順) に並べる
+func sortNewestFirst(posts []models.Post) {
+	slices.SortStableFunc(posts, func(a, b models.Post) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+}
+
+func postField(t graphql.Output, value func(*models.Post) any) *graphql.Field {
+	return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (any, error) {
+		return value(p.Source.(*models.Post)), nil
+	}}
+}
+
+func resolveSeries(value func(*models.PostSeries) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return value(p.Source.(*models.PostSeries)), nil
+	}
+}
+
+// thunk は loader の thunk を graphql-go が遅延して解決する関数の形にする。見つからない場合は null
+func thunk[V any](load func() (*V, error)) func() (any, error) {
+	return func() (any, error) {
+		v, err := load()
+		if err != nil || v == nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// nullable は空文字列を null にする
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
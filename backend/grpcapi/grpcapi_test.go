@@ -0,0 +1,233 @@
+package grpcapi_test
+
+import (
+	"blog/grpcapi"
+	blogv1 "blog/proto/blog/v1"
+	"blog/repositories"
+	"blog/services"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+const token = "secret"
+
+// startServer はメモリ上の接続でサーバーを起動し、クライアントの接続を返す
+func startServer(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	server, err := grpcapi.NewServer("", token, services.NewPostService(repositories.NewMemoryPostRepository()))
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func authenticated(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestNewServer_RequiresToken(t *testing.T) {
+	_, err := grpcapi.NewServer(":0", "", services.NewPostService(repositories.NewMemoryPostRepository()))
+	assert.Error(t, err)
+}
+
+func TestServer_Auth(t *testing.T) {
+	conn := startServer(t)
+	client := blogv1.NewPostServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := client.ListPosts(ctx, &blogv1.ListPostsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.ListPosts(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong"), &blogv1.ListPostsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.ListPosts(authenticated(ctx), &blogv1.ListPostsRequest{})
+	assert.NoError(t, err)
+
+	// ヘルスチェックはトークンなしで呼べる
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.GetStatus())
+
+	// リフレクションはトークンが必要
+	_, err = listServices(ctx, conn)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	names, err := listServices(authenticated(ctx), conn)
+	require.NoError(t, err)
+	assert.Contains(t, names, "blog.v1.PostService")
+}
+
+func listServices(ctx context.Context, conn *grpc.ClientConn) ([]string, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := &reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}}
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		names = append(names, service.GetName())
+	}
+	return names, nil
+}
+
+func TestPostService(t *testing.T) {
+	client := blogv1.NewPostServiceClient(startServer(t))
+	ctx := authenticated(context.Background())
+
+	created, err := client.CreatePost(ctx, &blogv1.CreatePostRequest{Post: &blogv1.Post{
+		Title: "Hello", Content: "Hello gRPC world", Author: "alice", Tags: []string{"go"}, WordCount: 100,
+	}})
+	require.NoError(t, err)
+	assert.NotZero(t, created.GetId())
+	assert.Equal(t, []string{"go"}, created.GetTags())
+	assert.Equal(t, int32(3), created.GetWordCount(), "output only fields are computed")
+	assert.NotNil(t, created.GetCreatedAt())
+
+	// read_mask のフィールドと id だけを返す
+	got, err := client.GetPost(ctx, &blogv1.GetPostRequest{Id: created.GetId(), ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}}})
+	require.NoError(t, err)
+	assert.Equal(t, created.GetId(), got.GetId())
+	assert.Equal(t, "Hello", got.GetTitle())
+	assert.Empty(t, got.GetContent())
+	assert.Nil(t, got.GetCreatedAt())
+
+	// update_mask のフィールドだけを更新する
+	updated, err := client.UpdatePost(ctx, &blogv1.UpdatePostRequest{
+		Post:       &blogv1.Post{Id: created.GetId(), Title: "Hello again", Content: "ignored"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello again", updated.GetTitle())
+	assert.Equal(t, "Hello gRPC world", updated.GetContent())
+	assert.Equal(t, []string{"go"}, updated.GetTags())
+
+	for _, tc := range []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"unknown read_mask field", func() error {
+			_, err := client.GetPost(ctx, &blogv1.GetPostRequest{Id: created.GetId(), ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"comments"}}})
+			return err
+		}, codes.InvalidArgument},
+		{"output only update_mask field", func() error {
+			_, err := client.UpdatePost(ctx, &blogv1.UpdatePostRequest{Post: &blogv1.Post{Id: created.GetId()}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"word_count"}}})
+			return err
+		}, codes.InvalidArgument},
+		{"missing post", func() error {
+			_, err := client.UpdatePost(ctx, &blogv1.UpdatePostRequest{Post: &blogv1.Post{Id: 999, Title: "x"}})
+			return err
+		}, codes.NotFound},
+		{"invalid input", func() error {
+			_, err := client.CreatePost(ctx, &blogv1.CreatePostRequest{Post: &blogv1.Post{Title: "x", Slug: strings.Repeat("a", 1000)}})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid page_token", func() error {
+			_, err := client.ListPosts(ctx, &blogv1.ListPostsRequest{PageToken: "?"})
+			return err
+		}, codes.InvalidArgument},
+	} {
+		assert.Equal(t, tc.code, status.Code(tc.call()), tc.name)
+	}
+
+	_, err = client.DeletePost(ctx, &blogv1.DeletePostRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	_, err = client.GetPost(ctx, &blogv1.GetPostRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestPostService_ListPages(t *testing.T) {
+	client := blogv1.NewPostServiceClient(startServer(t))
+	ctx := authenticated(context.Background())
+	for _, title := range []string{"first", "second", "third"} {
+		_, err := client.CreatePost(ctx, &blogv1.CreatePostRequest{Post: &blogv1.Post{Title: title, Content: "body"}})
+		require.NoError(t, err)
+	}
+	// 下書きは一覧にも ID の取得にも出さない
+	draft, err := client.CreatePost(ctx, &blogv1.CreatePostRequest{Post: &blogv1.Post{Title: "draft", Content: "body", Draft: true}})
+	require.NoError(t, err)
+	_, err = client.GetPost(ctx, &blogv1.GetPostRequest{Id: draft.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	var titles []string
+	req := &blogv1.ListPostsRequest{PageSize: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 2)
+		resp, err := client.ListPosts(ctx, req)
+		require.NoError(t, err)
+		for _, post := range resp.GetPosts() {
+			titles = append(titles, post.GetTitle())
+			assert.Empty(t, post.GetContent(), "content is omitted without read_mask")
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, titles)
+}
+
+// google.api.http のマッピングで REST API と同じパスから呼べる
+func TestPostService_Gateway(t *testing.T) {
+	ctx := context.Background()
+	mux := runtime.NewServeMux()
+	require.NoError(t, blogv1.RegisterPostServiceHandlerServer(ctx, mux, grpcapi.NewPostServer(services.NewPostService(repositories.NewMemoryPostRepository()))))
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(`{"title":"Hello","content":"body"}`)))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/api/v1/posts/1?update_mask=title", strings.NewReader(`{"title":"Updated"}`)))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/posts/1?read_mask=title,content", nil))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var post map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &post))
+	assert.Equal(t, "Updated", post["title"])
+	assert.Equal(t, "body", post["content"])
+	assert.Nil(t, post["createdAt"])
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/posts/2", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
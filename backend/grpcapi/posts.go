@@ -0,0 +1,335 @@
+package grpcapi
+
+import (
+	"blog/logging"
+	"blog/models"
+	blogv1 "blog/proto/blog/v1"
+	"blog/repositories"
+	"blog/services"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ListPosts の page_size の既定値と上限
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// postDescriptor は Post のフィールド。マスクのパスの検証と、マスクにないフィールドを外すのに使う
+var postDescriptor = (&blogv1.Post{}).ProtoReflect().Descriptor().Fields()
+
+var (
+	// allFields は Post の全てのフィールド
+	allFields = fieldNames()
+	// listFields は ListPosts で read_mask を省略した場合に返すフィールド (本文以外)。REST API の一覧と同じ
+	listFields = slices.DeleteFunc(fieldNames(), func(field string) bool { return field == "content" })
+	// updatableFields は UpdatePost で更新できるフィールド。PostService.UpdatePost が更新するものと同じ
+	updatableFields = []string{"title", "content", "slug", "description", "cover_image", "draft", "metadata", "tags"}
+)
+
+func fieldNames() []string {
+	names := make([]string, postDescriptor.Len())
+	for i := range names {
+		names[i] = string(postDescriptor.Get(i).Name())
+	}
+	return names
+}
+
+type postServer struct {
+	blogv1.UnimplementedPostServiceServer
+	posts services.PostService
+}
+
+// NewPostServer は blogv1.PostServiceServer を PostService で実装する
+func NewPostServer(posts services.PostService) blogv1.PostServiceServer {
+	return &postServer{posts: posts}
+}
+
+func (s *postServer) ListPosts(ctx context.Context, req *blogv1.ListPostsRequest) (*blogv1.ListPostsResponse, error) {
+	fields, err := readMask(req.GetReadMask(), listFields)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+	after, err := parsePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	// マスクのフィールド名は posts テーブルの列名と同じ
+	query := services.PostQuery{Fields: slices.DeleteFunc(slices.Clone(fields), func(field string) bool { return field == "tags" }), Published: true}
+	if slices.Contains(fields, "tags") {
+		query.Include = []string{repositories.IncludeTags}
+	}
+	posts, err := s.posts.GetAllPosts(ctx, query)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	// 一覧は ID 順なので、ページのトークンは前のページの最後の ID にする
+	resp := &blogv1.ListPostsResponse{}
+	for i := range posts {
+		if posts[i].ID <= after {
+			continue
+		}
+		if len(resp.Posts) == pageSize {
+			resp.NextPageToken = pageToken(resp.Posts[pageSize-1].GetId())
+			break
+		}
+		post, err := toProto(&posts[i], fields)
+		if err != nil {
+			return nil, serviceError(ctx, err)
+		}
+		resp.Posts = append(resp.Posts, post)
+	}
+	return resp, nil
+}
+
+func (s *postServer) GetPost(ctx context.Context, req *blogv1.GetPostRequest) (*blogv1.Post, error) {
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	fields, err := readMask(req.GetReadMask(), allFields)
+	if err != nil {
+		return nil, err
+	}
+	post, err := s.posts.GetPostByID(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	// 下書きは一覧と同じく見つからないものとして扱う
+	if post.Draft {
+		return nil, serviceError(ctx, services.ErrNotFound)
+	}
+	return s.response(ctx, post, fields)
+}
+
+func (s *postServer) CreatePost(ctx context.Context, req *blogv1.CreatePostRequest) (*blogv1.Post, error) {
+	if req.GetPost() == nil {
+		return nil, status.Error(codes.InvalidArgument, "post is required")
+	}
+	post := fromProto(req.GetPost())
+	if err := s.posts.CreatePost(ctx, &post); err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return s.response(ctx, &post, allFields)
+}
+
+// UpdatePost は現在の投稿に update_mask のフィールドだけを上書きして PostService.UpdatePost に渡す
+func (s *postServer) UpdatePost(ctx context.Context, req *blogv1.UpdatePostRequest) (*blogv1.Post, error) {
+	id := uint(req.GetPost().GetId())
+	if id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "post.id is required")
+	}
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = updatableFields
+	}
+	for _, path := range paths {
+		if !slices.Contains(updatableFields, path) {
+			return nil, status.Errorf(codes.InvalidArgument, "field %q in update_mask cannot be updated (updatable: %s)", path, strings.Join(updatableFields, ", "))
+		}
+	}
+
+	post, err := s.posts.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	input := fromProto(req.GetPost())
+	// Tags が nil の場合は既存のタグを維持する
+	post.Tags = nil
+	for _, path := range paths {
+		switch path {
+		case "title":
+			post.Title = input.Title
+		case "content":
+			post.Content = input.Content
+		case "slug":
+			post.Slug = input.Slug
+		case "description":
+			post.Description = input.Description
+		case "cover_image":
+			post.CoverImage = input.CoverImage
+		case "draft":
+			post.Draft = input.Draft
+		case "metadata":
+			post.Metadata = input.Metadata
+		case "tags":
+			post.Tags = make([]models.Tag, len(input.Tags))
+			copy(post.Tags, input.Tags)
+		}
+	}
+	if err := s.posts.UpdatePost(ctx, id, *post); err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	// 語数などの計算したフィールドとタグを含めて返す
+	updated, err := s.posts.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return s.response(ctx, updated, allFields)
+}
+
+func (s *postServer) DeletePost(ctx context.Context, req *blogv1.DeletePostRequest) (*emptypb.Empty, error) {
+	if req.GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := s.posts.DeletePost(ctx, uint(req.GetId())); err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *postServer) response(ctx context.Context, post *models.Post, fields []string) (*blogv1.Post, error) {
+	resp, err := toProto(post, fields)
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+	return resp, nil
+}
+
+// readMask はマスクのパスを検証して返す。マスクが空の場合は defaults を返す
+func readMask(mask *fieldmaskpb.FieldMask, defaults []string) ([]string, error) {
+	paths := mask.GetPaths()
+	if len(paths) == 0 {
+		return defaults, nil
+	}
+	for _, path := range paths {
+		if postDescriptor.ByName(protoreflect.Name(path)) == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown field %q in read_mask (allowed: %s)", path, strings.Join(allFields, ", "))
+		}
+	}
+	return paths, nil
+}
+
+// pageToken は after より後の投稿から始まるページのトークンを返す。クライアントには中身を意識させない
+func pageToken(after uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(after, 10)))
+}
+
+func parsePageToken(token string) (uint, error) {
+	if token == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	after, err := strconv.ParseUint(string(data), 10, 0)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	return uint(after), nil
+}
+
+// toProto は投稿を fields のフィールドだけを含む Post に変換する。id は常に含める
+func toProto(post *models.Post, fields []string) (*blogv1.Post, error) {
+	metadata, err := toStruct(post.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	resp := &blogv1.Post{
+		Id:                 uint64(post.ID),
+		Title:              post.Title,
+		Content:            post.Content,
+		Author:             post.Author,
+		Slug:               post.Slug,
+		Description:        post.Description,
+		CoverImage:         post.CoverImage,
+		Draft:              post.Draft,
+		Metadata:           metadata,
+		WordCount:          int32(post.WordCount),
+		CharCount:          int32(post.CharCount),
+		ReadingTimeMinutes: int32(post.ReadingTimeMinutes),
+		Excerpt:            post.Excerpt,
+		CreatedAt:          timestamppb.New(post.CreatedAt),
+		UpdatedAt:          timestamppb.New(post.UpdatedAt),
+	}
+	for _, tag := range post.Tags {
+		resp.Tags = append(resp.Tags, tag.Name)
+	}
+
+	message := resp.ProtoReflect()
+	for i := 0; i < postDescriptor.Len(); i++ {
+		field := postDescriptor.Get(i)
+		if name := string(field.Name()); name != "id" && !slices.Contains(fields, name) {
+			message.Clear(field)
+		}
+	}
+	return resp, nil
+}
+
+// toStruct はメタデータを Struct に変換する。フロントマターの日時などは JSON と同じ値にする
+func toStruct(metadata models.PostMetadata) (*structpb.Struct, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(values)
+}
+
+// fromProto はリクエストの Post を投稿に変換する。出力のみのフィールドは使わない
+func fromProto(post *blogv1.Post) models.Post {
+	result := models.Post{
+		Title:       post.GetTitle(),
+		Content:     post.GetContent(),
+		Author:      post.GetAuthor(),
+		Slug:        post.GetSlug(),
+		Description: post.GetDescription(),
+		CoverImage:  post.GetCoverImage(),
+		Draft:       post.GetDraft(),
+	}
+	if post.GetMetadata() != nil {
+		result.Metadata = post.GetMetadata().AsMap()
+	}
+	for _, name := range post.GetTags() {
+		result.Tags = append(result.Tags, models.Tag{Name: name})
+	}
+	return result
+}
+
+// serviceError はサービス層のエラーを gRPC のステータスに変換する
+func serviceError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrNotFound):
+		return status.Error(codes.NotFound, "post not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "request timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	}
+	logging.FromContext(ctx).ErrorContext(ctx, "gRPC request failed", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
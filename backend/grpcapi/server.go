@@ -0,0 +1,69 @@
+// Package grpcapi は他の内部サービス向けの gRPC API (proto/blog/v1) を提供する。
+// 実装は REST API と同じ services.PostService を使い、google.api.http のマッピングも REST API のパスと揃える。
+// サーバーは REST API とは別のポートで動き、リフレクションとヘルスチェック (grpc.health.v1) に対応する。
+package grpcapi
+
+import (
+	blogv1 "blog/proto/blog/v1"
+	"blog/services"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server は gRPC のサーバー
+type Server struct {
+	addr   string
+	server *grpc.Server
+	health *health.Server
+}
+
+// NewServer は addr (例: ":9090") で待ち受けるサーバーを生成する。
+// ヘルスチェック以外の RPC は Bearer トークンが token と一致する場合だけ受け付ける
+func NewServer(addr, token string, posts services.PostService) (*Server, error) {
+	if token == "" {
+		return nil, fmt.Errorf("gRPC API requires a token")
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryAuth(token)),
+		grpc.ChainStreamInterceptor(streamAuth(token)),
+	)
+	blogv1.RegisterPostServiceServer(server, NewPostServer(posts))
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	return &Server{addr: addr, server: server, health: healthServer}, nil
+}
+
+// Run は ctx がキャンセルされるまでリクエストを受け付ける。キャンセル後は処理中の RPC を待ってから停止する
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve は listener でリクエストを受け付ける。テストで任意の listener を使うために分けている
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	slog.InfoContext(ctx, "starting gRPC server", "addr", listener.Addr().String())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	s.health.Shutdown()
+	s.server.GracefulStop()
+	return <-errCh
+}
@@ -0,0 +1,79 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StrongETag はレスポンスボディのバイト列から強い ETag を生成する
+func StrongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// WeakETag はリソースのバージョンを表す値 (ID や更新日時) から弱い ETag を生成する。
+// JSON のシリアライズ結果に依存しないため、意味的に同じ内容であれば一致する。
+func WeakETag(parts ...any) string {
+	h := sha256.New()
+	for _, p := range parts {
+		if t, ok := p.(time.Time); ok {
+			p = t.UTC().Format(time.RFC3339Nano)
+		}
+		fmt.Fprintf(h, "%v\x00", p)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// NotModified は ETag と Last-Modified ヘッダを設定し、条件付きリクエストを評価する。
+// クライアントのキャッシュが有効な場合は 304 を返して true を返す。
+// If-None-Match がある場合は If-Modified-Since より優先する (RFC 9110 13.2.2)。
+func NotModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if etag == "" || !matchETag(inm, etag) {
+			return false
+		}
+		c.AbortWithStatus(http.StatusNotModified)
+		return true
+	}
+
+	if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil || lastModified.Truncate(time.Second).After(t) {
+			return false
+		}
+		c.AbortWithStatus(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// matchETag は If-None-Match の値と ETag を弱い比較で照合する
+func matchETag(header, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
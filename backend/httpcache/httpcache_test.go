@@ -0,0 +1,70 @@
+package httpcache_test
+
+import (
+	"blog/httpcache"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContext(method string, headers map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(method, "/api/posts/1", nil)
+	for k, v := range headers {
+		ctx.Request.Header.Set(k, v)
+	}
+	return ctx, recorder
+}
+
+func TestETags(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, httpcache.WeakETag(1, updatedAt), httpcache.WeakETag(1, updatedAt.In(time.FixedZone("JST", 9*60*60))))
+	assert.NotEqual(t, httpcache.WeakETag(1, updatedAt), httpcache.WeakETag(1, updatedAt.Add(time.Nanosecond)))
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, httpcache.WeakETag(1, updatedAt))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, httpcache.StrongETag([]byte("<h1>Hello</h1>")))
+}
+
+func TestNotModified_IfNoneMatch(t *testing.T) {
+	etag := httpcache.StrongETag([]byte("body"))
+
+	ctx, recorder := newContext(http.MethodGet, map[string]string{"If-None-Match": `"other", W/` + etag})
+	assert.True(t, httpcache.NotModified(ctx, etag, time.Time{}))
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Equal(t, etag, recorder.Header().Get("ETag"))
+
+	ctx, _ = newContext(http.MethodGet, map[string]string{"If-None-Match": `"other"`})
+	assert.False(t, httpcache.NotModified(ctx, etag, time.Time{}))
+}
+
+func TestNotModified_IfModifiedSince(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 500, time.UTC)
+
+	ctx, recorder := newContext(http.MethodGet, map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)})
+	assert.True(t, httpcache.NotModified(ctx, "", lastModified))
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	ctx, _ = newContext(http.MethodGet, map[string]string{"If-Modified-Since": lastModified.Add(-time.Hour).Format(http.TimeFormat)})
+	assert.False(t, httpcache.NotModified(ctx, "", lastModified))
+}
+
+func TestNotModified_IfNoneMatchTakesPrecedence(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx, _ := newContext(http.MethodGet, map[string]string{
+		"If-None-Match":     `"stale"`,
+		"If-Modified-Since": lastModified.Format(http.TimeFormat),
+	})
+	assert.False(t, httpcache.NotModified(ctx, `"fresh"`, lastModified))
+}
+
+func TestNotModified_IgnoresUnsafeMethods(t *testing.T) {
+	etag := `"abc"`
+	ctx, _ := newContext(http.MethodPut, map[string]string{"If-None-Match": etag})
+	assert.False(t, httpcache.NotModified(ctx, etag, time.Time{}))
+}
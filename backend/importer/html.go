@@ -0,0 +1,325 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToMarkdown は WordPress の本文の HTML を Markdown に変換する。
+// クラシックエディタの本文は <p> を持たず空行で段落を区切るため、テキスト中の空行も段落の区切りとして扱う。
+// 変換できない要素は中のテキストだけを残す。
+func HTMLToMarkdown(src string) string {
+	src = shortcodePattern.ReplaceAllString(src, "")
+	nodes, err := html.ParseFragment(strings.NewReader(src), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		return strings.TrimSpace(src)
+	}
+	w := &markdownWriter{}
+	for _, n := range nodes {
+		w.node(n)
+	}
+	return w.String()
+}
+
+// shortcodePattern は中身を残して取り除く WordPress のショートコード
+var shortcodePattern = regexp.MustCompile(`\[/?(caption|embed)(\s[^\]]*)?\]`)
+
+var (
+	// markdownEscaper は Markdown として解釈される文字をエスケープする
+	markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+	// paragraphBreak はテキスト中の段落の区切り (空行)
+	paragraphBreak = regexp.MustCompile(`\n[ \t]*\n`)
+)
+
+// markdownWriter はブロックの区切りと行頭のプレフィックス (引用やリスト) を管理しながら Markdown を書き出す
+type markdownWriter struct {
+	out strings.Builder
+	// prefix は行頭に付ける文字列 (引用の "> " やリストのインデント)
+	prefix string
+	// pendingBreak は次に書くテキストの前に入れる改行の数
+	pendingBreak int
+	// atLineStart は行頭にいるかどうか
+	atLineStart bool
+	// lists は入れ子のリストの番号。箇条書きは -1
+	lists []int
+}
+
+func (w *markdownWriter) String() string {
+	return strings.TrimSpace(w.out.String())
+}
+
+// block はブロック要素の区切りとして空行を予約する
+func (w *markdownWriter) block() {
+	if w.out.Len() > 0 {
+		w.pendingBreak = 2
+	}
+}
+
+// lineBreak は改行を予約する
+func (w *markdownWriter) lineBreak() {
+	if w.out.Len() > 0 && w.pendingBreak < 1 {
+		w.pendingBreak = 1
+	}
+}
+
+// write は予約した改行とプレフィックスを出力してから s を書く
+func (w *markdownWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.pendingBreak > 0 {
+		for i := 0; i < w.pendingBreak; i++ {
+			if i > 0 {
+				// 空行にも引用のプレフィックスを付ける
+				w.out.WriteString(strings.TrimRight(w.prefix, " "))
+			}
+			w.out.WriteByte('\n')
+		}
+		w.pendingBreak = 0
+		w.atLineStart = true
+	}
+	if w.atLineStart || w.out.Len() == 0 {
+		w.out.WriteString(w.prefix)
+		w.atLineStart = false
+	}
+	w.out.WriteString(s)
+}
+
+// text はテキストノードの空白をまとめて書く
+func (w *markdownWriter) text(s string) {
+	for i, paragraph := range paragraphBreak.Split(s, -1) {
+		if i > 0 {
+			w.block()
+		}
+		collapsed := strings.Join(strings.Fields(paragraph), " ")
+		if collapsed == "" {
+			continue
+		}
+		// 単語の間の空白を保つ。ブロックの先頭の空白は捨てる
+		if startsWithSpace(paragraph) && !w.atBlockStart() && !strings.HasSuffix(w.out.String(), " ") {
+			collapsed = " " + collapsed
+		}
+		if endsWithSpace(paragraph) {
+			collapsed += " "
+		}
+		w.write(markdownEscaper.Replace(collapsed))
+	}
+}
+
+func (w *markdownWriter) atBlockStart() bool {
+	return w.out.Len() == 0 || w.pendingBreak > 0 || w.atLineStart
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s, " \t\r\n") != s
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s, " \t\r\n") != s
+}
+
+func (w *markdownWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *markdownWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		// コメント (ブロックエディタの <!-- wp:... -->) などは捨てる
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style:
+	case atom.P, atom.Div, atom.Figure, atom.Section, atom.Article, atom.Table:
+		w.block()
+		w.children(n)
+		w.block()
+	case atom.Figcaption, atom.Tr:
+		w.lineBreak()
+		w.children(n)
+		w.lineBreak()
+	case atom.Td, atom.Th:
+		w.children(n)
+		w.write(" ")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.block()
+		level := int(n.Data[1] - '0')
+		w.write(strings.Repeat("#", level) + " ")
+		w.children(n)
+		w.block()
+	case atom.Br:
+		w.write("  ")
+		w.lineBreak()
+	case atom.Hr:
+		w.block()
+		w.write("---")
+		w.block()
+	case atom.Strong, atom.B:
+		w.wrap(n, "**")
+	case atom.Em, atom.I:
+		w.wrap(n, "*")
+	case atom.Del, atom.S:
+		w.wrap(n, "~~")
+	case atom.Code:
+		w.write(inlineCode(textContent(n)))
+	case atom.Pre:
+		w.block()
+		code := strings.Trim(textContent(n), "\n")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		// コード中の空行を残すため、改行の予約を使わずにまとめて書く
+		newline := "\n" + w.prefix
+		w.write(fence + codeLanguage(n) + newline + strings.ReplaceAll(code, "\n", newline) + newline + fence)
+		w.block()
+	case atom.A:
+		href := attr(n, "href")
+		if href == "" {
+			w.children(n)
+			return
+		}
+		w.write("[")
+		w.children(n)
+		w.write("](" + href + ")")
+	case atom.Img:
+		if src := attr(n, "src"); src != "" {
+			w.write("![" + markdownEscaper.Replace(attr(n, "alt")) + "](" + src + ")")
+		}
+	case atom.Blockquote:
+		w.block()
+		prefix := w.prefix
+		w.prefix += "> "
+		w.children(n)
+		w.prefix = prefix
+		w.block()
+	case atom.Ul, atom.Ol:
+		if len(w.lists) == 0 {
+			w.block()
+		} else {
+			w.lineBreak()
+		}
+		start := -1
+		if n.DataAtom == atom.Ol {
+			start = 1
+			if s, err := strconv.Atoi(attr(n, "start")); err == nil {
+				start = s
+			}
+		}
+		w.lists = append(w.lists, start)
+		w.children(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		if len(w.lists) == 0 {
+			w.block()
+		}
+	case atom.Li:
+		w.listItem(n)
+	default:
+		w.children(n)
+	}
+}
+
+// listItem はリストの項目を書く。項目内の行は記号の幅だけインデントする
+func (w *markdownWriter) listItem(n *html.Node) {
+	marker := "- "
+	if depth := len(w.lists); depth > 0 && w.lists[depth-1] >= 0 {
+		marker = strconv.Itoa(w.lists[depth-1]) + ". "
+		w.lists[depth-1]++
+	}
+	w.lineBreak()
+	w.write(marker)
+	prefix := w.prefix
+	w.prefix += strings.Repeat(" ", len(marker))
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.P {
+			// 項目内の段落は空行で区切らない
+			w.children(c)
+			continue
+		}
+		w.node(c)
+	}
+	w.prefix = prefix
+	w.lineBreak()
+}
+
+// wrap は子要素を強調などの記号で囲む。前後の空白は記号の外に出す
+func (w *markdownWriter) wrap(n *html.Node, mark string) {
+	inner := &markdownWriter{}
+	inner.children(n)
+	text := inner.String()
+	if text == "" {
+		return
+	}
+	content := textContent(n)
+	if startsWithSpace(content) && !w.atBlockStart() && !strings.HasSuffix(w.out.String(), " ") {
+		w.write(" ")
+	}
+	w.write(mark + text + mark)
+	if endsWithSpace(content) {
+		w.write(" ")
+	}
+}
+
+// inlineCode はバッククォートを含むコードも囲めるように区切りを伸ばす
+func inlineCode(code string) string {
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// codeLanguage は <pre> または中の <code> の class (language-go, lang:go, brush: go) から言語名を取り出す
+func codeLanguage(n *html.Node) string {
+	classes := attr(n, "class")
+	if c := n.FirstChild; c != nil && c.Type == html.ElementNode && c.DataAtom == atom.Code {
+		classes += " " + attr(c, "class")
+	}
+	if m := codeLanguagePattern.FindStringSubmatch(classes); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+var codeLanguagePattern = regexp.MustCompile(`(?:language-|lang:|brush:\s*)([\w+#-]+)`)
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			b.WriteByte('\n')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
@@ -0,0 +1,180 @@
+// Package importer は他のブログのエクスポート (WordPress の WXR、Hugo / Jekyll / Zenn / Qiita の Markdown、
+// Qiita API の JSON) を読み込み、取り込む投稿の一覧に変換する。データベースへの保存は services.ImportService が行う。
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Format はエクスポートの形式
+type Format string
+
+const (
+	FormatWordPress Format = "wordpress"
+	FormatHugo      Format = "hugo"
+	FormatJekyll    Format = "jekyll"
+	FormatQiita     Format = "qiita"
+	FormatZenn      Format = "zenn"
+)
+
+// Formats は対応している形式
+var Formats = []Format{FormatWordPress, FormatHugo, FormatJekyll, FormatQiita, FormatZenn}
+
+// ErrUnsupportedFormat は対応していない形式を指定したことを表す
+var ErrUnsupportedFormat = errors.New("unsupported import format")
+
+// ParseFormat は形式の名前を Format に変換する
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, name)
+	}
+	return format, nil
+}
+
+// Entry は取り込む 1 件の投稿
+type Entry struct {
+	// SourceID は取り込み元での ID。同じ形式で同じ SourceID の投稿は再実行しても重複しない
+	SourceID string
+	Title    string
+	// Content は Markdown の本文
+	Content   string
+	Author    string
+	Tags      []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Skip は取り込まない理由 (下書きなど)。空の場合は取り込む
+	Skip string
+}
+
+// ErrTooLarge は読み込むファイルの数か展開後の合計サイズが Limits を超えたことを表す
+var ErrTooLarge = errors.New("import is too large")
+
+// Limits は 1 回の取り込みで読み込む量の上限。zip の展開でメモリを使い切らないようにする
+type Limits struct {
+	// MaxFiles は読み込むファイルとディレクトリの最大数
+	MaxFiles int
+	// MaxTotalSize はファイルの展開後の合計の最大サイズ (バイト)
+	MaxTotalSize int64
+}
+
+// DefaultLimits は ReadFile と ReadFS の上限
+var DefaultLimits = Limits{MaxFiles: 10000, MaxTotalSize: 256 << 20}
+
+// ReadFile は DefaultLimits の上限で 1 つのファイルを読み込む
+func ReadFile(format Format, name string, data []byte) ([]Entry, error) {
+	return DefaultLimits.ReadFile(format, name, data)
+}
+
+// ReadFS は DefaultLimits の上限で fsys 以下の全てのファイルを読み込む
+func ReadFS(format Format, fsys fs.FS) ([]Entry, error) {
+	return DefaultLimits.ReadFS(format, fsys)
+}
+
+// ReadFile は 1 つのファイルを読み込む。name の拡張子で中身を判断し、zip の場合は中の全てのファイルを読み込む
+func (l Limits) ReadFile(format Format, name string, data []byte) ([]Entry, error) {
+	if strings.EqualFold(path.Ext(name), ".zip") {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		if len(archive.File) > l.MaxFiles {
+			return nil, fmt.Errorf("%w: %s has more than %d files", ErrTooLarge, name, l.MaxFiles)
+		}
+		return l.ReadFS(format, archive)
+	}
+	return readFile(format, path.Base(name), data)
+}
+
+// ReadFS は fsys 以下の全てのファイルを読み込む。隠しファイルと形式に関係のないファイルは無視する。
+// ファイルの数か展開後の合計サイズが上限を超えた場合は ErrTooLarge を返す
+func (l Limits) ReadFS(format Format, fsys fs.FS) ([]Entry, error) {
+	var (
+		entries []Entry
+		files   int
+		// remaining は読み込める残りのバイト数。zip のヘッダーのサイズは信用せず、実際に読んだ量で数える
+		remaining = l.MaxTotalSize
+	)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if files++; files > l.MaxFiles {
+			return fmt.Errorf("%w: more than %d files", ErrTooLarge, l.MaxFiles)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := readLimited(fsys, name, remaining)
+		if err != nil {
+			return err
+		}
+		remaining -= int64(len(data))
+		read, err := readFile(format, name, data)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, read...)
+		return nil
+	})
+	return entries, err
+}
+
+// readLimited は fsys のファイルを最大 limit バイトまで読み込む。超える場合は ErrTooLarge を返す
+func readLimited(fsys fs.FS, name string, limit int64) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: files are larger than %d bytes in total", ErrTooLarge, limit)
+	}
+	return data, nil
+}
+
+// readFile は形式と拡張子に応じてファイルを解析する。name は fs.FS のパス
+func readFile(format Format, name string, data []byte) ([]Entry, error) {
+	ext := strings.ToLower(path.Ext(name))
+	var (
+		entries []Entry
+		err     error
+	)
+	switch {
+	case format == FormatWordPress && ext == ".xml":
+		entries, err = parseWXR(bytes.NewReader(data))
+	case format == FormatQiita && ext == ".json":
+		entries, err = parseQiitaJSON(data)
+	case format != FormatWordPress && (ext == ".md" || ext == ".markdown"):
+		var entry *Entry
+		entry, err = parseMarkdown(format, name, string(data))
+		if entry != nil {
+			entries = []Entry{*entry}
+		}
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return entries, nil
+}
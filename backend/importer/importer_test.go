@@ -0,0 +1,246 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"blog/importer"
+	"bytes"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "blocks",
+			html: `<!-- wp:heading --><h2>Intro</h2><!-- /wp:heading --><p>Some <strong>bold</strong> and <em>italic</em> with <a href="https://example.com">a link</a>.</p>`,
+			want: "## Intro\n\nSome **bold** and *italic* with [a link](https://example.com).",
+		},
+		{
+			name: "classic editor paragraphs",
+			html: "First line\nstill first.\n\nSecond *paragraph*.",
+			want: "First line still first.\n\nSecond \\*paragraph\\*.",
+		},
+		{
+			name: "lists",
+			html: "<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>",
+			want: "- one\n- two\n  1. nested",
+		},
+		{
+			name: "code",
+			html: "<p>Use <code>go test</code>:</p><pre class=\"wp-block-code\"><code class=\"language-go\">func main() {\n\n}</code></pre>",
+			want: "Use `go test`:\n\n```go\nfunc main() {\n\n}\n```",
+		},
+		{
+			name: "quote and image",
+			html: `<blockquote><p>Quoted</p><p>Twice</p></blockquote>[caption id="1"]<img src="/a.png" alt="An image"> Caption[/caption]`,
+			want: "> Quoted\n>\n> Twice\n\n![An image](/a.png) Caption",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, importer.HTMLToMarkdown(tt.html))
+		})
+	}
+}
+
+const wxrSample = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:author><wp:author_login><![CDATA[taro]]></wp:author_login><wp:author_display_name><![CDATA[Taro Yamada]]></wp:author_display_name></wp:author>
+	<item>
+		<title>Hello World</title>
+		<dc:creator><![CDATA[taro]]></dc:creator>
+		<content:encoded><![CDATA[<p>Welcome to <b>WordPress</b>.</p>]]></content:encoded>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_date_gmt>2020-01-02 03:04:05</wp:post_date_gmt>
+		<wp:post_modified_gmt>2020-02-01 00:00:00</wp:post_modified_gmt>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="uncategorized"><![CDATA[Uncategorized]]></category>
+		<category domain="category" nicename="go"><![CDATA[Go]]></category>
+		<category domain="post_tag" nicename="web"><![CDATA[Web]]></category>
+	</item>
+	<item>
+		<title>Work in progress</title>
+		<wp:post_id>13</wp:post_id>
+		<wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>logo.png</title>
+		<wp:post_id>14</wp:post_id>
+		<wp:post_type>attachment</wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestReadFile_WordPress(t *testing.T) {
+	entries, err := importer.ReadFile(importer.FormatWordPress, "export.xml", []byte(wxrSample))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, importer.Entry{
+		SourceID:  "12",
+		Title:     "Hello World",
+		Content:   "Welcome to **WordPress**.",
+		Author:    "Taro Yamada",
+		Tags:      []string{"Go", "Web"},
+		CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		UpdatedAt: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+	}, entries[0])
+	assert.Equal(t, "13", entries[1].SourceID)
+	assert.NotEmpty(t, entries[1].Skip)
+	assert.True(t, entries[1].CreatedAt.IsZero())
+
+	_, err = importer.ReadFile(importer.FormatWordPress, "broken.xml", []byte("<rss><channel>"))
+	assert.Error(t, err)
+}
+
+func TestReadFS_Hugo(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/first.md":         {Data: []byte("---\ntitle: First\ndate: 2021-03-04T05:06:07Z\ntags: [go]\ncategories: [dev]\nauthor: Hanako\n---\n\nBody one\n")},
+		"posts/bundle/index.md":  {Data: []byte("+++\ntitle = \"Bundle\"\ndate = 2021-05-06\nlastmod = 2021-06-07\ndraft = true\n+++\nBody two")},
+		"posts/_index.md":        {Data: []byte("---\ntitle: Posts\n---\n")},
+		"README.md":              {Data: []byte("# Not a post\n")},
+		".git/HEAD.md":           {Data: []byte("---\ntitle: hidden\n---\n")},
+		"posts/bundle/image.png": {Data: []byte{0x89}},
+	}
+	entries, err := importer.ReadFS(importer.FormatHugo, fsys)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	bundle, first := entries[0], entries[1]
+	assert.Equal(t, "posts/bundle", bundle.SourceID)
+	assert.Equal(t, "Body two", bundle.Content)
+	assert.Equal(t, "draft", bundle.Skip)
+	assert.Equal(t, time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC), bundle.UpdatedAt)
+
+	assert.Equal(t, "posts/first", first.SourceID)
+	assert.Equal(t, "First", first.Title)
+	assert.Equal(t, "Body one\n", first.Content)
+	assert.Equal(t, "Hanako", first.Author)
+	assert.Equal(t, []string{"go", "dev"}, first.Tags)
+	assert.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), first.CreatedAt)
+	assert.Empty(t, first.Skip)
+}
+
+func TestReadFS_Jekyll(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_posts/2019-08-07-hello.markdown": {Data: []byte("---\nlayout: post\ntitle: Hello\ntags: ruby jekyll\n---\nHi")},
+		"_posts/2019-08-08-hidden.md":      {Data: []byte("---\ntitle: Hidden\npublished: false\n---\nHi")},
+		"about.md":                         {Data: []byte("---\ntitle: About\n---\nPage")},
+	}
+	entries, err := importer.ReadFS(importer.FormatJekyll, fsys)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "_posts/2019-08-07-hello", entries[0].SourceID)
+	assert.Equal(t, []string{"ruby", "jekyll"}, entries[0].Tags)
+	assert.Equal(t, time.Date(2019, 8, 7, 0, 0, 0, 0, time.UTC), entries[0].CreatedAt)
+	assert.NotEmpty(t, entries[1].Skip)
+}
+
+func TestReadFS_Zenn(t *testing.T) {
+	fsys := fstest.MapFS{
+		"articles/go-generics.md": {Data: []byte("---\ntitle: \"Go のジェネリクス\"\nemoji: \"🐹\"\ntype: \"tech\"\ntopics: [\"go\", \"generics\"]\npublished: true\npublished_at: 2023-01-02 09:00\n---\n本文")},
+		"articles/draft.md":       {Data: []byte("---\ntitle: \"下書き\"\npublished: false\n---\n")},
+		"books/my-book/config.md": {Data: []byte("---\ntitle: \"本\"\n---\n")},
+	}
+	entries, err := importer.ReadFS(importer.FormatZenn, fsys)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "draft", entries[0].SourceID)
+	assert.NotEmpty(t, entries[0].Skip)
+	assert.Equal(t, "go-generics", entries[1].SourceID)
+	assert.Equal(t, []string{"go", "generics"}, entries[1].Tags)
+	assert.Equal(t, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), entries[1].CreatedAt.UTC())
+}
+
+func TestReadFile_Qiita(t *testing.T) {
+	data := []byte(`[
+		{"id": "abc123", "title": "Qiita post", "body": "# Hello", "private": false,
+		 "created_at": "2022-01-01T09:00:00+09:00", "updated_at": "2022-01-02T09:00:00+09:00",
+		 "tags": [{"name": "Go", "versions": []}], "user": {"id": "taro", "name": ""}},
+		{"id": "def456", "title": "Limited", "body": "secret", "private": true, "tags": [], "user": {"id": "taro"}}
+	]`)
+	entries, err := importer.ReadFile(importer.FormatQiita, "items.json", data)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "abc123", entries[0].SourceID)
+	assert.Equal(t, "taro", entries[0].Author)
+	assert.Equal(t, []string{"Go"}, entries[0].Tags)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), entries[0].CreatedAt.UTC())
+	assert.Equal(t, "private", entries[1].Skip)
+
+	// Qiita CLI の Markdown
+	entries, err = importer.ReadFile(importer.FormatQiita, "public/new.md",
+		[]byte("---\ntitle: New\ntags:\n  - Go\nprivate: false\nupdated_at: ''\nid: null\nignorePublish: false\n---\nBody"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "new", entries[0].SourceID)
+	assert.Empty(t, entries[0].Skip)
+}
+
+func TestReadFile_Zip(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.Create("content/posts/zipped.md")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("---\ntitle: Zipped\n---\nBody"))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	entries, err := importer.ReadFile(importer.FormatHugo, "site.zip", buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "content/posts/zipped", entries[0].SourceID)
+}
+
+func TestReadFile_ZipLimits(t *testing.T) {
+	zipped := func(files map[string][]byte) []byte {
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		for name, data := range files {
+			w, err := archive.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			require.NoError(t, err)
+		}
+		require.NoError(t, archive.Close())
+		return buf.Bytes()
+	}
+
+	// 圧縮すると小さくなる大きなファイル (zip bomb) は展開後のサイズで制限する
+	bomb := zipped(map[string][]byte{"bomb.md": bytes.Repeat([]byte{'a'}, 1<<20)})
+	limits := importer.Limits{MaxFiles: 10, MaxTotalSize: 1 << 10}
+	_, err := limits.ReadFile(importer.FormatHugo, "bomb.zip", bomb)
+	assert.ErrorIs(t, err, importer.ErrTooLarge)
+
+	post := []byte("---\ntitle: Post\n---\nBody")
+	many := zipped(map[string][]byte{"a.md": post, "b.md": post, "c.md": post})
+	limits = importer.Limits{MaxFiles: 2, MaxTotalSize: 1 << 10}
+	_, err = limits.ReadFile(importer.FormatHugo, "many.zip", many)
+	assert.ErrorIs(t, err, importer.ErrTooLarge)
+
+	limits = importer.Limits{MaxFiles: 10, MaxTotalSize: 1 << 10}
+	entries, err := limits.ReadFile(importer.FormatHugo, "many.zip", many)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := importer.ParseFormat(" WordPress ")
+	require.NoError(t, err)
+	assert.Equal(t, importer.FormatWordPress, format)
+
+	_, err = importer.ParseFormat("blogger")
+	assert.ErrorIs(t, err, importer.ErrUnsupportedFormat)
+}
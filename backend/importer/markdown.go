@@ -0,0 +1,149 @@
+package importer
+
+import (
+	"blog/frontmatter"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jekyllPostName は Jekyll の投稿のファイル名 (YYYY-MM-DD-slug.md)
+var jekyllPostName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-.+\.(md|markdown)$`)
+
+// zennTimezone は Zenn の published_at のタイムゾーン (日本時間)
+var zennTimezone = time.FixedZone("JST", 9*60*60)
+
+// parseMarkdown はフロントマター付きの Markdown を 1 件の投稿として読み込む。
+// 投稿ではないファイル (フロントマターのない README や Hugo の _index.md など) の場合は nil を返す。
+func parseMarkdown(format Format, name, src string) (*Entry, error) {
+	base := path.Base(name)
+	slug := strings.TrimSuffix(name, path.Ext(name))
+	switch format {
+	case FormatHugo:
+		if strings.HasPrefix(base, "_index.") {
+			return nil, nil
+		}
+		// ページバンドル (posts/slug/index.md) はディレクトリ名で識別する
+		if path.Base(slug) == "index" && path.Dir(slug) != "." {
+			slug = path.Dir(slug)
+		}
+	case FormatJekyll:
+		if !jekyllPostName.MatchString(base) {
+			return nil, nil
+		}
+	case FormatZenn:
+		// 本 (books/) は投稿として取り込まない
+		if slices.Contains(strings.Split(name, "/"), "books") {
+			return nil, nil
+		}
+		slug = strings.TrimSuffix(base, path.Ext(base))
+	}
+
+	matter, body, err := frontmatter.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if matter == nil {
+		return nil, nil
+	}
+
+	entry := &Entry{
+		SourceID: slug,
+		Title:    matter.String("title"),
+		Content:  body,
+		Author:   matter.String("author"),
+	}
+	if entry.Author == "" {
+		if authors := matter.Strings("authors"); len(authors) > 0 {
+			entry.Author = authors[0]
+		}
+	}
+
+	switch format {
+	case FormatHugo, FormatJekyll:
+		entry.Tags = append(matter.Strings("tags"), matter.Strings("categories")...)
+		entry.CreatedAt, _ = matter.Time("date", "publishdate")
+		entry.UpdatedAt, _ = matter.Time("lastmod", "last_modified_at")
+		if format == FormatJekyll && entry.CreatedAt.IsZero() {
+			entry.CreatedAt, _ = time.Parse("2006-01-02", jekyllPostName.FindStringSubmatch(base)[1])
+		}
+		if draft, _ := matter.Bool("draft"); draft {
+			entry.Skip = "draft"
+		}
+		if published, ok := matter.Bool("published"); ok && !published {
+			entry.Skip = "not published"
+		}
+	case FormatZenn:
+		entry.Tags = matter.Strings("topics")
+		entry.CreatedAt, _ = matter.TimeIn(zennTimezone, "published_at")
+		if published, ok := matter.Bool("published"); ok && !published {
+			entry.Skip = "not published"
+		}
+	case FormatQiita:
+		// Qiita CLI の Markdown。未投稿の記事は id が null なのでファイル名で識別する
+		if id := matter.String("id"); id != "" {
+			entry.SourceID = id
+		}
+		entry.Tags = matter.Strings("tags")
+		// 作成日時は持たないため更新日時で代用する
+		entry.UpdatedAt, _ = matter.Time("updated_at")
+		entry.CreatedAt = entry.UpdatedAt
+		if private, _ := matter.Bool("private"); private {
+			entry.Skip = "private"
+		}
+		if ignore, _ := matter.Bool("ignorepublish"); ignore {
+			entry.Skip = "ignorePublish is set"
+		}
+	}
+	return entry, nil
+}
+
+// qiitaItem は Qiita API (GET /api/v2/authenticated_user/items) の記事
+type qiitaItem struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Private   bool      `json:"private"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Tags      []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	User struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+// parseQiitaJSON は Qiita API の記事の配列を読み込む
+func parseQiitaJSON(data []byte) ([]Entry, error) {
+	var items []qiitaItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid Qiita export: %w", err)
+	}
+	entries := make([]Entry, len(items))
+	for i, item := range items {
+		entries[i] = Entry{
+			SourceID:  item.ID,
+			Title:     item.Title,
+			Content:   item.Body,
+			Author:    item.User.Name,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+		}
+		if entries[i].Author == "" {
+			entries[i].Author = item.User.ID
+		}
+		for _, tag := range item.Tags {
+			entries[i].Tags = append(entries[i].Tags, tag.Name)
+		}
+		if item.Private {
+			entries[i].Skip = "private"
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// wxr は WordPress のエクスポート (WXR) のうち取り込みに使う部分。
+// wp: の名前空間 URI は WXR のバージョンで変わるため、ローカル名だけで照合する。
+type wxr struct {
+	Channel struct {
+		Authors []struct {
+			Login       string `xml:"author_login"`
+			DisplayName string `xml:"author_display_name"`
+		} `xml:"author"`
+		Items []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title   string `xml:"title"`
+	PubDate string `xml:"pubDate"`
+	Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostID  string `xml:"post_id"`
+	// PostDateGMT と PostModifiedGMT は "2006-01-02 15:04:05" 形式。未公開の投稿は "0000-00-00 00:00:00"
+	PostDate        string `xml:"post_date"`
+	PostDateGMT     string `xml:"post_date_gmt"`
+	PostModifiedGMT string `xml:"post_modified_gmt"`
+	Status          string `xml:"status"`
+	PostType        string `xml:"post_type"`
+	Categories      []struct {
+		Domain   string `xml:"domain,attr"`
+		Nicename string `xml:"nicename,attr"`
+		Name     string `xml:",chardata"`
+	} `xml:"category"`
+}
+
+const wxrDateLayout = "2006-01-02 15:04:05"
+
+// parseWXR は WXR の投稿 (post_type が post のもの) を読み込む。固定ページや添付ファイルは無視する
+func parseWXR(r io.Reader) ([]Entry, error) {
+	var doc wxr
+	decoder := xml.NewDecoder(r)
+	// WXR は UTF-8 以外を宣言していても実際は UTF-8 で書き出される
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid WXR: %w", err)
+	}
+
+	authors := make(map[string]string, len(doc.Channel.Authors))
+	for _, a := range doc.Channel.Authors {
+		authors[a.Login] = a.DisplayName
+	}
+
+	var entries []Entry
+	for _, item := range doc.Channel.Items {
+		if item.PostType != "post" {
+			continue
+		}
+		entry := Entry{
+			SourceID: strings.TrimSpace(item.PostID),
+			Title:    strings.TrimSpace(item.Title),
+			Content:  HTMLToMarkdown(item.Content),
+			Author:   strings.TrimSpace(item.Creator),
+		}
+		if name := strings.TrimSpace(authors[entry.Author]); name != "" {
+			entry.Author = name
+		}
+		for _, c := range item.Categories {
+			// 既定のカテゴリ "未分類" はタグにしない
+			if (c.Domain == "post_tag" || c.Domain == "category") && c.Nicename != "uncategorized" {
+				entry.Tags = append(entry.Tags, strings.TrimSpace(c.Name))
+			}
+		}
+		entry.CreatedAt = wxrTime(item.PostDateGMT)
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = wxrTime(item.PostDate)
+		}
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt, _ = time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate))
+		}
+		entry.UpdatedAt = wxrTime(item.PostModifiedGMT)
+		if item.Status != "publish" {
+			entry.Skip = fmt.Sprintf("status is %q", item.Status)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// wxrTime は WXR の日時を解析する。空や "0000-00-00 00:00:00" の場合はゼロ値を返す
+func wxrTime(s string) time.Time {
+	t, err := time.Parse(wxrDateLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,288 @@
+// Package jobs はリクエストとは別に動くバックグラウンドジョブを提供する
+package jobs
+
+import (
+	"blog/logging"
+	"blog/models"
+	"blog/recommend"
+	"blog/repositories"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// RelatedPostsWorker は投稿の変更を受けて関連記事を再計算し、related_posts テーブルに保存する。
+//
+// 起動時に全投稿から索引を作って保存済みの関連記事との差分を書き込み、以降は MarkDirty された投稿と、
+// その投稿を関連記事に含む・含み得る投稿だけを再計算する。
+// IDF は投稿の追加や削除で少しずつ変わるが、影響を受けない投稿のスコアは次回の起動まで更新しない。
+type RelatedPostsWorker struct {
+	uow repositories.UnitOfWork
+	// limit は投稿ごとに保存する関連記事の数
+	limit int
+	// interval は変更を受けてから再計算するまでの待ち時間。この間の変更はまとめて処理する
+	interval time.Duration
+
+	mu     sync.Mutex
+	dirty  map[uint]struct{}
+	notify chan struct{}
+
+	// index と lists は Run のゴルーチンだけが触る
+	index *recommend.Index
+	lists map[uint][]recommend.Match
+}
+
+func NewRelatedPostsWorker(uow repositories.UnitOfWork, limit int, interval time.Duration) *RelatedPostsWorker {
+	return &RelatedPostsWorker{
+		uow:      uow,
+		limit:    limit,
+		interval: interval,
+		dirty:    make(map[uint]struct{}),
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// MarkDirty は投稿を再計算の対象にする。ブロックしない
+func (w *RelatedPostsWorker) MarkDirty(postID uint) {
+	w.mu.Lock()
+	w.dirty[postID] = struct{}{}
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run は ctx がキャンセルされるまで変更を待って関連記事を再計算する
+func (w *RelatedPostsWorker) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With("job", "related_posts")
+
+	for {
+		if w.index == nil {
+			if err := w.rebuild(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logger.ErrorContext(ctx, "failed to build related posts index", "error", err)
+			}
+		} else if ids := w.takeDirty(); len(ids) > 0 {
+			if err := w.process(ctx, ids); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logger.ErrorContext(ctx, "failed to update related posts", "posts", len(ids), "error", err)
+				// 途中まで反映した索引は信用できないため、次回は全体を作り直す
+				w.index = nil
+			}
+		}
+
+		if w.index == nil {
+			// 失敗した場合は少し待って再試行する
+			if !sleep(ctx, w.retryDelay()) {
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.notify:
+		}
+		if !sleep(ctx, w.interval) {
+			return nil
+		}
+	}
+}
+
+func (w *RelatedPostsWorker) retryDelay() time.Duration {
+	return max(w.interval, 5*time.Second)
+}
+
+func (w *RelatedPostsWorker) takeDirty() []uint {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ids := make([]uint, 0, len(w.dirty))
+	for id := range w.dirty {
+		ids = append(ids, id)
+	}
+	clear(w.dirty)
+	return ids
+}
+
+// rebuild は公開されている全投稿から索引を作り直し、保存済みの関連記事と異なる投稿だけを書き込む
+func (w *RelatedPostsWorker) rebuild(ctx context.Context) error {
+	// 作り直す間の変更は索引に反映されるため、ここまでの通知は不要になる
+	w.takeDirty()
+
+	repos := w.uow.Repositories()
+	// 下書きは関連記事の候補にも対象にもしない
+	posts, err := repos.Posts.List(ctx, repositories.PostQuery{Published: true})
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	tags, err := repos.Tags.FindByPostIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	stored, err := repos.Related.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	index := recommend.NewIndex()
+	for _, post := range posts {
+		post.Tags = tags[post.ID]
+		index.Upsert(document(post))
+	}
+
+	lists := make(map[uint][]recommend.Match, len(stored))
+	for postID, related := range stored {
+		if !index.Has(postID) {
+			if err := repos.Related.DeleteForPost(ctx, postID); err != nil {
+				return err
+			}
+			continue
+		}
+		matches := make([]recommend.Match, len(related))
+		for i, r := range related {
+			matches[i] = recommend.Match{ID: r.RelatedPostID, Score: r.Score}
+		}
+		lists[postID] = matches
+	}
+
+	w.index, w.lists = index, lists
+	if err := w.recompute(ctx, index.IDs()); err != nil {
+		w.index = nil
+		return err
+	}
+	return nil
+}
+
+// process は変更された投稿を索引に反映し、影響を受ける投稿の関連記事を再計算する
+func (w *RelatedPostsWorker) process(ctx context.Context, ids []uint) error {
+	repos := w.uow.Repositories()
+	affected := make(map[uint]struct{})
+
+	for _, id := range ids {
+		post, err := repos.Posts.FindByID(ctx, id)
+		switch {
+		// 下書きに戻された投稿は削除された投稿と同じく索引から外す
+		case errors.Is(err, repositories.ErrNotFound), err == nil && post.Draft:
+			w.index.Remove(id)
+			delete(w.lists, id)
+			if err := repos.Related.DeleteForPost(ctx, id); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			w.index.Upsert(document(*post))
+			affected[id] = struct{}{}
+		}
+
+		// 関連記事にこの投稿を含む投稿は、スコアが変わるか消えるため再計算する
+		for other, list := range w.lists {
+			if contains(list, id) {
+				affected[other] = struct{}{}
+			}
+		}
+	}
+
+	// 変更された投稿が新たに関連記事に入り得る投稿を再計算する
+	for _, other := range w.index.IDs() {
+		for _, id := range ids {
+			score := w.index.Similarity(other, id)
+			if score == 0 {
+				continue
+			}
+			list := w.lists[other]
+			if len(list) < w.limit || score > list[len(list)-1].Score {
+				affected[other] = struct{}{}
+				break
+			}
+		}
+	}
+
+	targets := make([]uint, 0, len(affected))
+	for id := range affected {
+		if w.index.Has(id) {
+			targets = append(targets, id)
+		}
+	}
+	return w.recompute(ctx, targets)
+}
+
+// recompute は投稿の関連記事を計算し、保存済みのものと異なる場合だけ書き込む
+func (w *RelatedPostsWorker) recompute(ctx context.Context, ids []uint) error {
+	now := time.Now()
+	for _, id := range ids {
+		matches := w.index.TopN(id, w.limit)
+		if equalMatches(matches, w.lists[id]) {
+			continue
+		}
+
+		related := make([]models.RelatedPost, len(matches))
+		for i, m := range matches {
+			related[i] = models.RelatedPost{PostID: id, RelatedPostID: m.ID, Score: m.Score, Rank: i + 1, ComputedAt: now}
+		}
+		err := w.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+			return repos.Related.ReplaceForPost(ctx, id, related)
+		})
+		if err != nil {
+			return err
+		}
+		w.lists[id] = matches
+	}
+	return nil
+}
+
+func document(post models.Post) recommend.Document {
+	tags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		tags[i] = tag.Name
+	}
+	return recommend.Document{ID: post.ID, Title: post.Title, Content: post.Content, Tags: tags}
+}
+
+func contains(matches []recommend.Match, id uint) bool {
+	for _, m := range matches {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// equalMatches は浮動小数点の誤差を無視して関連記事が同じかを比べる
+func equalMatches(a, b []recommend.Match) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || math.Abs(a[i].Score-b[i].Score) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// sleep は d だけ待つ。ctx がキャンセルされた場合は false を返す
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
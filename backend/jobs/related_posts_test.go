@@ -0,0 +1,125 @@
+package jobs_test
+
+import (
+	"blog/config"
+	"blog/database"
+	"blog/jobs"
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupSQLite(t *testing.T) *gorm.DB {
+	db, err := database.Open(config.DatabaseConfig{Driver: "sqlite", Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate SQLite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// startWorker はワーカーを起動し、テスト終了時に停止を待つ
+func startWorker(t *testing.T, worker *jobs.RelatedPostsWorker) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, worker.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func relatedIDs(t *testing.T, db *gorm.DB, postID uint) []uint {
+	var ids []uint
+	require.NoError(t, db.Model(&models.RelatedPost{}).Where("post_id = ?", postID).Order("rank").Pluck("related_post_id", &ids).Error)
+	return ids
+}
+
+func TestRelatedPostsWorker_RebuildsOnStart(t *testing.T) {
+	db := setupSQLite(t)
+	posts := []models.Post{
+		{Title: "Go の並行処理入門", Content: "goroutine と channel", Tags: []models.Tag{{Name: "go"}}},
+		{Title: "Go の並行処理パターン", Content: "channel のパターン"},
+		{Title: "Baking bread", Content: "flour and yeast"},
+		// 下書きは関連記事の候補にも対象にもしない
+		{Title: "Go の並行処理 (下書き)", Content: "goroutine と channel", Draft: true},
+	}
+	require.NoError(t, db.Create(&posts).Error)
+	// 削除済みの投稿の古い関連記事は起動時に消える
+	require.NoError(t, db.Create(&models.RelatedPost{PostID: 99, RelatedPostID: posts[0].ID, Score: 1, Rank: 1}).Error)
+
+	startWorker(t, jobs.NewRelatedPostsWorker(repositories.NewUnitOfWork(db), 5, 10*time.Millisecond))
+
+	require.Eventually(t, func() bool {
+		return len(relatedIDs(t, db, posts[0].ID)) == 1 && len(relatedIDs(t, db, 99)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []uint{posts[1].ID}, relatedIDs(t, db, posts[0].ID))
+	assert.Equal(t, []uint{posts[0].ID}, relatedIDs(t, db, posts[1].ID))
+	assert.Empty(t, relatedIDs(t, db, posts[2].ID))
+	assert.Empty(t, relatedIDs(t, db, posts[3].ID))
+
+	// 保存後に下書きに戻された投稿は、再計算を待たずに読み取りから外れる
+	require.NoError(t, db.Model(&posts[1]).Update("draft", true).Error)
+	related, err := repositories.NewRelatedPostRepository(db).FindByPostID(context.Background(), posts[0].ID, 5)
+	require.NoError(t, err)
+	assert.Empty(t, related)
+}
+
+func TestRelatedPostsWorker_MarkDirty(t *testing.T) {
+	db := setupSQLite(t)
+	posts := []models.Post{
+		{Title: "Docker 入門", Content: "コンテナの基本"},
+		{Title: "Docker Compose 入門", Content: "複数のコンテナ"},
+	}
+	require.NoError(t, db.Create(&posts).Error)
+
+	worker := jobs.NewRelatedPostsWorker(repositories.NewUnitOfWork(db), 5, 10*time.Millisecond)
+	startWorker(t, worker)
+	require.Eventually(t, func() bool {
+		return len(relatedIDs(t, db, posts[0].ID)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 追加された投稿は既存の投稿の関連記事にも入る
+	added := models.Post{Title: "Docker のネットワーク", Content: "コンテナ間の通信"}
+	require.NoError(t, db.Create(&added).Error)
+	worker.MarkDirty(added.ID)
+	require.Eventually(t, func() bool {
+		return len(relatedIDs(t, db, posts[0].ID)) == 2 && len(relatedIDs(t, db, added.ID)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 下書きに戻された投稿は他の投稿の関連記事から外れ、公開し直すと戻る
+	require.NoError(t, db.Model(&added).Update("draft", true).Error)
+	worker.MarkDirty(added.ID)
+	require.Eventually(t, func() bool {
+		return len(relatedIDs(t, db, posts[0].ID)) == 1 && len(relatedIDs(t, db, added.ID)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, db.Model(&added).Update("draft", false).Error)
+	worker.MarkDirty(added.ID)
+	require.Eventually(t, func() bool {
+		return len(relatedIDs(t, db, posts[0].ID)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 削除された投稿は他の投稿の関連記事から外れる
+	require.NoError(t, db.Delete(&posts[1]).Error)
+	worker.MarkDirty(posts[1].ID)
+	require.Eventually(t, func() bool {
+		ids := relatedIDs(t, db, posts[0].ID)
+		return len(ids) == 1 && ids[0] == added.ID && len(relatedIDs(t, db, posts[1].ID)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,234 @@
+package jobs
+
+import (
+	"blog/logging"
+	"blog/models"
+	"blog/repositories"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Webhook のリクエストのヘッダー
+const (
+	// WebhookIDHeader はイベントの ID。再送でも変わらないため、受信側は重複の排除に使える
+	WebhookIDHeader = "X-Webhook-ID"
+	// WebhookEventHeader はイベントの種類 (post.created など)
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookTimestampHeader は送信した日時 (Unix 秒)。署名に含める
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookSignatureHeader は "sha256=" と、"<タイムスタンプ>.<ボディ>" の HMAC-SHA256 (鍵は送信先の Secret) の 16 進数
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// maxLoggedBody は配信ログに残すレスポンスのボディとエラーの最大バイト数 (カラム長)
+const maxLoggedBody = 1000
+
+// WebhookOptions は Webhook の送信の設定
+type WebhookOptions struct {
+	// PollInterval は送信待ちの配信を確認する間隔
+	PollInterval time.Duration
+	// Timeout は 1 回の送信のタイムアウト
+	Timeout time.Duration
+	// MaxAttempts は再試行を含めた送信の回数の上限
+	MaxAttempts int
+	// BackoffBase と BackoffMax は再試行の間隔。失敗するたびに BackoffBase から倍にし、BackoffMax を上限とする
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// BatchSize は一度に取り出す配信の数
+	BatchSize int
+}
+
+// WebhookDispatcher は送信待ちの配信 (outbox) を取り出して Webhook を送信する。
+// 失敗した配信は指数バックオフで再試行し、送信ごとの結果を配信ログに残す。
+// 配信はデータベースで取り出すため、複数のレプリカで動かしても同じ配信を同時に送らない。
+type WebhookDispatcher struct {
+	repo   repositories.WebhookRepository
+	client *http.Client
+	opts   WebhookOptions
+	notify chan struct{}
+}
+
+func NewWebhookDispatcher(repo repositories.WebhookRepository, opts WebhookOptions) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			// リダイレクトは失敗として扱う (署名したリクエストを別の URL に送らない)
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		opts:   opts,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Notify は送信待ちの配信ができたことを知らせ、PollInterval を待たずに送信させる。ブロックしない
+func (d *WebhookDispatcher) Notify() {
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run は ctx がキャンセルされるまで送信待ちの配信を送信する
+func (d *WebhookDispatcher) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx).With("job", "webhooks")
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := d.dispatch(ctx)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to dispatch webhooks", "error", err)
+				break
+			}
+			// 取り出した数が BatchSize 未満なら、送信する時刻になった配信は残っていない
+			if sent < d.opts.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-d.notify:
+		}
+	}
+}
+
+// dispatch は送信する時刻になった配信を取り出して並行に送信し、取り出した数を返す
+func (d *WebhookDispatcher) dispatch(ctx context.Context) (int, error) {
+	// 送信中に他のワーカーが取り出さないよう、タイムアウトより長く確保する
+	deliveries, err := d.repo.ClaimDue(ctx, time.Now(), d.opts.BatchSize, 2*d.opts.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(deliveries))
+	for i := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.deliver(ctx, &deliveries[i])
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), errors.Join(errs...)
+}
+
+// deliver は配信を 1 回送信し、結果を記録する
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	subscription, err := d.repo.FindSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	attempt := d.send(ctx, subscription, delivery)
+	attempt.DurationMS = time.Since(started).Milliseconds()
+	attempt.CreatedAt = started
+
+	delivery.Attempts++
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	switch {
+	case attempt.Error == "":
+		delivery.Status = models.WebhookSucceeded
+		delivery.DeliveredAt = &started
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = models.WebhookFailed
+	default:
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	}
+	if !subscription.Active && delivery.Status == models.WebhookPending {
+		// 無効にした送信先には再試行しない
+		delivery.Status = models.WebhookFailed
+	}
+	// 停止中でも結果は記録する
+	return d.repo.RecordAttempt(context.WithoutCancel(ctx), delivery, attempt)
+}
+
+// send は Webhook のリクエストを送る。2xx 以外のレスポンスと接続のエラーは Error に理由を設定する
+func (d *WebhookDispatcher) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) *models.WebhookDeliveryAttempt {
+	attempt := &models.WebhookDeliveryAttempt{}
+	if !subscription.Active {
+		attempt.Error = "subscription is inactive"
+		return attempt
+	}
+
+	body := []byte(delivery.Event.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = truncate(err.Error())
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-webhooks/1.0")
+	req.Header.Set(WebhookIDHeader, delivery.EventID)
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, Sign(subscription.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = truncate(err.Error())
+		return attempt
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	attempt.StatusCode = resp.StatusCode
+	attempt.ResponseBody = truncate(string(data))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// backoff は attempts 回失敗した後の再試行までの間隔を返す
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.BackoffBase
+	for i := 1; i < attempts && delay < d.opts.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, d.opts.BackoffMax)
+}
+
+// Sign は Webhook の署名 (X-Webhook-Signature の値) を返す。受信側は同じ計算で検証する
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// truncate は s を配信ログのカラムに収まるように切り詰める
+func truncate(s string) string {
+	if len(s) <= maxLoggedBody {
+		return s
+	}
+	s = s[:maxLoggedBody]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+package jobs_test
+
+import (
+	"blog/jobs"
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver は受け取った Webhook を記録し、statuses の順にステータスを返す (尽きたら 200)
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, http.StatusText(status))
+}
+
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+var testWebhookOptions = jobs.WebhookOptions{
+	PollInterval: 5 * time.Millisecond,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	BackoffBase:  5 * time.Millisecond,
+	BackoffMax:   20 * time.Millisecond,
+	BatchSize:    10,
+}
+
+// enqueueWebhook は送信先を登録し、イベントを送信待ちにする
+func enqueueWebhook(t *testing.T, repo repositories.WebhookRepository, url string) *models.WebhookSubscription {
+	ctx := context.Background()
+	subscription := &models.WebhookSubscription{URL: url, Secret: "whsec_test", Active: true}
+	require.NoError(t, repo.CreateSubscription(ctx, subscription))
+	require.NoError(t, repo.Enqueue(ctx, &models.WebhookEvent{ID: "evt_1", Type: "post.created", Payload: `{"ID":"evt_1"}`, CreatedAt: time.Now()}))
+	return subscription
+}
+
+func startDispatcher(t *testing.T, dispatcher *jobs.WebhookDispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, dispatcher.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitDelivery(t *testing.T, repo repositories.WebhookRepository, subscriptionID uint, status models.WebhookDeliveryStatus) *models.WebhookDelivery {
+	var delivery *models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries, err := repo.FindDeliveries(context.Background(), subscriptionID, 1)
+		if err != nil || len(deliveries) == 0 || deliveries[0].Status != status {
+			return false
+		}
+		delivery, err = repo.FindDeliveryByID(context.Background(), subscriptionID, deliveries[0].ID)
+		return err == nil
+	}, 5*time.Second, 5*time.Millisecond)
+	return delivery
+}
+
+func TestWebhookDispatcher_RetriesUntilSuccess(t *testing.T) {
+	repo := repositories.NewWebhookRepository(setupSQLite(t))
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	subscription := enqueueWebhook(t, repo, srv.URL)
+
+	startDispatcher(t, jobs.NewWebhookDispatcher(repo, testWebhookOptions))
+	delivery := waitDelivery(t, repo, subscription.ID, models.WebhookSucceeded)
+
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.LastStatusCode)
+	assert.Empty(t, delivery.LastError)
+	assert.NotNil(t, delivery.DeliveredAt)
+	require.Len(t, delivery.AttemptLog, 2)
+	assert.Equal(t, http.StatusInternalServerError, delivery.AttemptLog[0].StatusCode)
+	assert.Equal(t, "unexpected status 500", delivery.AttemptLog[0].Error)
+	assert.Equal(t, "Internal Server Error", delivery.AttemptLog[0].ResponseBody)
+	assert.Equal(t, http.StatusOK, delivery.AttemptLog[1].StatusCode)
+	assert.Empty(t, delivery.AttemptLog[1].Error)
+
+	// 再試行でもイベントの ID とボディは同じで、署名は送信ごとのタイムスタンプで検証できる
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	require.Len(t, receiver.requests, 2)
+	for i, req := range receiver.requests {
+		assert.Equal(t, "evt_1", req.Header.Get(jobs.WebhookIDHeader))
+		assert.Equal(t, "post.created", req.Header.Get(jobs.WebhookEventHeader))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, `{"ID":"evt_1"}`, receiver.bodies[i])
+		timestamp := req.Header.Get(jobs.WebhookTimestampHeader)
+		assert.Equal(t, jobs.Sign("whsec_test", timestamp, []byte(receiver.bodies[i])), req.Header.Get(jobs.WebhookSignatureHeader))
+	}
+}
+
+func TestWebhookDispatcher_FailsAfterMaxAttempts(t *testing.T) {
+	repo := repositories.NewWebhookRepository(setupSQLite(t))
+	receiver := &webhookReceiver{statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	subscription := enqueueWebhook(t, repo, srv.URL)
+
+	startDispatcher(t, jobs.NewWebhookDispatcher(repo, testWebhookOptions))
+	delivery := waitDelivery(t, repo, subscription.ID, models.WebhookFailed)
+
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusBadGateway, delivery.LastStatusCode)
+	assert.Nil(t, delivery.DeliveredAt)
+	require.Len(t, delivery.AttemptLog, 3)
+	// 再試行の間隔は倍になる (5ms, 10ms)
+	first := delivery.AttemptLog[1].CreatedAt.Sub(delivery.AttemptLog[0].CreatedAt)
+	second := delivery.AttemptLog[2].CreatedAt.Sub(delivery.AttemptLog[1].CreatedAt)
+	assert.GreaterOrEqual(t, first, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, second, 10*time.Millisecond)
+
+	// 上限に達した配信はそれ以上送らない
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 3, receiver.count())
+}
+
+func TestWebhookDispatcher_DoesNotFollowRedirects(t *testing.T) {
+	repo := repositories.NewWebhookRepository(setupSQLite(t))
+	target := &webhookReceiver{}
+	targetSrv := httptest.NewServer(target)
+	defer targetSrv.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(targetSrv.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+	subscription := enqueueWebhook(t, repo, redirect.URL)
+
+	opts := testWebhookOptions
+	opts.MaxAttempts = 1
+	startDispatcher(t, jobs.NewWebhookDispatcher(repo, opts))
+	delivery := waitDelivery(t, repo, subscription.ID, models.WebhookFailed)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, delivery.LastStatusCode)
+	assert.Zero(t, target.count())
+}
@@ -0,0 +1,36 @@
+package logging_test
+
+import (
+	"blog/logging"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestGormLogger_HidesBoundValuesBelowDebug(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logging.NewGormLogger(time.Minute)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	query := func(level string) string {
+		var buf bytes.Buffer
+		ctx := logging.WithContext(context.Background(), logging.New(&buf, "text", level))
+		// 存在しないテーブルへのクエリでエラーログを出す
+		err := db.WithContext(ctx).Exec("SELECT * FROM missing WHERE secret = ?", "s3cr3t-value").Error
+		assert.Error(t, err)
+		return buf.String()
+	}
+
+	out := query("info")
+	assert.Contains(t, out, "database query failed")
+	assert.Contains(t, out, "secret = ?")
+	assert.NotContains(t, out, "s3cr3t-value")
+
+	assert.Contains(t, query("debug"), "s3cr3t-value")
+}
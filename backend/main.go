@@ -1,47 +1,116 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-
-	// インストール済みである必要あり
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"blog/api"
-	"blog/models" // モデルのパスを合わせる
+	"blog/config"
+	"blog/database"
+	"blog/logging"
+	"blog/tracing"
 )
 
-var db *gorm.DB
+func main() {
+	cfg := config.Load()
 
-var (
-	host     = os.Getenv("DATABASE_HOST")
-	user     = os.Getenv("DATABASE_USER")
-	password = os.Getenv("DATABASE_PASSWORD")
-	dbName   = os.Getenv("DATABASE_NAME")
-	port     = "5432"
-	dsn      = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", host, user, password, dbName, port)
-)
+	logger := logging.New(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	slog.SetDefault(logger)
 
-func main() {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// サブコマンドを省略した場合はサーバーを起動する
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "serve":
+		err = run(cfg, logger)
+	case "import":
+		err = runImport(cfg, args)
+	case "export":
+		err = runExport(cfg, args)
+	case "build":
+		err = runBuild(cfg, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q (available: serve, import, export, build)\n", command)
+		os.Exit(2)
+	}
 	if err != nil {
-		log.Fatalf("DB接続エラー: %v", err)
+		logger.Error(command+" exited with error", "error", err)
+		os.Exit(1)
 	}
+}
 
-	// マイグレーション
-	err = db.AutoMigrate(&models.Post{})
+func run(cfg *config.Config, logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// トレーサーの初期化
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error("failed to shut down tracing", "error", err)
+		}
+	}()
+
+	db, err := database.Open(cfg.Database)
 	if err != nil {
-		log.Fatalf("マイグレーションエラー: %v", err)
+		return err
+	}
+
+	// マイグレーション
+	if err := database.Migrate(db); err != nil {
+		return err
 	}
-	log.Println("マイグレーションが成功しました。")
+	logger.Info("migrations completed")
 
 	// ルートの登録
-	r := api.RegisterRoutes(db)
+	app, err := api.NewApp(db, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to register routes: %w", err)
+	}
+
+	// バックグラウンドジョブの起動。サーバー停止後に終了を待つ
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	app.Start(jobsCtx)
+	defer app.Wait()
+	defer stopJobs()
 
 	// サーバーの起動
-	log.Println("サーバーを起動します。ポート: 8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: app.Router}
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("starting server", "port", cfg.Port)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// シグナル受信後、処理中のリクエストを待ってから停止する
+	logger.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
 }
@@ -0,0 +1,40 @@
+package metrics_test
+
+import (
+	"blog/metrics"
+	"blog/models"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestGormPlugin(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock DB: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open mock GORM DB: %v", err)
+	}
+	assert.NoError(t, db.Use(metrics.GormPlugin{}))
+
+	mock.ExpectQuery(`SELECT \* FROM "posts"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "posts"`).WillReturnError(errors.New("database error"))
+
+	var posts []models.Post
+	assert.NoError(t, db.Find(&posts).Error)
+	assert.Error(t, db.Find(&posts).Error)
+
+	var m dto.Metric
+	assert.NoError(t, metrics.DBQueryDuration.WithLabelValues("query", "posts").(prometheus.Metric).Write(&m))
+	assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.DBQueryErrorsTotal.WithLabelValues("query", "posts")))
+}
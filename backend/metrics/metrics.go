@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry はアプリケーションのメトリクスを登録するレジストリ
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestsTotal はルートテンプレート・メソッド・ステータスごとのリクエスト数
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration はルートテンプレート・メソッドごとのレイテンシ
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// DBQueryDuration は GORM の操作・テーブルごとのクエリ時間
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency in seconds.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "table"})
+
+	// DBQueryErrorsTotal は GORM の操作・テーブルごとのエラー数
+	DBQueryErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Total number of failed database queries.",
+	}, []string{"operation", "table"})
+
+	// PostsCreatedTotal は作成された投稿数
+	PostsCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blog_posts_created_total",
+		Help: "Total number of posts created.",
+	})
+
+	// MarkdownRendersTotal は Markdown の HTML 変換回数
+	MarkdownRendersTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blog_markdown_renders_total",
+		Help: "Total number of markdown renders.",
+	})
+
+	// CacheRequestsTotal はキャッシュ名・結果 (hit/miss) ごとの参照数
+	CacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "blog_cache_requests_total",
+		Help: "Total number of cache lookups by result.",
+	}, []string{"cache", "result"})
+
+	// PostViewsTotal は投稿の閲覧の記録数。result は counted/duplicate/bot
+	PostViewsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "blog_post_views_total",
+		Help: "Total number of post views by result.",
+	}, []string{"result"})
+
+	// PostViewVisitorsEvictedTotal は重複の判定の上限を超えて忘れた訪問者の数。増え続ける場合は VIEWS_MAX_VISITORS を大きくする
+	PostViewVisitorsEvictedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blog_post_view_visitors_evicted_total",
+		Help: "Total number of visitors evicted from the post view deduplication set.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		DBQueryDuration,
+		DBQueryErrorsTotal,
+		PostsCreatedTotal,
+		MarkdownRendersTotal,
+		CacheRequestsTotal,
+		PostViewsTotal,
+		PostViewVisitorsEvictedTotal,
+	)
+}
+
+// RegisterDBStats は sql.DB のコネクションプール統計を登録する。
+// 既に登録済みの場合は新しい接続で置き換える。
+func RegisterDBStats(db *sql.DB) error {
+	collector := collectors.NewDBStatsCollector(db, "blog")
+	err := Registry.Register(collector)
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		Registry.Unregister(are.ExistingCollector)
+		return Registry.Register(collector)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserID は管理トークンで認証したリクエストの UserIDKey の値
+const AdminUserID = "admin"
+
+// RequireAdminToken は Authorization: Bearer のトークンが token と一致するリクエストだけを通す。
+// 一致しない場合は 401 を返す。
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasAdminToken(c, token) {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Set(UserIDKey, AdminUserID)
+		c.Next()
+	}
+}
+
+// IdentifyAdmin は Authorization: Bearer のトークンが token と一致するリクエストを管理者として UserIDKey に記録する。
+// 一致しないリクエストも拒否せずに通す。認証が任意のルートで、ユーザーごとのレート制限 (KeyByUser) などに使う
+func IdentifyAdmin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasAdminToken(c, token) {
+			c.Set(UserIDKey, AdminUserID)
+		}
+		c.Next()
+	}
+}
+
+func hasAdminToken(c *gin.Context, token string) bool {
+	given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// CachePolicy はルートごとの Cache-Control の値
+type CachePolicy struct {
+	// Public は匿名のリクエストに適用する値 (例: "public, max-age=60")
+	Public string
+	// Private は認証済みのリクエストに適用する値 (例: "private, no-store")
+	Private string
+}
+
+var (
+	// PublishedContentCache は公開済みコンテンツ向けのポリシー
+	PublishedContentCache = CachePolicy{
+		Public:  "public, max-age=60, stale-while-revalidate=300",
+		Private: "private, no-cache",
+	}
+	// NoStoreCache はキャッシュさせないポリシー
+	NoStoreCache = CachePolicy{
+		Public:  "no-store",
+		Private: "no-store",
+	}
+)
+
+// CacheControl はリクエストが認証済みかどうかに応じて Cache-Control を設定する。
+// 共有キャッシュが認証済みのレスポンスを返さないよう Vary: Authorization も付与する。
+func CacheControl(policy CachePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, authenticated := c.Get(UserIDKey)
+		if authenticated || c.GetHeader("Authorization") != "" {
+			c.Header("Cache-Control", policy.Private)
+		} else {
+			c.Header("Cache-Control", policy.Public)
+		}
+		c.Writer.Header().Add("Vary", "Authorization")
+		c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package middlewares_test
+
+import (
+	"blog/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/posts", middlewares.CacheControl(middlewares.PublishedContentCache), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	assert.Equal(t, middlewares.PublishedContentCache.Public, recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, "Authorization", recorder.Header().Get("Vary"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, middlewares.PublishedContentCache.Private, recorder.Header().Get("Cache-Control"))
+}
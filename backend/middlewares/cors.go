@@ -12,9 +12,13 @@ func CORSConfig() gin.HandlerFunc {
 			"http://localhost:3000", // 必要に応じて変更
 			"https://www.mynoteblog.com",
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Authorization", "If-None-Match", "If-Modified-Since", RequestIDHeader},
+		ExposeHeaders: []string{
+			"Content-Length", "ETag", "Last-Modified", RequestIDHeader,
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After",
+			"Deprecation", "Sunset", "Link",
+		},
 		AllowCredentials: true,
 	})
 }
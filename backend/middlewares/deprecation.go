@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated は非推奨のエンドポイントのレスポンスに Deprecation (RFC 9745) と Sunset (RFC 8594) のヘッダーを付ける。
+// successor はリクエストのパスから後継のエンドポイントのパスを返し、Link ヘッダー (rel="successor-version") で示す。
+// sunset がゼロの場合は Sunset を付けない。
+func Deprecated(deprecatedAt, sunset time.Time, successor func(path string) string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Link", "<"+successor(c.Request.URL.Path)+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
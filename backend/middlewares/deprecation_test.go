@@ -0,0 +1,39 @@
+package middlewares_test
+
+import (
+	"blog/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deprecatedAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	successor := func(path string) string { return strings.Replace(path, "/old", "/new", 1) }
+
+	r := gin.New()
+	r.GET("/old/:id", middlewares.Deprecated(deprecatedAt, time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC), successor), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/undated", middlewares.Deprecated(deprecatedAt, time.Time{}, successor), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/old/1?fields=title", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "@1792368000", recorder.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", recorder.Header().Get("Sunset"))
+	assert.Equal(t, `</new/1>; rel="successor-version"`, recorder.Header().Get("Link"))
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/undated", nil))
+	assert.NotEmpty(t, recorder.Header().Get("Deprecation"))
+	assert.Empty(t, recorder.Header().Get("Sunset"))
+}
@@ -0,0 +1,124 @@
+package middlewares
+
+import (
+	"blog/logging"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKeyFunc はリクエストからバケットのキーを導出する
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByIP はクライアント IP をキーにする。
+// gin の信頼済みプロキシ設定に従って X-Forwarded-For を解釈する。
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser は認証済みユーザー (UserIDKey。RequireAdminToken や IdentifyAdmin が設定する) をキーにし、
+// 未認証の場合はクライアント IP にフォールバックする
+func KeyByUser(c *gin.Context) string {
+	if userID, ok := c.Get(UserIDKey); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return KeyByIP(c)
+}
+
+// RateLimitPolicy はルートごとのレート制限の設定。
+// Period の間に Limit 回までリクエストでき、トークンは連続的に回復する。
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Key    RateLimitKeyFunc
+}
+
+// ParseRateLimitPolicy は "30/1m" 形式の文字列からポリシーを生成する
+func ParseRateLimitPolicy(name, spec string, key RateLimitKeyFunc) (RateLimitPolicy, error) {
+	limitStr, periodStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimitPolicy{}, fmt.Errorf("invalid rate limit %q: expected <limit>/<period>", spec)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("invalid rate limit %q: limit must be a positive integer", spec)
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", spec)
+	}
+	return RateLimitPolicy{Name: name, Limit: limit, Period: period, Key: key}, nil
+}
+
+// RateLimitResult はトークン取得の結果
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// ResetAfter はバケットが満杯に戻るまでの時間
+	ResetAfter time.Duration
+	// RetryAfter は次のトークンが利用可能になるまでの時間 (拒否時のみ)
+	RetryAfter time.Duration
+}
+
+// RateLimitStore はトークンバケットの状態を保持するストア。
+// 複数レプリカで共有する場合は Postgres や Redis などの実装を用いる。
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit int, period time.Duration) (RateLimitResult, error)
+}
+
+// RateLimit はトークンバケット方式でリクエストを制限する。
+// 応答には RateLimit-* ヘッダを付与し、超過時は 429 と Retry-After を返す。
+// ストアのエラー時はリクエストを通す (fail open)。
+func RateLimit(store RateLimitStore, policy RateLimitPolicy) gin.HandlerFunc {
+	keyFunc := policy.Key
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+
+	return func(c *gin.Context) {
+		key := policy.Name + ":" + keyFunc(c)
+		result, err := store.Take(c.Request.Context(), key, policy.Limit, policy.Period)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("rate limit store failed", "policy", policy.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// tokenResult はバケットの残量から RateLimitResult を組み立てる
+func tokenResult(tokens float64, allowed bool, limit int, period time.Duration) RateLimitResult {
+	perToken := period / time.Duration(limit)
+	result := RateLimitResult{
+		Allowed:    allowed,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(limit) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return result
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
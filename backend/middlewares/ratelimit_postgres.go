@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"blog/logging"
+	"blog/models"
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresRateLimitStore は複数レプリカでバケットを共有するストア。
+// 回復と消費を 1 つの UPSERT で行うため、同時リクエストでも整合性が保たれる。
+// 満杯まで回復したバケット (expires_at を過ぎた行) はプロセスごとに 1 分おきに削除する。
+type PostgresRateLimitStore struct {
+	db *gorm.DB
+	// lastSweep は最後に期限切れのバケットを削除した時刻 (Unix ナノ秒)
+	lastSweep atomic.Int64
+}
+
+// NewPostgresRateLimitStore はストアを生成する。
+// テーブル (models.RateLimitBucket) はマイグレーションで作成しておく必要がある。
+func NewPostgresRateLimitStore(db *gorm.DB) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db}
+}
+
+const takeTokenSQL = `
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at, expires_at)
+VALUES (@key, @limit - 1, true, now(), now() + @period * interval '1 second')
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE
+		WHEN LEAST(@limit, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * @rate) >= 1
+		THEN LEAST(@limit, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * @rate) - 1
+		ELSE LEAST(@limit, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * @rate)
+	END,
+	allowed = LEAST(@limit, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * @rate) >= 1,
+	updated_at = now(),
+	expires_at = now() + @period * interval '1 second'
+RETURNING tokens, allowed`
+
+// バケットは最後の消費から period で満杯まで回復するため、expires_at を過ぎた行は削除しても結果が変わらない
+const sweepBucketsSQL = `DELETE FROM rate_limit_buckets WHERE expires_at < now()`
+
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, limit int, period time.Duration) (RateLimitResult, error) {
+	s.sweep(ctx)
+
+	var bucket models.RateLimitBucket
+	err := s.db.WithContext(ctx).Raw(takeTokenSQL, map[string]interface{}{
+		"key":    key,
+		"limit":  float64(limit),
+		"rate":   float64(limit) / period.Seconds(),
+		"period": period.Seconds(),
+	}).Scan(&bucket).Error
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return tokenResult(bucket.Tokens, bucket.Allowed, limit, period), nil
+}
+
+// sweep は前回から 1 分以上経っていれば期限切れのバケットを削除する。失敗してもトークンの取得は続ける
+func (s *PostgresRateLimitStore) sweep(ctx context.Context) {
+	now := time.Now().UnixNano()
+	last := s.lastSweep.Load()
+	if now-last < int64(time.Minute) || !s.lastSweep.CompareAndSwap(last, now) {
+		return
+	}
+	if err := s.db.WithContext(ctx).Exec(sweepBucketsSQL).Error; err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to sweep rate limit buckets", "error", err)
+	}
+}
@@ -0,0 +1,129 @@
+package middlewares_test
+
+import (
+	"blog/middlewares"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupRateLimitRouter(store middlewares.RateLimitStore, policy middlewares.RateLimitPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set(middlewares.UserIDKey, user)
+		}
+	})
+	r.POST("/api/posts", middlewares.RateLimit(store, policy), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return r
+}
+
+func doRateLimitedRequest(r *gin.Engine, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRateLimit(t *testing.T) {
+	policy, err := middlewares.ParseRateLimitPolicy("write", "2/1h", middlewares.KeyByIP)
+	assert.NoError(t, err)
+	r := setupRateLimitRouter(middlewares.NewMemoryRateLimitStore(), policy)
+
+	first := doRateLimitedRequest(r, "")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=3600", first.Header().Get("RateLimit-Policy"))
+
+	second := doRateLimitedRequest(r, "")
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "0", second.Header().Get("RateLimit-Remaining"))
+
+	third := doRateLimitedRequest(r, "")
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	retryAfter, err := strconv.Atoi(third.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 1800, retryAfter, 1)
+}
+
+func TestRateLimit_KeyByUser(t *testing.T) {
+	policy, err := middlewares.ParseRateLimitPolicy("write", "1/1h", middlewares.KeyByUser)
+	assert.NoError(t, err)
+	r := setupRateLimitRouter(middlewares.NewMemoryRateLimitStore(), policy)
+
+	assert.Equal(t, http.StatusCreated, doRateLimitedRequest(r, "alice").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRateLimitedRequest(r, "alice").Code)
+	assert.Equal(t, http.StatusCreated, doRateLimitedRequest(r, "bob").Code)
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, int, time.Duration) (middlewares.RateLimitResult, error) {
+	return middlewares.RateLimitResult{}, errors.New("store unavailable")
+}
+
+func TestRateLimit_StoreErrorFailsOpen(t *testing.T) {
+	policy, err := middlewares.ParseRateLimitPolicy("write", "1/1h", middlewares.KeyByIP)
+	assert.NoError(t, err)
+	r := setupRateLimitRouter(failingRateLimitStore{}, policy)
+
+	assert.Equal(t, http.StatusCreated, doRateLimitedRequest(r, "").Code)
+	assert.Equal(t, http.StatusCreated, doRateLimitedRequest(r, "").Code)
+}
+
+func TestParseRateLimitPolicy_Invalid(t *testing.T) {
+	for _, spec := range []string{"30", "0/1m", "abc/1m", "30/forever", "30/-1s"} {
+		_, err := middlewares.ParseRateLimitPolicy("write", spec, nil)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestPostgresRateLimitStore_Take(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock DB: %v", err)
+	}
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open mock GORM DB: %v", err)
+	}
+
+	// 最初の Take で期限切れのバケットを削除する
+	mock.ExpectExec(`DELETE FROM rate_limit_buckets WHERE expires_at < now\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets AS b`).
+		WithArgs("write:ip:192.0.2.1", 10.0, 60.0, 10.0, 10.0/60, 10.0, 10.0/60, 10.0, 10.0/60, 10.0, 10.0/60, 60.0).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.5, false))
+
+	store := middlewares.NewPostgresRateLimitStore(gormDB)
+
+	result, err := store.Take(context.Background(), "write:ip:192.0.2.1", 10, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 3*time.Second, result.RetryAfter)
+
+	// 間隔内の 2 回目は削除しない
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets AS b`).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.0, true))
+	_, err = store.Take(context.Background(), "write:ip:192.0.2.1", 10, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"blog/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader はリクエスト ID を受け渡す HTTP ヘッダ
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey は gin.Context にリクエスト ID を格納するキー
+	RequestIDKey = "requestID"
+	// UserIDKey は認証済みユーザーの ID を gin.Context に格納するキー
+	UserIDKey = "userID"
+)
+
+// RequestLogger はリクエスト ID を付与し、リクエストごとのアクセスログを出力する。
+// リクエスト ID 付きのロガーはリクエストのコンテキストに格納され、
+// サービス層やリポジトリ層から logging.FromContext で取り出せる。
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = newRequestID()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		reqLogger := logger.With(slog.String("request_id", requestID))
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			reqLogger = reqLogger.With(slog.String("trace_id", sc.TraceID().String()))
+		}
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), reqLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID, ok := c.Get(UserIDKey); ok {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		reqLogger.LogAttrs(c.Request.Context(), level, "request completed", attrs...)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
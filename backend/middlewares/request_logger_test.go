@@ -0,0 +1,64 @@
+package middlewares_test
+
+import (
+	"blog/logging"
+	"blog/middlewares"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogger_PropagatesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "json", "info")
+
+	r := gin.New()
+	r.Use(middlewares.RequestLogger(logger))
+	r.GET("/posts", func(c *gin.Context) {
+		c.Set(middlewares.UserIDKey, "42")
+		logging.FromContext(c.Request.Context()).Info("inside handler")
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set(middlewares.RequestIDHeader, "abc-123")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	assert.Equal(t, "abc-123", recorder.Header().Get(middlewares.RequestIDHeader))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+
+	var handlerLog, accessLog map[string]any
+	assert.NoError(t, json.Unmarshal(lines[0], &handlerLog))
+	assert.NoError(t, json.Unmarshal(lines[1], &accessLog))
+	assert.Equal(t, "abc-123", handlerLog["request_id"])
+	assert.Equal(t, "abc-123", accessLog["request_id"])
+	assert.Equal(t, "GET", accessLog["method"])
+	assert.Equal(t, "/posts", accessLog["path"])
+	assert.Equal(t, float64(http.StatusOK), accessLog["status"])
+	assert.Equal(t, float64(2), accessLog["bytes"])
+	assert.Equal(t, "42", accessLog["user_id"])
+}
+
+func TestRequestLogger_GeneratesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.RequestLogger(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))))
+	r.GET("/posts", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	assert.Len(t, recorder.Header().Get(middlewares.RequestIDHeader), 32)
+}
@@ -0,0 +1,42 @@
+package middlewares_test
+
+import (
+	"blog/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.DBTimeout(10 * time.Millisecond))
+	r.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+}
+
+func TestDBTimeout_NotExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.DBTimeout(time.Second))
+	r.GET("/fast", func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		assert.True(t, hasDeadline)
+		c.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ViewRecorder は投稿の閲覧を記録する
+type ViewRecorder interface {
+	Record(postID uint, clientIP, userAgent string)
+}
+
+// ViewedPostKey は :id のないルート (公開サイトの /posts/:slug) で、ハンドラが表示した投稿の ID (uint) を格納するキー
+const ViewedPostKey = "viewedPostID"
+
+// CountView は :id (または ViewedPostKey) の投稿の取得に成功したリクエスト (200 または 304) を閲覧として記録する。
+// ブラウザの先読み (Sec-Purpose: prefetch) は数えない。
+func CountView(recorder ViewRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if status := c.Writer.Status(); status != http.StatusOK && status != http.StatusNotModified {
+			return
+		}
+		if isPrefetch(c.Request) {
+			return
+		}
+		if id, ok := c.Get(ViewedPostKey); ok {
+			recorder.Record(id.(uint), c.ClientIP(), c.Request.UserAgent())
+			return
+		}
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			return
+		}
+		recorder.Record(uint(id), c.ClientIP(), c.Request.UserAgent())
+	}
+}
+
+func isPrefetch(r *http.Request) bool {
+	purpose := r.Header.Get("Sec-Purpose")
+	if purpose == "" {
+		purpose = r.Header.Get("Purpose")
+	}
+	return purpose == "prefetch" || strings.HasPrefix(purpose, "prefetch;")
+}
@@ -0,0 +1,49 @@
+package middlewares_test
+
+import (
+	"blog/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedView struct {
+	postID    uint
+	userAgent string
+}
+
+type fakeViewRecorder struct {
+	views []recordedView
+}
+
+func (r *fakeViewRecorder) Record(postID uint, _, userAgent string) {
+	r.views = append(r.views, recordedView{postID: postID, userAgent: userAgent})
+}
+
+func TestCountView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := &fakeViewRecorder{}
+	r := gin.New()
+	r.GET("/api/posts/:id", middlewares.CountView(recorder), func(c *gin.Context) {
+		if c.Param("id") == "404" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	request := func(path string, header http.Header) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header = header
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	request("/api/posts/1", http.Header{"User-Agent": {"Mozilla/5.0"}})
+	request("/api/posts/404", http.Header{"User-Agent": {"Mozilla/5.0"}})
+	request("/api/posts/abc", http.Header{"User-Agent": {"Mozilla/5.0"}})
+	request("/api/posts/2", http.Header{"User-Agent": {"Mozilla/5.0"}, "Sec-Purpose": {"prefetch;prerender"}})
+
+	assert.Equal(t, []recordedView{{postID: 1, userAgent: "Mozilla/5.0"}}, recorder.views)
+}
@@ -0,0 +1,12 @@
+package models
+
+// Backup はバックアップの対象となる全てのデータ。削除済みの投稿と連載は含めない
+type Backup struct {
+	// Posts は Tags を含む投稿
+	Posts []Post
+	Tags  []Tag
+	// Series は Entries を含む連載
+	Series  []Series
+	Views   []PostView
+	Imports []ImportedPost
+}
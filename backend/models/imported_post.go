@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ImportedPost は他のブログから取り込んだ投稿と取り込み元の ID の対応。再実行時の重複を防ぐ
+type ImportedPost struct {
+	// Source は取り込み元の形式 (importer.Format)
+	Source   string `gorm:"primaryKey;size:20"`
+	SourceID string `gorm:"primaryKey;size:255"`
+	PostID   uint   `gorm:"not null;index"`
+	// ImportedAt は最後に取り込んだ (作成または更新した) 日時
+	ImportedAt time.Time
+}
+
+// ImportAction は取り込みで投稿に対して行った (ドライランでは行う予定の) 操作
+type ImportAction string
+
+const (
+	ImportCreated   ImportAction = "created"
+	ImportUpdated   ImportAction = "updated"
+	ImportUnchanged ImportAction = "unchanged"
+	ImportSkipped   ImportAction = "skipped"
+	ImportFailed    ImportAction = "failed"
+)
+
+// ImportResult は取り込み元の 1 件の投稿の取り込み結果
+type ImportResult struct {
+	SourceID string
+	Title    string
+	Action   ImportAction
+	// PostID は作成または更新した投稿の ID。ドライランで作成する場合は 0
+	PostID uint `json:",omitempty"`
+	// Reason はスキップまたは失敗した理由
+	Reason string `json:",omitempty"`
+}
+
+// ImportReport は取り込みの結果の集計と投稿ごとの結果
+type ImportReport struct {
+	Source    string
+	DryRun    bool
+	Created   int
+	Updated   int
+	Unchanged int
+	Skipped   int
+	Failed    int
+	Results   []ImportResult
+}
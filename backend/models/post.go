@@ -2,17 +2,64 @@
 package models
 
 import (
-    "time"
-    "gorm.io/gorm"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type Post struct {
-    ID        uint           `gorm:"primaryKey"`
-    Title     string         `gorm:"size:255;not null"`
-    Content   string         `gorm:"type:text"`
-    Author    string         `gorm:"size:100"`
-    CreatedAt time.Time      `gorm:"autoCreateTime"`
-    UpdatedAt time.Time      `gorm:"autoUpdateTime"`
-    DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID      uint   `gorm:"primaryKey"`
+	Title   string `gorm:"size:255;not null"`
+	Content string `gorm:"type:text"`
+	Author  string `gorm:"size:100"`
+	// Slug から Metadata までは本文のフロントマターからも設定できる (services の applyFrontMatter)
+	Slug        string       `gorm:"size:255;index"`
+	Description string       `gorm:"size:1000"`
+	CoverImage  string       `gorm:"size:1000"`
+	Draft       bool         `gorm:"not null;default:false"`
+	Metadata    PostMetadata `gorm:"type:text"`
+	// WordCount から Excerpt までは保存時に Content から計算する (textstats.Compute)
+	WordCount          int    `gorm:"not null;default:0"`
+	CharCount          int    `gorm:"not null;default:0"`
+	ReadingTimeMinutes int    `gorm:"not null;default:0"`
+	Excerpt            string `gorm:"size:1000"`
+	Tags               []Tag  `gorm:"many2many:post_tags"`
+	// Series は一覧で include=series を指定した場合だけ読み込む
+	Series    *PostSeries    `gorm:"-" json:",omitempty"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
+// PostMetadata はフロントマターのキーのうち投稿のフィールドに対応しないもの。JSON として保存する
+type PostMetadata map[string]any
+
+func (m PostMetadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (m *PostMetadata) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported metadata value %T", value)
+	}
+	return json.Unmarshal(data, m)
+}
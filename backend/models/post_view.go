@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PostView は投稿の日別 (UTC) の閲覧数。同じ訪問者による同じ日の閲覧は 1 回として数える
+type PostView struct {
+	PostID uint `gorm:"primaryKey"`
+	// Day は "2006-01-02" 形式の UTC の日付
+	Day   string `gorm:"primaryKey;size:10;index"`
+	Views int64  `gorm:"not null"`
+}
+
+// PopularPost は期間内の閲覧数が多い投稿 (本文なし)
+type PopularPost struct {
+	ID        uint
+	Title     string
+	Author    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Views     int64
+}
+
+// PostStats は投稿の期間内の閲覧数と日別の内訳
+type PostStats struct {
+	PostID uint
+	// Since は集計の開始日 ("2006-01-02" 形式の UTC の日付)
+	Since string
+	Views int64
+	Daily []PostView
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RateLimitBucket は複数レプリカで共有するレート制限のトークンバケット
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey;size:255"`
+	Tokens    float64   `gorm:"type:double precision;not null"`
+	Allowed   bool      `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+	// ExpiresAt はバケットが満杯まで回復する時刻。これを過ぎた行は削除してよい
+	ExpiresAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RelatedPost は投稿に類似する投稿とそのスコア。バックグラウンドジョブが計算して保存する
+type RelatedPost struct {
+	PostID        uint    `gorm:"primaryKey"`
+	RelatedPostID uint    `gorm:"primaryKey;index"`
+	Score         float64 `gorm:"not null"`
+	// Rank はスコアの高い順の 1 始まりの順位
+	Rank       int `gorm:"not null"`
+	ComputedAt time.Time
+	// Related は関連する投稿 (本文なし)
+	Related Post `gorm:"foreignKey:RelatedPostID;constraint:OnDelete:CASCADE"`
+}
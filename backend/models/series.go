@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Series は複数回に分かれた連載記事のまとまり
+type Series struct {
+	ID          uint   `gorm:"primaryKey"`
+	Title       string `gorm:"size:255;not null"`
+	Description string `gorm:"type:text"`
+	// Entries は Position 順に並んだ連載の各回
+	Entries   []SeriesEntry  `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// SeriesEntry は連載と投稿の対応と並び順。投稿は 1 つの連載にのみ属する
+type SeriesEntry struct {
+	SeriesID uint `gorm:"primaryKey"`
+	PostID   uint `gorm:"primaryKey;uniqueIndex"`
+	Position int  `gorm:"not null"`
+	Post     Post `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// SeriesNavigation は投稿が属する連載と前後の回へのリンク
+type SeriesNavigation struct {
+	ID        uint
+	Title     string
+	Position  int
+	Total     int
+	Previous  *SeriesLink
+	Next      *SeriesLink
+	UpdatedAt time.Time
+}
+
+// SeriesLink は連載内の他の回へのリンク
+type SeriesLink struct {
+	ID    uint
+	Title string
+}
+
+// PostSeries は投稿が属する連載と、その中で何回目か
+type PostSeries struct {
+	ID       uint
+	Title    string
+	Position int
+}
@@ -0,0 +1,7 @@
+package models
+
+// Tag は投稿に付けるタグ。Name は正規化 (前後の空白除去・小文字化) して保存する
+type Tag struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"size:100;not null;uniqueIndex"`
+}
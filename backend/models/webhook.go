@@ -0,0 +1,134 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebhookSubscription は投稿の変更を通知する Webhook の送信先
+type WebhookSubscription struct {
+	ID  uint   `gorm:"primaryKey"`
+	URL string `gorm:"size:2000;not null"`
+	// Secret は署名 (HMAC-SHA256) の鍵。作成時のレスポンスでだけ返す
+	Secret string `gorm:"size:255;not null" json:"-"`
+	// Events は通知するイベントの種類。空の場合は全ての種類を通知する
+	Events WebhookEvents `gorm:"type:text"`
+	Active bool          `gorm:"not null;default:true"`
+	// Deliveries はこの送信先への配信。削除すると配信の記録も消える
+	Deliveries []WebhookDelivery `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt  time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time         `gorm:"autoUpdateTime"`
+}
+
+// Accepts は送信先がイベントの種類を通知するかを返す
+func (s *WebhookSubscription) Accepts(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, event := range s.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvents はイベントの種類の一覧。JSON の配列として保存する
+type WebhookEvents []string
+
+func (e WebhookEvents) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal([]string(e))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (e *WebhookEvents) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported webhook events value %T", value)
+	}
+	return json.Unmarshal(data, (*[]string)(e))
+}
+
+// WebhookEvent は送信待ちのイベント (outbox)。投稿の変更と同じトランザクションで保存し、送信先ごとの配信を持つ
+type WebhookEvent struct {
+	// ID はイベントの一意な ID。X-Webhook-ID ヘッダーで送り、受信側の重複の排除に使う
+	ID   string `gorm:"primaryKey;size:64"`
+	Type string `gorm:"size:50;not null"`
+	// Payload は送信する JSON (WebhookPayload)
+	Payload   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// WebhookPayload は Webhook で送るボディ
+type WebhookPayload struct {
+	ID        string
+	Type      string
+	CreatedAt time.Time
+	// Post は変更後の投稿。削除の場合は削除する前の投稿
+	Post Post
+}
+
+// WebhookDeliveryStatus は配信の状態
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookPending は送信待ちまたは再試行待ち
+	WebhookPending WebhookDeliveryStatus = "pending"
+	// WebhookSucceeded は送信先が 2xx を返した
+	WebhookSucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookFailed は再試行の回数の上限まで失敗した
+	WebhookFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery はイベントの 1 つの送信先への配信
+type WebhookDelivery struct {
+	ID             uint                  `gorm:"primaryKey"`
+	SubscriptionID uint                  `gorm:"not null;index"`
+	EventID        string                `gorm:"size:64;not null;index"`
+	Event          WebhookEvent          `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	EventType      string                `gorm:"size:50;not null"`
+	Status         WebhookDeliveryStatus `gorm:"size:20;not null;index:idx_webhook_deliveries_due,priority:1"`
+	// Attempts は送信を試みた回数
+	Attempts int `gorm:"not null;default:0"`
+	// NextAttemptAt は次に送信する日時。送信中は他のワーカーが取らないよう先の日時にしておく
+	NextAttemptAt time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	// LastStatusCode と LastError は最後の送信の結果
+	LastStatusCode int    `gorm:"not null;default:0"`
+	LastError      string `gorm:"size:1000"`
+	// DeliveredAt は送信に成功した日時
+	DeliveredAt *time.Time
+	// AttemptLog は送信ごとの記録 (配信ログ)。配信の詳細でだけ読み込む
+	AttemptLog []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID;constraint:OnDelete:CASCADE" json:",omitempty"`
+	CreatedAt  time.Time                `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time                `gorm:"autoUpdateTime"`
+}
+
+// WebhookDeliveryAttempt は 1 回の送信の記録
+type WebhookDeliveryAttempt struct {
+	ID         uint `gorm:"primaryKey"`
+	DeliveryID uint `gorm:"not null;index"`
+	// StatusCode は送信先のレスポンスのステータスコード。接続できなかった場合は 0
+	StatusCode int `gorm:"not null;default:0"`
+	// Error は失敗した理由
+	Error string `gorm:"size:1000"`
+	// ResponseBody は送信先のレスポンスの先頭
+	ResponseBody string `gorm:"size:1000"`
+	DurationMS   int64  `gorm:"not null"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,120 @@
+// openapi.json を読み込み、タグごとに操作の一覧を表示する。
+// 外部のスクリプトを読み込まないよう、/docs と同じオリジンから配信する。
+// ドキュメントの文字列は textContent で挿入し、HTML として解釈しない。
+(function () {
+  "use strict";
+
+  function el(tag, props, children) {
+    var node = document.createElement(tag);
+    Object.keys(props || {}).forEach(function (key) {
+      node[key] = props[key];
+    });
+    (children || []).forEach(function (child) {
+      if (child != null) {
+        node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+      }
+    });
+    return node;
+  }
+
+  // $ref を参照先のオブジェクトに置き換える
+  function resolve(spec, value) {
+    while (value && value.$ref) {
+      value = value.$ref.replace(/^#\//, "").split("/").reduce(function (node, key) {
+        return node && node[key.replace(/~1/g, "/").replace(/~0/g, "~")];
+      }, spec);
+    }
+    return value || {};
+  }
+
+  function schemaName(schema) {
+    if (!schema) return "";
+    if (schema.$ref) return schema.$ref.split("/").pop();
+    if (schema.type === "array") return schemaName(schema.items) + "[]";
+    return schema.type || "";
+  }
+
+  function parameters(spec, params) {
+    if (!params.length) return null;
+    return el("table", {}, [
+      el("tr", {}, ["名前", "場所", "必須", "説明"].map(function (h) { return el("th", {}, [h]); })),
+    ].concat(params.map(function (p) {
+      p = resolve(spec, p);
+      return el("tr", {}, [
+        el("td", {}, [el("code", {}, [p.name])]),
+        el("td", {}, [p.in]),
+        el("td", {}, [p.required ? "はい" : ""]),
+        el("td", {}, [p.description || ""]),
+      ]);
+    })));
+  }
+
+  function content(body) {
+    return Object.keys(body.content || {}).map(function (type) {
+      var name = schemaName(body.content[type].schema);
+      return el("div", {}, [el("code", {}, [type]), name ? " " + name : ""]);
+    });
+  }
+
+  function operation(spec, path, method, op, shared) {
+    var body = el("div", {}, [
+      op.description ? el("pre", {}, [op.description]) : null,
+      parameters(spec, (shared || []).concat(op.parameters || [])),
+    ]);
+    if (op.requestBody) {
+      body.appendChild(el("h4", {}, ["リクエスト"]));
+      content(resolve(spec, op.requestBody)).forEach(function (node) { body.appendChild(node); });
+    }
+    body.appendChild(el("h4", {}, ["レスポンス"]));
+    Object.keys(op.responses || {}).forEach(function (code) {
+      var response = resolve(spec, op.responses[code]);
+      body.appendChild(el("div", {}, [el("strong", {}, [code]), " " + (response.description || "")]));
+      content(response).forEach(function (node) { body.appendChild(node); });
+    });
+    return el("details", { id: op.operationId || "", className: op.deprecated ? "deprecated" : "" }, [
+      el("summary", {}, [
+        el("span", { className: "method " + method }, [method]),
+        el("span", { className: "path" }, [path]),
+        op.summary ? " " + op.summary : "",
+      ]),
+      body,
+    ]);
+  }
+
+  function render(spec) {
+    var sections = {};
+    var order = (spec.tags || []).map(function (tag) { return tag.name; });
+    Object.keys(spec.paths || {}).forEach(function (path) {
+      var item = spec.paths[path];
+      Object.keys(item).forEach(function (method) {
+        if (method === "parameters" || typeof item[method] !== "object") return;
+        var op = item[method];
+        var tag = (op.tags || ["other"])[0];
+        if (order.indexOf(tag) < 0) order.push(tag);
+        (sections[tag] = sections[tag] || []).push(operation(spec, path, method, op, item.parameters));
+      });
+    });
+
+    var root = document.getElementById("docs");
+    root.textContent = "";
+    root.appendChild(el("h1", {}, [spec.info.title + " " + spec.info.version]));
+    root.appendChild(el("pre", {}, [spec.info.description || ""]));
+    order.forEach(function (tag) {
+      if (!sections[tag]) return;
+      var meta = (spec.tags || []).filter(function (t) { return t.name === tag; })[0] || {};
+      root.appendChild(el("h2", {}, [tag]));
+      if (meta.description) root.appendChild(el("p", {}, [meta.description]));
+      sections[tag].forEach(function (node) { root.appendChild(node); });
+    });
+  }
+
+  fetch("openapi.json")
+    .then(function (res) {
+      if (!res.ok) throw new Error("HTTP " + res.status);
+      return res.json();
+    })
+    .then(render)
+    .catch(function (err) {
+      document.getElementById("docs").textContent = "ドキュメントを読み込めませんでした: " + err.message;
+    });
+})();
@@ -0,0 +1,318 @@
+// Package openapi は API の OpenAPI 3.1 ドキュメント (openapi.yaml) を読み込み、
+// 配信用の JSON と、実際のレスポンスがドキュメントに合うかの検証を提供する。
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var source []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+//go:embed docs.js
+var docsScript []byte
+
+// DocsContentSecurityPolicy は /docs に付ける Content-Security-Policy。
+// UI のスクリプトと API の呼び出しを同じオリジンに限り、外部のスクリプトを実行させない
+const DocsContentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'unsafe-inline'; connect-src 'self'; " +
+	"base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// methods はドキュメントのパスに書ける HTTP メソッド
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Operation はドキュメントに書かれた操作
+type Operation struct {
+	Method string
+	// Path はパスのテンプレート (例: /api/posts/{id})
+	Path string
+}
+
+// Document は読み込んだ OpenAPI ドキュメント
+type Document struct {
+	root  map[string]any
+	json  []byte
+	paths []string
+}
+
+// Load は埋め込まれた openapi.yaml を読み込み、$ref が全て解決できることを確かめる
+func Load() (*Document, error) {
+	var root map[string]any
+	if err := yaml.Unmarshal(source, &root); err != nil {
+		return nil, fmt.Errorf("invalid openapi.yaml: %w", err)
+	}
+	if err := addDeprecatedAliases(root); err != nil {
+		return nil, fmt.Errorf("invalid openapi.yaml: %w", err)
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi.yaml: %w", err)
+	}
+	// 検証では JSON の数値と比べるため、JSON に変換したものを使う
+	root = nil
+	if err := decodeJSON(data, &root); err != nil {
+		return nil, err
+	}
+	doc := &Document{root: root, json: data}
+	paths, _ := root["paths"].(map[string]any)
+	for path := range paths {
+		doc.paths = append(doc.paths, path)
+	}
+	slices.Sort(doc.paths)
+	if err := doc.checkRefs(root); err != nil {
+		return nil, fmt.Errorf("invalid openapi.yaml: %w", err)
+	}
+	return doc, nil
+}
+
+// JSON は /openapi.json で配信するドキュメント
+func (d *Document) JSON() []byte {
+	return d.json
+}
+
+// DocsPage は /openapi.json を表示する API ドキュメントの UI の HTML
+func DocsPage() []byte {
+	return docsPage
+}
+
+// DocsScript は DocsPage が読み込む UI のスクリプト (/docs.js)
+func DocsScript() []byte {
+	return docsScript
+}
+
+// Operations はドキュメントに書かれた全ての操作
+func (d *Document) Operations() []Operation {
+	var operations []Operation
+	for _, path := range d.paths {
+		item := d.pathItem(path)
+		for _, method := range methods {
+			if _, ok := item[method]; ok {
+				operations = append(operations, Operation{Method: strings.ToUpper(method), Path: path})
+			}
+		}
+	}
+	return operations
+}
+
+func (d *Document) pathItem(path string) map[string]any {
+	paths, _ := d.root["paths"].(map[string]any)
+	item, _ := paths[path].(map[string]any)
+	return item
+}
+
+// match はリクエストのパスに合うパスのテンプレートを返す。複数合う場合は固定の要素が多いものを選ぶ
+// (/api/posts/popular は /api/posts/{id} より優先する)
+func (d *Document) match(path string) (string, bool) {
+	segments := strings.Split(path, "/")
+	best, bestLiterals := "", -1
+	for _, template := range d.paths {
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		literals := 0
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && segments[i] != "" {
+				continue
+			}
+			if part != segments[i] {
+				literals = -1
+				break
+			}
+			literals++
+		}
+		if literals > bestLiterals {
+			best, bestLiterals = template, literals
+		}
+	}
+	return best, bestLiterals >= 0
+}
+
+// ValidateResponse はリクエスト (method と実際のパス) に対するレスポンスがドキュメントに合うかを検証する。
+// ステータスコード (または default) とメディアタイプがドキュメントにあるかを確かめ、JSON のボディはスキーマで検証する。
+func (d *Document) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	template, ok := d.match(path)
+	if !ok {
+		return fmt.Errorf("%s %s: no path in the OpenAPI document", method, path)
+	}
+	operation, ok := d.pathItem(template)[strings.ToLower(method)].(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s %s: operation is not documented", method, template)
+	}
+	responses, _ := operation["responses"].(map[string]any)
+	response, ok := responses[strconv.Itoa(status)]
+	if !ok {
+		if response, ok = responses["default"]; !ok {
+			return fmt.Errorf("%s %s: status %d is not documented", method, template, status)
+		}
+	}
+	resolved, err := d.resolve(response)
+	if err != nil {
+		return err
+	}
+	content, ok := resolved["content"].(map[string]any)
+	if !ok || len(body) == 0 || method == http.MethodHead {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%s %s: status %d: invalid content type %q", method, template, status, contentType)
+	}
+	media, ok := content[mediaType]
+	if !ok {
+		media, ok = content[strings.SplitN(mediaType, "/", 2)[0]+"/*"]
+	}
+	if !ok {
+		media, ok = content["*/*"]
+	}
+	if !ok {
+		return fmt.Errorf("%s %s: status %d: content type %q is not documented", method, template, status, mediaType)
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+	schema, _ := media.(map[string]any)["schema"]
+	if schema == nil {
+		return nil
+	}
+	var value any
+	if err := decodeJSON(body, &value); err != nil {
+		return fmt.Errorf("%s %s: status %d: %w", method, template, status, err)
+	}
+	if err := d.validate(schema, value, "$"); err != nil {
+		return fmt.Errorf("%s %s: status %d: %w", method, template, status, err)
+	}
+	return nil
+}
+
+// resolve は $ref であれば参照先を返す。参照は同じドキュメントの中 (#/...) だけを扱う
+func (d *Document) resolve(value any) (map[string]any, error) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", value)
+	}
+	for seen := 0; seen < 16; seen++ {
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return object, nil
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, fmt.Errorf("unsupported $ref %q", ref)
+		}
+		var target any = d.root
+		for _, name := range strings.Split(ref[2:], "/") {
+			name = strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+			parent, _ := target.(map[string]any)
+			if target, ok = parent[name]; !ok {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+		}
+		if object, ok = target.(map[string]any); !ok {
+			return nil, fmt.Errorf("$ref %q is not an object", ref)
+		}
+	}
+	return nil, errors.New("too many nested $ref")
+}
+
+// addDeprecatedAliases は x-deprecated-aliases ({"<後継のパスの接頭辞>": "<別名の接頭辞>"}) に従って、
+// 非推奨の別名のパスを paths に加える。別名の操作は後継と同じ内容に deprecated を付け、operationId には Deprecated を付ける
+func addDeprecatedAliases(root map[string]any) error {
+	aliases, _ := root["x-deprecated-aliases"].(map[string]any)
+	paths, _ := root["paths"].(map[string]any)
+	for successor, value := range aliases {
+		alias, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("x-deprecated-aliases: alias of %s must be a string", successor)
+		}
+		for path, item := range paths {
+			rest, ok := strings.CutPrefix(path, successor)
+			if !ok {
+				continue
+			}
+			if _, exists := paths[alias+rest]; exists {
+				return fmt.Errorf("x-deprecated-aliases: %s is already documented", alias+rest)
+			}
+			aliasItem, _ := deepCopy(item).(map[string]any)
+			for _, method := range methods {
+				operation, ok := aliasItem[method].(map[string]any)
+				if !ok {
+					continue
+				}
+				operation["deprecated"] = true
+				if id, ok := operation["operationId"].(string); ok {
+					operation["operationId"] = id + "Deprecated"
+				}
+				description, _ := operation["description"].(string)
+				operation["description"] = strings.TrimSpace(description + "\n\n" + path + " の非推奨の別名。レスポンスに Deprecation、Sunset と後継のパスを示す Link ヘッダーを付ける。")
+			}
+			paths[alias+rest] = aliasItem
+		}
+	}
+	delete(root, "x-deprecated-aliases")
+	return nil
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, child := range v {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	}
+	return value
+}
+
+// checkRefs は value の中の全ての $ref が解決できるかを確かめる
+func (d *Document) checkRefs(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if _, ok := v["$ref"]; ok {
+			if _, err := d.resolve(v); err != nil {
+				return err
+			}
+		}
+		for _, child := range v {
+			if err := d.checkRefs(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := d.checkRefs(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package openapi_test
+
+import (
+	"blog/openapi"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResponse(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	const post = `{"ID":1,"Title":"t","Content":"c","Author":"","Slug":"","Description":"","CoverImage":"","Draft":false,
+		"Metadata":null,"WordCount":1,"CharCount":1,"ReadingTimeMinutes":1,"Excerpt":"c","Tags":null,
+		"CreatedAt":"2024-01-02T03:04:05.123456Z","UpdatedAt":"2024-01-02T03:04:05Z","DeletedAt":null}`
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		status      int
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{"valid", "GET", "/api/posts/1", 200, "application/json; charset=utf-8", post, ""},
+		{"list item with selected fields", "GET", "/api/posts", 200, "application/json", `[{"ID":1,"Title":"t","Tags":[],"Series":null}]`, ""},
+		{"literal path takes precedence", "GET", "/api/posts/popular", 200, "application/json",
+			`[{"ID":1,"Title":"t","Author":"","CreatedAt":"2024-01-02T03:04:05Z","UpdatedAt":"2024-01-02T03:04:05Z","Views":3}]`, ""},
+		{"error by default response", "GET", "/api/posts/1", 504, "application/json", `{"error":"Request timed out"}`, ""},
+		{"not modified without body", "GET", "/api/posts/1", 304, "", "", ""},
+		{"html page", "GET", "/posts/hello", 200, "text/html; charset=utf-8", "<html></html>", ""},
+		{"missing required", "GET", "/api/posts", 200, "application/json", `[{"Title":"t"}]`, `$[0]: missing required property "ID"`},
+		{"wrong type", "GET", "/api/posts", 200, "application/json", `[{"ID":"1"}]`, `$[0].ID: expected integer, got "1"`},
+		{"unexpected property", "GET", "/api/posts", 200, "application/json", `[{"ID":1,"Password":"x"}]`, `unexpected property "Password"`},
+		{"invalid date-time", "GET", "/api/posts", 200, "application/json", `[{"ID":1,"CreatedAt":"yesterday"}]`, "not a valid date-time"},
+		{"enum", "POST", "/api/import", 200, "application/json",
+			`{"Source":"hugo","DryRun":false,"Created":0,"Updated":0,"Unchanged":0,"Skipped":0,"Failed":0,"Results":[{"SourceID":"a","Title":"t","Action":"moved"}]}`,
+			`$.Results[0].Action: "moved" is not one of`},
+		{"undocumented status", "GET", "/health", 500, "application/json", `{"error":"x"}`, "status 500 is not documented"},
+		{"undocumented content type", "GET", "/api/posts/1", 200, "text/plain", "post", `content type "text/plain" is not documented`},
+		{"undocumented method", "PATCH", "/api/posts/1", 200, "application/json", "{}", "operation is not documented"},
+		{"undocumented path", "GET", "/api/users", 200, "application/json", "[]", "no path in the OpenAPI document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.ValidateResponse(tt.method, tt.path, tt.status, tt.contentType, []byte(tt.body))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestOperations(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	operations := doc.Operations()
+	assert.Contains(t, operations, openapi.Operation{Method: "GET", Path: "/api/v1/posts/{id}"})
+	// バージョンなしのパスは x-deprecated-aliases から加える
+	assert.Contains(t, operations, openapi.Operation{Method: "GET", Path: "/api/posts/{id}"})
+
+	var spec struct {
+		Paths map[string]map[string]any
+	}
+	require.NoError(t, json.Unmarshal(doc.JSON(), &spec))
+	legacy := spec.Paths["/api/posts/{id}"]["get"].(map[string]any)
+	assert.Equal(t, true, legacy["deprecated"])
+	assert.Equal(t, "getPostDeprecated", legacy["operationId"])
+	assert.NotContains(t, spec.Paths["/api/v1/posts/{id}"]["get"], "deprecated")
+	assert.Contains(t, operations, openapi.Operation{Method: "HEAD", Path: "/static/{filepath}"})
+}
@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// validate は value を JSON Schema (2020-12) で検証する。openapi.yaml で使うキーワードだけを扱う:
+// $ref, type, const, enum, format (date-time, date), minimum, maximum,
+// properties, required, additionalProperties, items, anyOf, allOf, oneOf。
+// それ以外のキーワード (description, examples など) は無視する。
+func (d *Document) validate(schemaValue any, value any, path string) error {
+	schema, err := d.resolve(schemaValue)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if types, ok := schemaTypes(schema); ok && !slices.Contains(types, typeOf(value)) {
+		// integer は number にも合う
+		if !(typeOf(value) == "integer" && slices.Contains(types, "number")) {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), describe(value))
+		}
+	}
+	if expected, ok := schema["const"]; ok && !equalJSON(expected, value) {
+		return fmt.Errorf("%s: expected %s, got %s", path, describe(expected), describe(value))
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return equalJSON(e, value) }) {
+		return fmt.Errorf("%s: %s is not one of %s", path, describe(value), describe(enum))
+	}
+
+	if err := d.validateComposition(schema, value, path); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return validateFormat(schema, v, path)
+	case json.Number:
+		return validateRange(schema, v, path)
+	case []any:
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				if err := d.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		return d.validateObject(schema, v, path)
+	}
+	return nil
+}
+
+func (d *Document) validateComposition(schema map[string]any, value any, path string) error {
+	if allOf, ok := schema["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			if err := d.validate(sub, value, path); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var errs []string
+		for _, sub := range anyOf {
+			err := d.validate(sub, value, path)
+			if err == nil {
+				errs = nil
+				break
+			}
+			errs = append(errs, err.Error())
+		}
+		if errs != nil {
+			return fmt.Errorf("%s: does not match any schema of anyOf (%s)", path, strings.Join(errs, "; "))
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		for _, sub := range oneOf {
+			if d.validate(sub, value, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d schemas of oneOf", path, matched)
+		}
+	}
+	return nil
+}
+
+func (d *Document) validateObject(schema map[string]any, object map[string]any, path string) error {
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := object[name.(string)]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		property, ok := properties[name]
+		if !ok {
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			case map[string]any:
+				property = additional
+			default:
+				continue
+			}
+		}
+		if err := d.validate(property, object[name], path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFormat(schema map[string]any, value, path string) error {
+	var layout string
+	switch schema["format"] {
+	case "date-time":
+		layout = time.RFC3339Nano
+	case "date":
+		layout = time.DateOnly
+	default:
+		return nil
+	}
+	if _, err := time.Parse(layout, value); err != nil {
+		return fmt.Errorf("%s: %q is not a valid %s", path, value, schema["format"])
+	}
+	return nil
+}
+
+func validateRange(schema map[string]any, value json.Number, path string) error {
+	n, err := value.Float64()
+	if err != nil {
+		return fmt.Errorf("%s: invalid number %s", path, value)
+	}
+	if minimum, ok := schema["minimum"].(json.Number); ok {
+		if m, _ := minimum.Float64(); n < m {
+			return fmt.Errorf("%s: %s is less than %s", path, value, minimum)
+		}
+	}
+	if maximum, ok := schema["maximum"].(json.Number); ok {
+		if m, _ := maximum.Float64(); n > m {
+			return fmt.Errorf("%s: %s is greater than %s", path, value, maximum)
+		}
+	}
+	return nil
+}
+
+// schemaTypes は type キーワードの型 (文字列または配列)
+func schemaTypes(schema map[string]any) ([]string, bool) {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}, true
+	case []any:
+		types := make([]string, len(t))
+		for i, v := range t {
+			types[i], _ = v.(string)
+		}
+		return types, true
+	}
+	return nil, false
+}
+
+// typeOf は JSON の値の JSON Schema の型。小数部のない数は integer
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func describe(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil || len(data) > 80 {
+		return typeOf(value)
+	}
+	return string(data)
+}
+
+func equalJSON(a, b any) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
@@ -0,0 +1,198 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// titleWeight はタイトル中の語を本文の何回分として数えるか
+	titleWeight = 3
+	// tagWeight は類似度のうちタグの一致 (Jaccard 係数) が占める割合。残りは TF-IDF のコサイン類似度
+	tagWeight = 0.4
+)
+
+// Document は索引に登録する投稿
+type Document struct {
+	ID      uint
+	Title   string
+	Content string
+	Tags    []string
+}
+
+// Match は類似する投稿とそのスコア (0〜1)
+type Match struct {
+	ID    uint
+	Score float64
+}
+
+// Index は投稿の語の出現頻度と文書頻度を保持し、投稿同士の類似度を求める。
+// 並行に使う場合は呼び出し側で排他すること。
+type Index struct {
+	docs map[uint]*document
+	// df は語ごとの出現文書数
+	df map[string]int
+	// vectors は TF-IDF ベクトルのキャッシュ。IDF は全文書に依存するため、登録・削除の度に破棄する
+	vectors map[uint]vector
+}
+
+type document struct {
+	counts map[string]float64
+	total  float64
+	tags   map[string]bool
+}
+
+type vector struct {
+	weights map[string]float64
+	norm    float64
+}
+
+func NewIndex() *Index {
+	return &Index{docs: make(map[uint]*document), df: make(map[string]int)}
+}
+
+// Len は登録されている投稿数を返す
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// Has は投稿が登録されているかを返す
+func (ix *Index) Has(id uint) bool {
+	_, ok := ix.docs[id]
+	return ok
+}
+
+// IDs は登録されている投稿の ID を昇順で返す
+func (ix *Index) IDs() []uint {
+	ids := make([]uint, 0, len(ix.docs))
+	for id := range ix.docs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Upsert は投稿を登録する。既に登録されている場合は置き換える
+func (ix *Index) Upsert(doc Document) {
+	ix.Remove(doc.ID)
+
+	d := &document{counts: make(map[string]float64), tags: make(map[string]bool)}
+	for _, token := range Tokenize(doc.Title) {
+		d.counts[token] += titleWeight
+		d.total += titleWeight
+	}
+	for _, token := range Tokenize(doc.Content) {
+		d.counts[token]++
+		d.total++
+	}
+	for _, tag := range doc.Tags {
+		if tag = normalizeTag(tag); tag != "" {
+			d.tags[tag] = true
+		}
+	}
+
+	for term := range d.counts {
+		ix.df[term]++
+	}
+	ix.docs[doc.ID] = d
+	ix.vectors = nil
+}
+
+// Remove は投稿を索引から取り除く
+func (ix *Index) Remove(id uint) {
+	d, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for term := range d.counts {
+		if ix.df[term]--; ix.df[term] <= 0 {
+			delete(ix.df, term)
+		}
+	}
+	delete(ix.docs, id)
+	ix.vectors = nil
+}
+
+// Similarity は 2 つの投稿の類似度を返す。どちらかが登録されていない場合は 0
+func (ix *Index) Similarity(a, b uint) float64 {
+	da, okA := ix.docs[a]
+	db, okB := ix.docs[b]
+	if !okA || !okB || a == b {
+		return 0
+	}
+	return tagWeight*jaccard(da.tags, db.tags) + (1-tagWeight)*cosine(ix.vector(a), ix.vector(b))
+}
+
+// TopN は id の投稿に類似する投稿をスコアの高い順に最大 n 件返す。スコアが 0 の投稿は含めない
+func (ix *Index) TopN(id uint, n int) []Match {
+	if !ix.Has(id) || n <= 0 {
+		return nil
+	}
+
+	var matches []Match
+	for other := range ix.docs {
+		if score := ix.Similarity(id, other); score > 0 {
+			matches = append(matches, Match{ID: other, Score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if len(matches) > n {
+		matches = matches[:n]
+	}
+	return matches
+}
+
+// vector は投稿の TF-IDF ベクトルを返す
+func (ix *Index) vector(id uint) vector {
+	if v, ok := ix.vectors[id]; ok {
+		return v
+	}
+	if ix.vectors == nil {
+		ix.vectors = make(map[uint]vector, len(ix.docs))
+	}
+
+	d := ix.docs[id]
+	n := float64(len(ix.docs))
+	v := vector{weights: make(map[string]float64, len(d.counts))}
+	for term, count := range d.counts {
+		idf := math.Log((1+n)/(1+float64(ix.df[term]))) + 1
+		w := count / d.total * idf
+		v.weights[term] = w
+		v.norm += w * w
+	}
+	v.norm = math.Sqrt(v.norm)
+	ix.vectors[id] = v
+	return v
+}
+
+func cosine(a, b vector) float64 {
+	if a.norm == 0 || b.norm == 0 {
+		return 0
+	}
+	if len(a.weights) > len(b.weights) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, w := range a.weights {
+		dot += w * b.weights[term]
+	}
+	return dot / (a.norm * b.norm)
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for tag := range a {
+		if b[tag] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,62 @@
+package recommend_test
+
+import (
+	"blog/recommend"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize_Latin(t *testing.T) {
+	tokens := recommend.Tokenize("Getting started with Go: the GORM ORM, v2!")
+	assert.Equal(t, []string{"getting", "started", "go", "gorm", "orm", "v2"}, tokens)
+}
+
+func TestTokenize_Japanese(t *testing.T) {
+	// 漢字・カタカナは bigram に分割し、ひらがなだけの bigram は除く
+	tokens := recommend.Tokenize("データベースの設計")
+	assert.Equal(t, []string{"デー", "ータ", "タベ", "ベー", "ース", "スの", "の設", "設計"}, tokens)
+
+	assert.Equal(t, []string{"猫"}, recommend.Tokenize("猫"))
+	assert.Empty(t, recommend.Tokenize("これは"))
+}
+
+func TestTokenize_Mixed(t *testing.T) {
+	tokens := recommend.Tokenize("Goで並行処理")
+	assert.Equal(t, []string{"go", "で並", "並行", "行処", "処理"}, tokens)
+}
+
+func TestIndex_TopN(t *testing.T) {
+	ix := recommend.NewIndex()
+	ix.Upsert(recommend.Document{ID: 1, Title: "Go の並行処理入門", Content: "goroutine と channel を使った並行処理", Tags: []string{"go"}})
+	ix.Upsert(recommend.Document{ID: 2, Title: "Go の並行処理パターン", Content: "channel による並行処理のパターン", Tags: []string{"go", "concurrency"}})
+	ix.Upsert(recommend.Document{ID: 3, Title: "Baking bread", Content: "flour, yeast and a hot oven", Tags: []string{"cooking"}})
+	ix.Upsert(recommend.Document{ID: 4, Title: "Go のテスト", Content: "testing パッケージの使い方", Tags: []string{"Go"}})
+
+	matches := ix.TopN(1, 3)
+	require.Len(t, matches, 2)
+	assert.Equal(t, uint(2), matches[0].ID)
+	assert.Equal(t, uint(4), matches[1].ID)
+	assert.Greater(t, matches[0].Score, matches[1].Score)
+	assert.LessOrEqual(t, matches[0].Score, 1.0)
+
+	assert.Empty(t, ix.TopN(3, 3))
+	assert.InDelta(t, ix.Similarity(1, 2), ix.Similarity(2, 1), 1e-12)
+}
+
+func TestIndex_UpsertAndRemove(t *testing.T) {
+	ix := recommend.NewIndex()
+	ix.Upsert(recommend.Document{ID: 1, Title: "Docker 入門"})
+	ix.Upsert(recommend.Document{ID: 2, Title: "Docker 入門"})
+	assert.InDelta(t, 1.0, ix.Similarity(1, 2)/(1-0.4), 1e-9)
+
+	ix.Upsert(recommend.Document{ID: 2, Title: "Kubernetes"})
+	assert.Zero(t, ix.Similarity(1, 2))
+
+	ix.Remove(2)
+	assert.False(t, ix.Has(2))
+	assert.Equal(t, 1, ix.Len())
+	assert.Empty(t, ix.TopN(1, 5))
+	assert.Zero(t, ix.Similarity(1, 2))
+}
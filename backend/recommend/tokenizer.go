@@ -0,0 +1,78 @@
+// Package recommend はタグと本文の TF-IDF から投稿同士の類似度を求める
+package recommend
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords は英語の頻出語。索引から除外する
+var stopWords = map[string]bool{
+	"an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "can": true, "do": true, "for": true, "from": true, "has": true, "have": true,
+	"if": true, "in": true, "is": true, "it": true, "its": true, "not": true, "of": true,
+	"on": true, "or": true, "so": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "we": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// Tokenize はテキストを索引用の語に分割する。
+// 英数字は空白や記号で区切って小文字にし、1 文字の語とストップワードを除く。
+// 日本語 (漢字・ひらがな・カタカナ) は分かち書きせずに文字 bigram に分割する。
+// 助詞などを除くため、ひらがなだけの bigram は捨てる。
+func Tokenize(text string) []string {
+	var tokens []string
+	var word, cjk []rune
+
+	flushWord := func() {
+		if len(word) >= 2 {
+			if w := string(word); !stopWords[w] {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			if !isHiragana(cjk[0]) {
+				tokens = append(tokens, string(cjk))
+			}
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			if isHiragana(cjk[i]) && isHiragana(cjk[i+1]) {
+				continue
+			}
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー' || r == '々'
+}
+
+func isHiragana(r rune) bool {
+	return unicode.Is(unicode.Hiragana, r)
+}
+
+// normalizeTag はタグを比較用に正規化する
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
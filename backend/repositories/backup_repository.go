@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// restoreBatchSize は復元時に 1 回の INSERT で保存する件数
+const restoreBatchSize = 100
+
+type backupRepository struct {
+	db *gorm.DB
+}
+
+func NewBackupRepository(db *gorm.DB) BackupRepository {
+	return &backupRepository{db: db}
+}
+
+func (r *backupRepository) IsEmpty(ctx context.Context) (bool, error) {
+	db := r.db.WithContext(ctx).Unscoped()
+	for _, model := range []any{&models.Post{}, &models.Tag{}, &models.Series{}} {
+		var count int64
+		if err := db.Model(model).Limit(1).Count(&count).Error; err != nil {
+			return false, fmt.Errorf("failed to count records: %w", err)
+		}
+		if count > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *backupRepository) Dump(ctx context.Context) (*models.Backup, error) {
+	db := r.db.WithContext(ctx)
+	// 削除済みの投稿を除く
+	postIDs := db.Model(&models.Post{}).Select("id")
+
+	var backup models.Backup
+	if err := preloadTags(db).Order("id").Find(&backup.Posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to dump posts: %w", err)
+	}
+	if err := db.Order("id").Find(&backup.Tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to dump tags: %w", err)
+	}
+	err := db.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Order("id").Find(&backup.Series).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump series: %w", err)
+	}
+	if err := db.Where("post_id IN (?)", postIDs).Order("post_id, day").Find(&backup.Views).Error; err != nil {
+		return nil, fmt.Errorf("failed to dump post views: %w", err)
+	}
+	if err := db.Where("post_id IN (?)", postIDs).Order("source, source_id").Find(&backup.Imports).Error; err != nil {
+		return nil, fmt.Errorf("failed to dump imported posts: %w", err)
+	}
+	return &backup, nil
+}
+
+func (r *backupRepository) Restore(ctx context.Context, backup *models.Backup) error {
+	db := r.db.WithContext(ctx)
+
+	if err := createInBatches(db, backup.Tags); err != nil {
+		return fmt.Errorf("failed to restore tags: %w", err)
+	}
+	// タグは復元済みなので post_tags だけを作成する
+	if err := createInBatches(db.Omit("Tags.*"), backup.Posts); err != nil {
+		return fmt.Errorf("failed to restore posts: %w", err)
+	}
+	var entries []models.SeriesEntry
+	for _, series := range backup.Series {
+		entries = append(entries, series.Entries...)
+	}
+	if err := createInBatches(db.Omit("Entries"), backup.Series); err != nil {
+		return fmt.Errorf("failed to restore series: %w", err)
+	}
+	if err := createInBatches(db.Omit("Post"), entries); err != nil {
+		return fmt.Errorf("failed to restore series entries: %w", err)
+	}
+	if err := createInBatches(db, backup.Views); err != nil {
+		return fmt.Errorf("failed to restore post views: %w", err)
+	}
+	if err := createInBatches(db, backup.Imports); err != nil {
+		return fmt.Errorf("failed to restore imported posts: %w", err)
+	}
+
+	// ID を指定して INSERT しても PostgreSQL のシーケンスは進まないため、最大の ID の次に合わせる
+	if db.Dialector.Name() == "postgres" {
+		for _, table := range []string{"posts", "tags", "series"} {
+			err := db.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM %[1]s), false)", table)).Error
+			if err != nil {
+				return fmt.Errorf("failed to reset sequence of %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+func createInBatches[T any](db *gorm.DB, records []T) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(records, restoreBatchSize).Error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+)
+
+type BackupRepository interface {
+	// IsEmpty は投稿・タグ・連載が (削除済みを含めて) 1 件もないかどうかを返す
+	IsEmpty(ctx context.Context) (bool, error)
+	// Dump は全てのデータを ID 順に返す
+	Dump(ctx context.Context) (*models.Backup, error)
+	// Restore は backup を ID を保ったまま保存する。空のデータベースに対して使う
+	Restore(ctx context.Context, backup *models.Backup) error
+}
@@ -0,0 +1,6 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// ErrNotFound は対象のレコードが存在しないことを表す。リポジトリのエラーは errors.Is で判定できる
+var ErrNotFound = gorm.ErrRecordNotFound
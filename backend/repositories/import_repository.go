@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type importRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+func (r *importRepository) Find(ctx context.Context, source, sourceID string) (*models.ImportedPost, error) {
+	var imported models.ImportedPost
+	err := r.db.WithContext(ctx).Where("source = ? AND source_id = ?", source, sourceID).Take(&imported).Error
+	if err != nil {
+		return nil, fmt.Errorf("imported post not found: %w", err)
+	}
+	return &imported, nil
+}
+
+func (r *importRepository) Save(ctx context.Context, imported *models.ImportedPost) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "source"}, {Name: "source_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"post_id", "imported_at"}),
+		}).
+		Create(imported).Error
+	if err != nil {
+		return fmt.Errorf("failed to save imported post: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+)
+
+type ImportRepository interface {
+	// Find は取り込み元の ID に対応する投稿を返す。取り込んでいない場合は ErrNotFound を返す
+	Find(ctx context.Context, source, sourceID string) (*models.ImportedPost, error)
+	// Save は対応を作成または更新する
+	Save(ctx context.Context, imported *models.ImportedPost) error
+}
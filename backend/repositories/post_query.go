@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidQuery は PostQuery に許可されていないフィールドや関連が含まれることを表す
+var ErrInvalidQuery = errors.New("invalid query")
+
+// 一緒に読み込める関連
+const (
+	// IncludeAuthor は著者名。著者は投稿の author 列に名前として保存しているため、列を読み込むだけ
+	IncludeAuthor = "author"
+	// IncludeTags は投稿のタグ
+	IncludeTags = "tags"
+	// IncludeSeries は投稿が属する連載と何回目か
+	IncludeSeries = "series"
+)
+
+// PostFields は PostQuery.Fields で指定できるフィールド。名前は posts テーブルの列名と同じで、
+// この一覧にない名前は Select に渡さない。
+var PostFields = []string{
+	"id", "title", "content", "author", "slug", "description", "cover_image", "draft", "metadata", "word_count", "char_count", "reading_time_minutes", "excerpt", "created_at", "updated_at",
+}
+
+// PostIncludes は PostQuery.Include で指定できる関連
+var PostIncludes = []string{IncludeAuthor, IncludeTags, IncludeSeries}
+
+// PostQuery は投稿の一覧で読み込むフィールドと関連
+type PostQuery struct {
+	// Fields は読み込むフィールド。空の場合は全てのフィールドを読み込む。
+	// id と updated_at は ETag の計算などに使うため常に読み込む。
+	Fields []string
+	// Include は一緒に読み込む関連
+	Include []string
+	// IDs は読み込む投稿の ID。空の場合は全ての投稿を読み込む
+	IDs []uint
+	// Published は公開されている投稿 (下書きでないもの) だけを読み込む。公開の読み取りは全てこれを指定する
+	Published bool
+}
+
+// Validate はフィールドと関連が許可されたものだけかを検証する
+func (q PostQuery) Validate() error {
+	for _, field := range q.Fields {
+		if !slices.Contains(PostFields, field) {
+			return fmt.Errorf("%w: unknown field %q (allowed: %s)", ErrInvalidQuery, field, strings.Join(PostFields, ", "))
+		}
+	}
+	for _, include := range q.Include {
+		if include == "comment_count" {
+			return fmt.Errorf("%w: comment_count is not available because comments are not implemented", ErrInvalidQuery)
+		}
+		if !slices.Contains(PostIncludes, include) {
+			return fmt.Errorf("%w: unknown include %q (allowed: %s)", ErrInvalidQuery, include, strings.Join(PostIncludes, ", "))
+		}
+	}
+	return nil
+}
+
+// Includes は関連を読み込むかを返す
+func (q PostQuery) Includes(include string) bool {
+	return slices.Contains(q.Include, include)
+}
+
+// Columns は Select に渡す列を返す。全ての列を読み込む場合は nil を返す
+func (q PostQuery) Columns() []string {
+	if len(q.Fields) == 0 {
+		return nil
+	}
+	columns := []string{"id", "updated_at"}
+	for _, field := range q.Fields {
+		if !slices.Contains(columns, field) {
+			columns = append(columns, field)
+		}
+	}
+	if q.Includes(IncludeAuthor) && !slices.Contains(columns, "author") {
+		columns = append(columns, "author")
+	}
+	return columns
+}
+
+// String はキャッシュのキーなどに使う正規化した表現を返す
+func (q PostQuery) String() string {
+	fields := slices.Clone(q.Columns())
+	slices.Sort(fields)
+	include := slices.Clone(q.Include)
+	slices.Sort(include)
+	key := "fields=" + strings.Join(fields, ",") + ";include=" + strings.Join(slices.Compact(include), ",")
+	if len(q.IDs) > 0 {
+		ids := slices.Clone(q.IDs)
+		slices.Sort(ids)
+		key += ";ids=" + strings.Trim(fmt.Sprint(slices.Compact(ids)), "[]")
+	}
+	if q.Published {
+		key += ";published"
+	}
+	return key
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"blog/models"
+	"context"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -15,38 +16,116 @@ func NewPostRepository(db *gorm.DB) PostRepository {
 	return &postRepository{db: db}
 }
 
-func (r *postRepository) FindAll() ([]models.Post, error) {
+// preloadTags は投稿のタグを名前順で読み込む
+func preloadTags(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name")
+	})
+}
+
+func (r *postRepository) FindAll(ctx context.Context) ([]models.Post, error) {
 	var posts []models.Post
-	if err := r.db.Find(&posts).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&posts).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch posts: %w", err)
 	}
 	return posts, nil
 }
 
-func (r *postRepository) FindByID(id uint) (*models.Post, error) {
+func (r *postRepository) List(ctx context.Context, query PostQuery) ([]models.Post, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	db := r.db.WithContext(ctx)
+	if columns := query.Columns(); columns != nil {
+		db = db.Select(columns)
+	}
+	if query.Includes(IncludeTags) {
+		db = preloadTags(db)
+	}
+	if len(query.IDs) > 0 {
+		db = db.Where("id IN ?", query.IDs)
+	}
+	if query.Published {
+		db = db.Where("draft = ?", false)
+	}
+
+	var posts []models.Post
+	if err := db.Order("id").Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+	if query.Includes(IncludeSeries) {
+		if err := r.loadSeries(ctx, posts); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
+}
+
+// loadSeries は投稿が属する連載を 1 回のクエリで読み込んで設定する
+func (r *postRepository) loadSeries(ctx context.Context, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	var rows []struct {
+		PostID   uint
+		ID       uint
+		Title    string
+		Position int
+	}
+	err := r.db.WithContext(ctx).
+		Table("series_entries").
+		// 何回目かは下書きを除いて数える
+		Select("series_entries.post_id, series.id, series.title, "+
+			"(SELECT COUNT(*) FROM series_entries AS e JOIN posts AS p ON p.id = e.post_id AND p.draft = ? "+
+			"WHERE e.series_id = series_entries.series_id AND e.position <= series_entries.position) AS position", false).
+		Joins("JOIN series ON series.id = series_entries.series_id AND series.deleted_at IS NULL").
+		Where("series_entries.post_id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch post series: %w", err)
+	}
+
+	byPost := make(map[uint]*models.PostSeries, len(rows))
+	for _, row := range rows {
+		byPost[row.PostID] = &models.PostSeries{ID: row.ID, Title: row.Title, Position: row.Position}
+	}
+	for i := range posts {
+		posts[i].Series = byPost[posts[i].ID]
+	}
+	return nil
+}
+
+func (r *postRepository) FindByID(ctx context.Context, id uint) (*models.Post, error) {
 	var post models.Post
-	if err := r.db.First(&post, id).Error; err != nil {
+	if err := preloadTags(r.db.WithContext(ctx)).First(&post, id).Error; err != nil {
 		return nil, fmt.Errorf("post not found: %w", err)
 	}
 	return &post, nil
 }
 
-func (r *postRepository) Create(post *models.Post) error {
-	if err := r.db.Create(post).Error; err != nil {
+func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	if err := r.db.WithContext(ctx).Create(post).Error; err != nil {
 		return fmt.Errorf("failed to create post: %w", err)
 	}
 	return nil
 }
 
-func (r *postRepository) Update(post *models.Post) error {
-	if err := r.db.Save(post).Error; err != nil {
+func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
+	// タグの付け替えは TagRepository.ReplacePostTags で行う
+	if err := r.db.WithContext(ctx).Omit("Tags").Save(post).Error; err != nil {
 		return fmt.Errorf("failed to update post: %w", err)
 	}
 	return nil
 }
 
-func (r *postRepository) Delete(post *models.Post) error {
-	if err := r.db.Delete(post).Error; err != nil {
+func (r *postRepository) Delete(ctx context.Context, post *models.Post) error {
+	if err := r.db.WithContext(ctx).Delete(post).Error; err != nil {
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
 	return nil
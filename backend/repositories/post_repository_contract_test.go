@@ -0,0 +1,52 @@
+package repositories_test
+
+import (
+	"blog/apitest"
+	"blog/database"
+	"blog/repositories"
+	"blog/repositories/repositorytest"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestMemoryPostRepository_Contract(t *testing.T) {
+	repositorytest.RunPostRepositoryContract(t, func(t *testing.T) repositories.PostRepository {
+		return repositories.NewMemoryPostRepository()
+	})
+}
+
+func TestSQLitePostRepository_Contract(t *testing.T) {
+	repositorytest.RunPostRepositoryContract(t, func(t *testing.T) repositories.PostRepository {
+		return repositories.NewPostRepository(setupSQLite(t))
+	})
+}
+
+// TEST_POSTGRES_DSN が設定されている場合のみ実行する
+func TestPostgresPostRepository_Contract(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	// サブテストごとに一意なスキーマを作るため、既存のテーブルにも他のサブテストのデータにも触れない。
+	// 外部キーで posts を参照するテーブルがあるため、TRUNCATE で空にする方法は使わない
+	repositorytest.RunPostRepositoryContract(t, func(t *testing.T) repositories.PostRepository {
+		db, err := gorm.Open(postgres.Open(apitest.IsolatePostgres(t, dsn)), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("Failed to connect to Postgres: %v", err)
+		}
+		// スキーマを削除する前に接続を閉じる
+		t.Cleanup(func() {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+		if err := database.Migrate(db); err != nil {
+			t.Fatalf("Failed to migrate Postgres: %v", err)
+		}
+		return repositories.NewPostRepository(db)
+	})
+}
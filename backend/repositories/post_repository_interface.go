@@ -1,11 +1,17 @@
 package repositories
 
-import "blog/models"
+import (
+	"blog/models"
+	"context"
+)
 
 type PostRepository interface {
-	FindAll() ([]models.Post, error)
-	FindByID(id uint) (*models.Post, error)
-	Create(post *models.Post) error
-	Update(post *models.Post) error
-	Delete(post *models.Post) error
+	FindAll(ctx context.Context) ([]models.Post, error)
+	// List は query で指定したフィールドと関連だけを読み込んだ投稿を ID 順に返す。
+	// 許可されていないフィールドや関連が含まれる場合は ErrInvalidQuery を返す。
+	List(ctx context.Context, query PostQuery) ([]models.Post, error)
+	FindByID(ctx context.Context, id uint) (*models.Post, error)
+	Create(ctx context.Context, post *models.Post) error
+	Update(ctx context.Context, post *models.Post) error
+	Delete(ctx context.Context, post *models.Post) error
 }
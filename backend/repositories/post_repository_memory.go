@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// memoryPostRepository はプロセス内のマップに投稿を保持する PostRepository。
+// テストや DB なしでのローカル開発に用いる。削除は GORM と同様に論理削除として扱う。
+type memoryPostRepository struct {
+	mu     sync.RWMutex
+	posts  map[uint]models.Post
+	nextID uint
+}
+
+func NewMemoryPostRepository() PostRepository {
+	return &memoryPostRepository{posts: make(map[uint]models.Post), nextID: 1}
+}
+
+func (r *memoryPostRepository) FindAll(ctx context.Context) ([]models.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	posts := make([]models.Post, 0, len(r.posts))
+	for _, post := range r.posts {
+		if !post.DeletedAt.Valid {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+	return posts, nil
+}
+
+// List は FindAll の結果から query のフィールドだけを残す。連載はメモリ実装では扱わないため読み込まない
+func (r *memoryPostRepository) List(ctx context.Context, query PostQuery) ([]models.Post, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	posts, err := r.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(query.IDs) > 0 {
+		posts = slices.DeleteFunc(posts, func(post models.Post) bool { return !slices.Contains(query.IDs, post.ID) })
+	}
+	if query.Published {
+		posts = slices.DeleteFunc(posts, func(post models.Post) bool { return post.Draft })
+	}
+	columns := query.Columns()
+	for i, post := range posts {
+		if !query.Includes(IncludeTags) {
+			post.Tags = nil
+		}
+		if columns != nil {
+			post = selectColumns(post, columns)
+		}
+		posts[i] = post
+	}
+	return posts, nil
+}
+
+// selectColumns は Select(columns) で読み込んだ場合と同じように、columns 以外のフィールドをゼロ値にする
+func selectColumns(post models.Post, columns []string) models.Post {
+	selected := models.Post{ID: post.ID, UpdatedAt: post.UpdatedAt, Tags: post.Tags, DeletedAt: post.DeletedAt}
+	for _, column := range columns {
+		switch column {
+		case "title":
+			selected.Title = post.Title
+		case "content":
+			selected.Content = post.Content
+		case "author":
+			selected.Author = post.Author
+		case "slug":
+			selected.Slug = post.Slug
+		case "description":
+			selected.Description = post.Description
+		case "cover_image":
+			selected.CoverImage = post.CoverImage
+		case "draft":
+			selected.Draft = post.Draft
+		case "metadata":
+			selected.Metadata = post.Metadata
+		case "word_count":
+			selected.WordCount = post.WordCount
+		case "char_count":
+			selected.CharCount = post.CharCount
+		case "reading_time_minutes":
+			selected.ReadingTimeMinutes = post.ReadingTimeMinutes
+		case "excerpt":
+			selected.Excerpt = post.Excerpt
+		case "created_at":
+			selected.CreatedAt = post.CreatedAt
+		case "updated_at":
+			selected.UpdatedAt = post.UpdatedAt
+		}
+	}
+	return selected
+}
+
+func (r *memoryPostRepository) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("post not found: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := r.posts[id]
+	if !ok || post.DeletedAt.Valid {
+		return nil, fmt.Errorf("post not found: %w", gorm.ErrRecordNotFound)
+	}
+	return &post, nil
+}
+
+func (r *memoryPostRepository) Create(ctx context.Context, post *models.Post) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if post.ID == 0 {
+		post.ID = r.nextID
+	} else if _, exists := r.posts[post.ID]; exists {
+		return fmt.Errorf("failed to create post: %w", gorm.ErrDuplicatedKey)
+	}
+	if post.ID >= r.nextID {
+		r.nextID = post.ID + 1
+	}
+
+	now := time.Now()
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = now
+	}
+	if post.UpdatedAt.IsZero() {
+		post.UpdatedAt = now
+	}
+	r.posts[post.ID] = *post
+	return nil
+}
+
+func (r *memoryPostRepository) Update(ctx context.Context, post *models.Post) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to update post: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// GORM の Save と同様に、存在しない場合は作成する
+	if post.ID == 0 {
+		post.ID = r.nextID
+	}
+	if post.ID >= r.nextID {
+		r.nextID = post.ID + 1
+	}
+	post.UpdatedAt = time.Now()
+	r.posts[post.ID] = *post
+	return nil
+}
+
+func (r *memoryPostRepository) Delete(ctx context.Context, post *models.Post) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.posts[post.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return nil
+	}
+	stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.posts[post.ID] = stored
+	return nil
+}
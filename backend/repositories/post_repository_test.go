@@ -3,6 +3,7 @@ package repositories_test
 import (
 	"blog/models"
 	"blog/repositories"
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -39,7 +40,7 @@ func TestFindAll(t *testing.T) {
 			AddRow(1, "Test Post 1", "Content 1", "Author 1", time.Now(), time.Now(), nil).
 			AddRow(2, "Test Post 2", "Content 2", "Author 2", time.Now(), time.Now(), nil))
 
-	posts, err := repo.FindAll()
+	posts, err := repo.FindAll(context.Background())
 	assert.NoError(t, err)
 	assert.Len(t, posts, 2)
 	assert.Equal(t, "Test Post 1", posts[0].Title)
@@ -56,7 +57,7 @@ func TestFindAll_Error(t *testing.T) {
 	mock.ExpectQuery(`SELECT \* FROM "posts"`).
 		WillReturnError(errors.New("database error"))
 
-	posts, err := repo.FindAll()
+	posts, err := repo.FindAll(context.Background())
 	assert.Error(t, err)
 	assert.Nil(t, posts)
 }
@@ -68,8 +69,11 @@ func TestFindByID(t *testing.T) {
 		WithArgs(1, 1). // `LIMIT $2` を考慮
 		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at", "deleted_at"}).
 			AddRow(1, "Test Post", "Test Content", "Test Author", time.Now(), time.Now(), nil))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
 
-	post, err := repo.FindByID(1)
+	post, err := repo.FindByID(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, post)
 	assert.Equal(t, "Test Post", post.Title)
@@ -84,7 +88,7 @@ func TestFindByID_NotFound(t *testing.T) {
 		WithArgs(999, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "title"})) // 空の結果
 
-	post, err := repo.FindByID(999)
+	post, err := repo.FindByID(context.Background(), 999)
 	assert.Error(t, err)
 	assert.Nil(t, post)
 }
@@ -94,14 +98,14 @@ func TestCreate(t *testing.T) {
 
 	mock.ExpectBegin() // トランザクション開始
 
-	mock.ExpectQuery(`INSERT INTO "posts" \("title","content","author","created_at","updated_at","deleted_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\) RETURNING "id"`).
-		WithArgs("New Post", "New Content", "New Author", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+	mock.ExpectQuery(`INSERT INTO "posts" \("title","content","author","slug","description","cover_image","draft","metadata","word_count","char_count","reading_time_minutes","excerpt","created_at","updated_at","deleted_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15\) RETURNING "id"`).
+		WithArgs("New Post", "New Content", "New Author", "", "", "", false, nil, 0, 0, 0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	mock.ExpectCommit() // トランザクションコミット
 
 	post := &models.Post{Title: "New Post", Content: "New Content", Author: "New Author"}
-	err := repo.Create(post)
+	err := repo.Create(context.Background(), post)
 	assert.NoError(t, err)
 }
 
@@ -112,13 +116,13 @@ func TestCreate_Error(t *testing.T) {
 
 	// データベースエラーを発生させる
 	mock.ExpectQuery(`INSERT INTO "posts"`).
-		WithArgs("New Post", "New Content", "New Author", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WithArgs("New Post", "New Content", "New Author", "", "", "", false, nil, 0, 0, 0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
 		WillReturnError(errors.New("failed to insert post"))
 
 	mock.ExpectRollback()
 
 	post := &models.Post{Title: "New Post", Content: "New Content", Author: "New Author"}
-	err := repo.Create(post)
+	err := repo.Create(context.Background(), post)
 	assert.Error(t, err)
 }
 
@@ -127,14 +131,14 @@ func TestUpdate(t *testing.T) {
 
 	mock.ExpectBegin() // トランザクション開始
 
-	mock.ExpectExec(`UPDATE "posts" SET "title"=\$1,"content"=\$2,"author"=\$3,"created_at"=\$4,"updated_at"=\$5,"deleted_at"=\$6 WHERE "posts"."deleted_at" IS NULL AND "id" = \$7`).
-		WithArgs("Updated Post", "Updated Content", "Updated Author", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1).
+	mock.ExpectExec(`UPDATE "posts" SET "title"=\$1,"content"=\$2,"author"=\$3,"slug"=\$4,"description"=\$5,"cover_image"=\$6,"draft"=\$7,"metadata"=\$8,"word_count"=\$9,"char_count"=\$10,"reading_time_minutes"=\$11,"excerpt"=\$12,"created_at"=\$13,"updated_at"=\$14,"deleted_at"=\$15 WHERE "posts"."deleted_at" IS NULL AND "id" = \$16`).
+		WithArgs("Updated Post", "Updated Content", "Updated Author", "", "", "", false, nil, 0, 0, 0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectCommit() // トランザクションコミット
 
 	post := &models.Post{ID: 1, Title: "Updated Post", Content: "Updated Content", Author: "Updated Author"}
-	err := repo.Update(post)
+	err := repo.Update(context.Background(), post)
 	assert.NoError(t, err)
 }
 
@@ -145,13 +149,13 @@ func TestUpdate_Error(t *testing.T) {
 
 	// データベースエラーを発生させる
 	mock.ExpectExec(`UPDATE "posts"`).
-		WithArgs("Updated Post", "Updated Content", "Updated Author", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1).
+		WithArgs("Updated Post", "Updated Content", "Updated Author", "", "", "", false, nil, 0, 0, 0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1).
 		WillReturnError(errors.New("failed to update post"))
 
 	mock.ExpectRollback()
 
 	post := &models.Post{ID: 1, Title: "Updated Post", Content: "Updated Content", Author: "Updated Author"}
-	err := repo.Update(post)
+	err := repo.Update(context.Background(), post)
 	assert.Error(t, err)
 }
 
@@ -167,7 +171,7 @@ func TestDelete(t *testing.T) {
 	mock.ExpectCommit() // トランザクションコミット
 
 	post := &models.Post{ID: 1}
-	err := repo.Delete(post)
+	err := repo.Delete(context.Background(), post)
 	assert.NoError(t, err)
 }
 
@@ -184,6 +188,6 @@ func TestDelete_Error(t *testing.T) {
 	mock.ExpectRollback()
 
 	post := &models.Post{ID: 1}
-	err := repo.Delete(post)
+	err := repo.Delete(context.Background(), post)
 	assert.Error(t, err)
 }
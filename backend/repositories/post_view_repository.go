@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postViewRepository struct {
+	db *gorm.DB
+}
+
+func NewPostViewRepository(db *gorm.DB) PostViewRepository {
+	return &postViewRepository{db: db}
+}
+
+func (r *postViewRepository) Increment(ctx context.Context, views []models.PostView) error {
+	if len(views) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]any{"views": gorm.Expr("post_views.views + excluded.views")}),
+		}).
+		Create(&views).Error
+	if err != nil {
+		return fmt.Errorf("failed to record post views: %w", err)
+	}
+	return nil
+}
+
+func (r *postViewRepository) FindPopular(ctx context.Context, since string, limit int) ([]models.PopularPost, error) {
+	var popular []models.PopularPost
+	err := r.db.WithContext(ctx).
+		Table("post_views").
+		Select("posts.id, posts.title, posts.author, posts.created_at, posts.updated_at, SUM(post_views.views) AS views").
+		Joins("JOIN posts ON posts.id = post_views.post_id AND posts.deleted_at IS NULL AND posts.draft = ?", false).
+		Where("post_views.day >= ?", since).
+		Group("posts.id, posts.title, posts.author, posts.created_at, posts.updated_at").
+		Order("views DESC, posts.id").
+		Limit(limit).
+		Scan(&popular).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch popular posts: %w", err)
+	}
+	return popular, nil
+}
+
+func (r *postViewRepository) FindByPostID(ctx context.Context, postID uint, since string) ([]models.PostView, error) {
+	var views []models.PostView
+	err := r.db.WithContext(ctx).
+		Where("post_id = ? AND day >= ?", postID, since).
+		Order("day").
+		Find(&views).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch post views: %w", err)
+	}
+	return views, nil
+}
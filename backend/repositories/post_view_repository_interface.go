@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+)
+
+type PostViewRepository interface {
+	// Increment は投稿ごと・日ごとの閲覧数を加算する
+	Increment(ctx context.Context, views []models.PostView) error
+	// FindPopular は since (その日を含む) 以降の閲覧数が多い順に、削除済みと下書きを除いた投稿を最大 limit 件返す
+	FindPopular(ctx context.Context, since string, limit int) ([]models.PopularPost, error)
+	// FindByPostID は since (その日を含む) 以降の投稿の日別の閲覧数を日付順に返す
+	FindByPostID(ctx context.Context, postID uint, since string) ([]models.PostView, error)
+}
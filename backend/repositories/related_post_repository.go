@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type relatedPostRepository struct {
+	db *gorm.DB
+}
+
+func NewRelatedPostRepository(db *gorm.DB) RelatedPostRepository {
+	return &relatedPostRepository{db: db}
+}
+
+// publishedRelated は関連記事として結合する投稿の列。保存後に下書きに戻された投稿はジョブが再計算するまでの間も返さない
+func publishedRelated(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "title", "author", "created_at", "updated_at").Where(map[string]any{"draft": false})
+}
+
+func (r *relatedPostRepository) FindByPostID(ctx context.Context, postID uint, limit int) ([]models.RelatedPost, error) {
+	var related []models.RelatedPost
+	err := r.db.WithContext(ctx).
+		InnerJoins("Related", publishedRelated(r.db)).
+		Where("related_posts.post_id = ?", postID).
+		Order("related_posts.rank").
+		Limit(limit).
+		Find(&related).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch related posts: %w", err)
+	}
+	return related, nil
+}
+
+func (r *relatedPostRepository) FindByPostIDs(ctx context.Context, postIDs []uint, limit int) (map[uint][]models.RelatedPost, error) {
+	var rows []models.RelatedPost
+	err := r.db.WithContext(ctx).
+		InnerJoins("Related", publishedRelated(r.db)).
+		Where("related_posts.post_id IN ? AND related_posts.rank <= ?", postIDs, limit).
+		Order("related_posts.post_id, related_posts.rank").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch related posts: %w", err)
+	}
+	result := make(map[uint][]models.RelatedPost, len(postIDs))
+	for _, row := range rows {
+		result[row.PostID] = append(result[row.PostID], row)
+	}
+	return result, nil
+}
+
+func (r *relatedPostRepository) FindAll(ctx context.Context) (map[uint][]models.RelatedPost, error) {
+	var rows []models.RelatedPost
+	if err := r.db.WithContext(ctx).Order("post_id, rank").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch related posts: %w", err)
+	}
+	result := make(map[uint][]models.RelatedPost)
+	for _, row := range rows {
+		result[row.PostID] = append(result[row.PostID], row)
+	}
+	return result, nil
+}
+
+func (r *relatedPostRepository) ReplaceForPost(ctx context.Context, postID uint, related []models.RelatedPost) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("post_id = ?", postID).Delete(&models.RelatedPost{}).Error; err != nil {
+		return fmt.Errorf("failed to clear related posts: %w", err)
+	}
+	if len(related) == 0 {
+		return nil
+	}
+	if err := db.Omit("Related").Create(&related).Error; err != nil {
+		return fmt.Errorf("failed to create related posts: %w", err)
+	}
+	return nil
+}
+
+func (r *relatedPostRepository) DeleteForPost(ctx context.Context, postID uint) error {
+	err := r.db.WithContext(ctx).
+		Where("post_id = ? OR related_post_id = ?", postID, postID).
+		Delete(&models.RelatedPost{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete related posts: %w", err)
+	}
+	return nil
+}
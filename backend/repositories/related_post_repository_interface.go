@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+)
+
+type RelatedPostRepository interface {
+	// FindByPostID は投稿の関連記事を Rank 順に最大 limit 件、削除済みの投稿を除いて返す
+	FindByPostID(ctx context.Context, postID uint, limit int) ([]models.RelatedPost, error)
+	// FindByPostIDs は複数の投稿の関連記事を投稿ごとに Rank 順で、Rank が limit 以下のものを削除済みの投稿を除いて返す
+	FindByPostIDs(ctx context.Context, postIDs []uint, limit int) (map[uint][]models.RelatedPost, error)
+	// FindAll は保存されている全ての関連記事を投稿ごとに Rank 順で返す
+	FindAll(ctx context.Context) (map[uint][]models.RelatedPost, error)
+	// ReplaceForPost は投稿の関連記事を related に置き換える
+	ReplaceForPost(ctx context.Context, postID uint, related []models.RelatedPost) error
+	// DeleteForPost は投稿の関連記事と、他の投稿の関連記事に含まれるこの投稿を削除する
+	DeleteForPost(ctx context.Context, postID uint) error
+}
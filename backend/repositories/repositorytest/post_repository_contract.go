@@ -0,0 +1,194 @@
+// Package repositorytest は PostRepository の各実装が満たすべき振る舞いを共通のテストとして提供する。
+package repositorytest
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// PostRepositoryFactory は空の PostRepository を生成する
+type PostRepositoryFactory func(t *testing.T) repositories.PostRepository
+
+// RunPostRepositoryContract は PostRepository の契約テストを実行する。
+// メモリ・SQLite・Postgres の各実装のテストから呼び出す。
+func RunPostRepositoryContract(t *testing.T, newRepo PostRepositoryFactory) {
+	t.Run("CreateAssignsIDAndFindByIDReturnsPost", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		post := &models.Post{Title: "New Post", Content: "New Content", Author: "New Author"}
+		require.NoError(t, repo.Create(ctx, post))
+		assert.NotZero(t, post.ID)
+
+		found, err := repo.FindByID(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "New Post", found.Title)
+		assert.Equal(t, "New Content", found.Content)
+		assert.Equal(t, "New Author", found.Author)
+		assert.WithinDuration(t, time.Now(), found.CreatedAt, time.Minute)
+	})
+
+	t.Run("CreateAssignsDistinctIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		first := &models.Post{Title: "First"}
+		second := &models.Post{Title: "Second"}
+		require.NoError(t, repo.Create(ctx, first))
+		require.NoError(t, repo.Create(ctx, second))
+		assert.NotEqual(t, first.ID, second.ID)
+	})
+
+	t.Run("FindByIDNotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		post, err := repo.FindByID(context.Background(), 999)
+		assert.Nil(t, post)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "expected gorm.ErrRecordNotFound, got %v", err)
+	})
+
+	t.Run("FindAllReturnsAllPosts", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		posts, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, posts)
+
+		for _, title := range []string{"Post 1", "Post 2", "Post 3"} {
+			require.NoError(t, repo.Create(ctx, &models.Post{Title: title}))
+		}
+
+		posts, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		titles := make([]string, 0, len(posts))
+		for _, p := range posts {
+			titles = append(titles, p.Title)
+		}
+		assert.ElementsMatch(t, []string{"Post 1", "Post 2", "Post 3"}, titles)
+	})
+
+	t.Run("ListSelectsFields", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		post := &models.Post{Title: "Title", Content: "Content", Author: "Author"}
+		require.NoError(t, repo.Create(ctx, post))
+
+		posts, err := repo.List(ctx, repositories.PostQuery{Fields: []string{"title"}, Include: []string{repositories.IncludeAuthor}})
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, post.ID, posts[0].ID)
+		assert.Equal(t, "Title", posts[0].Title)
+		assert.Equal(t, "Author", posts[0].Author)
+		assert.Empty(t, posts[0].Content)
+		assert.True(t, posts[0].CreatedAt.IsZero())
+
+		posts, err = repo.List(ctx, repositories.PostQuery{})
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, "Content", posts[0].Content)
+	})
+
+	t.Run("ListFiltersByIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		var ids []uint
+		for _, title := range []string{"Post 1", "Post 2", "Post 3"} {
+			post := &models.Post{Title: title}
+			require.NoError(t, repo.Create(ctx, post))
+			ids = append(ids, post.ID)
+		}
+
+		posts, err := repo.List(ctx, repositories.PostQuery{IDs: []uint{ids[2], ids[0], 999}})
+		require.NoError(t, err)
+		require.Len(t, posts, 2)
+		assert.Equal(t, "Post 1", posts[0].Title)
+		assert.Equal(t, "Post 3", posts[1].Title)
+	})
+
+	t.Run("ListExcludesDrafts", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		require.NoError(t, repo.Create(ctx, &models.Post{Title: "Published"}))
+		require.NoError(t, repo.Create(ctx, &models.Post{Title: "Draft", Draft: true}))
+
+		posts, err := repo.List(ctx, repositories.PostQuery{Fields: []string{"title"}, Published: true})
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, "Published", posts[0].Title)
+
+		posts, err = repo.List(ctx, repositories.PostQuery{})
+		require.NoError(t, err)
+		assert.Len(t, posts, 2)
+	})
+
+	t.Run("ListRejectsUnknownFields", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, query := range []repositories.PostQuery{
+			{Fields: []string{"title; DROP TABLE posts"}},
+			{Fields: []string{"deleted_at"}},
+			{Include: []string{"comments"}},
+		} {
+			_, err := repo.List(context.Background(), query)
+			assert.ErrorIs(t, err, repositories.ErrInvalidQuery)
+		}
+	})
+
+	t.Run("UpdatePersistsChanges", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		post := &models.Post{Title: "Old Title", Content: "Old Content"}
+		require.NoError(t, repo.Create(ctx, post))
+
+		post.Title = "New Title"
+		post.Content = "New Content"
+		require.NoError(t, repo.Update(ctx, post))
+
+		found, err := repo.FindByID(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "New Title", found.Title)
+		assert.Equal(t, "New Content", found.Content)
+	})
+
+	t.Run("DeleteHidesPost", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		kept := &models.Post{Title: "Kept"}
+		deleted := &models.Post{Title: "Deleted"}
+		require.NoError(t, repo.Create(ctx, kept))
+		require.NoError(t, repo.Create(ctx, deleted))
+
+		require.NoError(t, repo.Delete(ctx, deleted))
+
+		_, err := repo.FindByID(ctx, deleted.ID)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "expected gorm.ErrRecordNotFound, got %v", err)
+
+		posts, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, kept.ID, posts[0].ID)
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repo.FindAll(ctx)
+		assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+	})
+}
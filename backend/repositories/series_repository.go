@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type seriesRepository struct {
+	db *gorm.DB
+}
+
+func NewSeriesRepository(db *gorm.DB) SeriesRepository {
+	return &seriesRepository{db: db}
+}
+
+// preloadEntries は公開済みの各回を Position 順に、投稿を本文なしで読み込む。下書きの回は含めない
+func preloadEntries(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Entries", func(db *gorm.DB) *gorm.DB {
+			return db.Where("post_id IN (SELECT id FROM posts WHERE draft = ?)", false).Order("position")
+		}).
+		Preload("Entries.Post", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "author", "created_at", "updated_at")
+		})
+}
+
+func (r *seriesRepository) FindAll(ctx context.Context) ([]models.Series, error) {
+	var series []models.Series
+	if err := r.db.WithContext(ctx).Order("id").Find(&series).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch series: %w", err)
+	}
+	return series, nil
+}
+
+func (r *seriesRepository) FindByID(ctx context.Context, id uint) (*models.Series, error) {
+	var series models.Series
+	if err := preloadEntries(r.db.WithContext(ctx)).First(&series, id).Error; err != nil {
+		return nil, fmt.Errorf("series not found: %w", err)
+	}
+	// 下書きを除いた後の順番に振り直す
+	for i := range series.Entries {
+		series.Entries[i].Position = i + 1
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) FindByPostID(ctx context.Context, postID uint) (*models.Series, error) {
+	var entry models.SeriesEntry
+	if err := r.db.WithContext(ctx).Where("post_id = ?", postID).First(&entry).Error; err != nil {
+		return nil, fmt.Errorf("series not found: %w", err)
+	}
+	return r.FindByID(ctx, entry.SeriesID)
+}
+
+func (r *seriesRepository) Create(ctx context.Context, series *models.Series) error {
+	if err := r.db.WithContext(ctx).Omit("Entries").Create(series).Error; err != nil {
+		return fmt.Errorf("failed to create series: %w", err)
+	}
+	return nil
+}
+
+func (r *seriesRepository) Update(ctx context.Context, series *models.Series) error {
+	if err := r.db.WithContext(ctx).Omit("Entries").Save(series).Error; err != nil {
+		return fmt.Errorf("failed to update series: %w", err)
+	}
+	return nil
+}
+
+func (r *seriesRepository) Delete(ctx context.Context, series *models.Series) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("series_id = ?", series.ID).Delete(&models.SeriesEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to delete series entries: %w", err)
+	}
+	if err := db.Delete(series).Error; err != nil {
+		return fmt.Errorf("failed to delete series: %w", err)
+	}
+	return nil
+}
+
+func (r *seriesRepository) ReplaceEntries(ctx context.Context, seriesID uint, postIDs []uint) error {
+	db := r.db.WithContext(ctx)
+
+	query := db.Where("series_id = ?", seriesID)
+	if len(postIDs) > 0 {
+		query = query.Or("post_id IN ?", postIDs)
+	}
+	if err := query.Delete(&models.SeriesEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to clear series entries: %w", err)
+	}
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	entries := make([]models.SeriesEntry, len(postIDs))
+	for i, postID := range postIDs {
+		entries[i] = models.SeriesEntry{SeriesID: seriesID, PostID: postID, Position: i + 1}
+	}
+	if err := db.Omit("Post").Create(&entries).Error; err != nil {
+		return fmt.Errorf("failed to create series entries: %w", err)
+	}
+	return nil
+}
+
+func (r *seriesRepository) RemovePost(ctx context.Context, postID uint) error {
+	if err := r.db.WithContext(ctx).Where("post_id = ?", postID).Delete(&models.SeriesEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to remove post from series: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+)
+
+type SeriesRepository interface {
+	FindAll(ctx context.Context) ([]models.Series, error)
+	// FindByID は Position 順の Entries と各回の投稿を含めて返す。
+	// Entries には公開済みの回だけを含め、Position は公開済みの回の中での順番にする
+	FindByID(ctx context.Context, id uint) (*models.Series, error)
+	// FindByPostID は投稿が属する連載を返す。属していない場合は ErrNotFound を返す
+	FindByPostID(ctx context.Context, postID uint) (*models.Series, error)
+	Create(ctx context.Context, series *models.Series) error
+	Update(ctx context.Context, series *models.Series) error
+	Delete(ctx context.Context, series *models.Series) error
+	// ReplaceEntries は連載の各回を postIDs の順に置き換える。他の連載に属していた投稿は移動する
+	ReplaceEntries(ctx context.Context, seriesID uint, postIDs []uint) error
+	// RemovePost は投稿を連載から外す
+	RemovePost(ctx context.Context, postID uint) error
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) FindOrCreate(ctx context.Context, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return []models.Tag{}, nil
+	}
+	db := r.db.WithContext(ctx)
+
+	// 同時に同じタグを作成しても一意制約で失敗しないようにする
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+	if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	var found []models.Tag
+	if err := db.Where("name IN ?", names).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	byName := make(map[string]models.Tag, len(found))
+	for _, tag := range found {
+		byName[tag.Name] = tag
+	}
+
+	// 指定された順序で返す
+	result := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		if tag, ok := byName[name]; ok {
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+func (r *tagRepository) FindByPostIDs(ctx context.Context, postIDs []uint) (map[uint][]models.Tag, error) {
+	result := make(map[uint][]models.Tag, len(postIDs))
+	if len(postIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		models.Tag
+	}
+	err := r.db.WithContext(ctx).
+		Table("post_tags").
+		Select("post_tags.post_id, tags.id, tags.name").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("post_tags.post_id IN ?", postIDs).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch post tags: %w", err)
+	}
+	for _, row := range rows {
+		result[row.PostID] = append(result[row.PostID], row.Tag)
+	}
+	return result, nil
+}
+
+func (r *tagRepository) ReplacePostTags(ctx context.Context, post *models.Post, tags []models.Tag) error {
+	if err := r.db.WithContext(ctx).Model(post).Association("Tags").Replace(tags); err != nil {
+		return fmt.Errorf("failed to replace post tags: %w", err)
+	}
+	post.Tags = tags
+	return nil
+}
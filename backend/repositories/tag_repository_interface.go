@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+)
+
+type TagRepository interface {
+	// FindOrCreate は names のタグを返す。存在しないタグは作成する。names は正規化済みであること
+	FindOrCreate(ctx context.Context, names []string) ([]models.Tag, error)
+	// FindByPostIDs は投稿ごとのタグを名前順で返す
+	FindByPostIDs(ctx context.Context, postIDs []uint) (map[uint][]models.Tag, error)
+	// ReplacePostTags は投稿のタグを tags に置き換える
+	ReplacePostTags(ctx context.Context, post *models.Post, tags []models.Tag) error
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories はユニットオブワークが提供するリポジトリの集合。
+// トランザクション内で受け取ったリポジトリは全て同じトランザクションで動作する。
+type Repositories struct {
+	Posts  PostRepository
+	Series SeriesRepository
+	Tags   TagRepository
+	// Related は投稿の関連記事
+	Related RelatedPostRepository
+	// Views は投稿の日別の閲覧数
+	Views PostViewRepository
+	// Imports は他のブログから取り込んだ投稿と取り込み元の ID の対応
+	Imports ImportRepository
+	// Backup は全てのデータのバックアップと復元
+	Backup BackupRepository
+	// Webhooks は Webhook の送信先と送信待ちのイベント
+	Webhooks WebhookRepository
+}
+
+// UnitOfWork は複数のリポジトリにまたがる操作をアトミックに実行する
+type UnitOfWork interface {
+	// Repositories はトランザクション外で使うリポジトリを返す
+	Repositories() Repositories
+	// Do は fn をトランザクション内で実行する。
+	// fn がエラーを返すか panic した場合はロールバックし、それ以外はコミットする。
+	// fn の中で渡された ctx を使って Do を呼ぶと、SAVEPOINT によるネストしたトランザクションになる。
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
+
+type txContextKey struct{}
+
+type gormUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &gormUnitOfWork{db: db}
+}
+
+func (u *gormUnitOfWork) Repositories() Repositories {
+	return newRepositories(u.db)
+}
+
+func (u *gormUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	db := u.db
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		// 外側のトランザクションがあれば GORM が SAVEPOINT を使う
+		db = tx
+	}
+
+	// panic 時も GORM の Transaction がロールバックしてから panic を再送出する
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(ctx, txContextKey{}, tx)
+		return fn(txCtx, newRepositories(tx))
+	})
+}
+
+func newRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Posts:    NewPostRepository(db),
+		Series:   NewSeriesRepository(db),
+		Tags:     NewTagRepository(db),
+		Related:  NewRelatedPostRepository(db),
+		Views:    NewPostViewRepository(db),
+		Imports:  NewImportRepository(db),
+		Backup:   NewBackupRepository(db),
+		Webhooks: NewWebhookRepository(db),
+	}
+}
+
+// nonTransactionalUnitOfWork はトランザクションを持たないリポジトリ (メモリ実装やモック) 向けの UnitOfWork。
+// fn をそのまま実行するため、アトミック性は保証しない。
+type nonTransactionalUnitOfWork struct {
+	repos Repositories
+}
+
+func NewNonTransactionalUnitOfWork(repos Repositories) UnitOfWork {
+	return &nonTransactionalUnitOfWork{repos: repos}
+}
+
+func (u *nonTransactionalUnitOfWork) Repositories() Repositories {
+	return u.repos
+}
+
+func (u *nonTransactionalUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	return fn(ctx, u.repos)
+}
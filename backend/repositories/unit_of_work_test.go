@@ -0,0 +1,104 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/database"
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupSQLite(t *testing.T) *gorm.DB {
+	db, err := database.Open(config.DatabaseConfig{Driver: "sqlite", Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate SQLite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func countPosts(t *testing.T, uow repositories.UnitOfWork) int {
+	posts, err := uow.Repositories().Posts.FindAll(context.Background())
+	require.NoError(t, err)
+	return len(posts)
+}
+
+func TestUnitOfWork_Commit(t *testing.T) {
+	uow := repositories.NewUnitOfWork(setupSQLite(t))
+
+	err := uow.Do(context.Background(), func(ctx context.Context, repos repositories.Repositories) error {
+		if err := repos.Posts.Create(ctx, &models.Post{Title: "First"}); err != nil {
+			return err
+		}
+		return repos.Posts.Create(ctx, &models.Post{Title: "Second"})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, countPosts(t, uow))
+}
+
+func TestUnitOfWork_RollbackOnError(t *testing.T) {
+	uow := repositories.NewUnitOfWork(setupSQLite(t))
+	errFailed := errors.New("failed")
+
+	err := uow.Do(context.Background(), func(ctx context.Context, repos repositories.Repositories) error {
+		if err := repos.Posts.Create(ctx, &models.Post{Title: "First"}); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, 0, countPosts(t, uow))
+}
+
+func TestUnitOfWork_RollbackOnPanic(t *testing.T) {
+	uow := repositories.NewUnitOfWork(setupSQLite(t))
+
+	assert.Panics(t, func() {
+		_ = uow.Do(context.Background(), func(ctx context.Context, repos repositories.Repositories) error {
+			if err := repos.Posts.Create(ctx, &models.Post{Title: "First"}); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 0, countPosts(t, uow))
+}
+
+func TestUnitOfWork_NestedSavepoint(t *testing.T) {
+	uow := repositories.NewUnitOfWork(setupSQLite(t))
+
+	err := uow.Do(context.Background(), func(ctx context.Context, repos repositories.Repositories) error {
+		if err := repos.Posts.Create(ctx, &models.Post{Title: "Outer"}); err != nil {
+			return err
+		}
+
+		// 内側の失敗は SAVEPOINT までロールバックされ、外側の書き込みは残る
+		inner := uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+			if err := repos.Posts.Create(ctx, &models.Post{Title: "Inner"}); err != nil {
+				return err
+			}
+			return errors.New("inner failed")
+		})
+		assert.Error(t, inner)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	posts, err := uow.Repositories().Posts.FindAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "Outer", posts[0].Title)
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) FindAllSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.WithContext(ctx).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) FindSubscriptionByID(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.db.WithContext(ctx).First(&subscription, id).Error; err != nil {
+		return nil, fmt.Errorf("webhook subscription not found: %w", err)
+	}
+	return &subscription, nil
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	// Active の既定値 (true) で false を上書きしないよう、全ての列を指定する
+	if err := r.db.WithContext(ctx).Select("*").Omit("ID").Create(subscription).Error; err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	if err := r.db.WithContext(ctx).Save(subscription).Error; err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&models.WebhookDelivery{}).Select("id").Where("subscription_id = ?", subscription.ID)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookDeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", subscription.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(subscription).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) Enqueue(ctx context.Context, event *models.WebhookEvent) error {
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.WithContext(ctx).Where("active = ?", true).Order("id").Find(&subscriptions).Error; err != nil {
+		return fmt.Errorf("failed to fetch webhook subscriptions: %w", err)
+	}
+	var deliveries []models.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if subscription.Accepts(event.Type) {
+			deliveries = append(deliveries, models.WebhookDelivery{
+				SubscriptionID: subscription.ID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				Status:         models.WebhookPending,
+				NextAttemptAt:  event.CreatedAt,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Event").Create(&deliveries).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.WebhookDelivery{}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+			Order("next_attempt_at").Order("id").
+			Limit(limit)
+		if tx.Dialector.Name() == "postgres" {
+			// 複数のレプリカが同じ配信を取り出さないよう、他のトランザクションがロックした行は飛ばす
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var ids []uint
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).UpdateColumn("next_attempt_at", now.Add(lease)).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Event").Order("next_attempt_at").Order("id").Find(&deliveries, ids).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookDeliveryAttempt) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attempt.DeliveryID = delivery.ID
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(delivery).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) FindDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) FindDeliveryByID(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Where("subscription_id = ?", subscriptionID).
+		First(&delivery, deliveryID).Error
+	if err != nil {
+		return nil, fmt.Errorf("webhook delivery not found: %w", err)
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Save(delivery).Error; err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"blog/models"
+	"context"
+	"time"
+)
+
+// WebhookRepository は Webhook の送信先と、送信待ちのイベント (outbox) と配信を保存する
+type WebhookRepository interface {
+	FindAllSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	FindSubscriptionByID(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	// DeleteSubscription は送信先と、その配信と配信ログを削除する
+	DeleteSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+
+	// Enqueue はイベントを保存し、イベントの種類を通知する有効な送信先ごとに配信を作る。
+	// 送信先がない場合は何も保存しない
+	Enqueue(ctx context.Context, event *models.WebhookEvent) error
+	// ClaimDue は送信する時刻になった配信を limit 件まで取り出し、lease の間は他のワーカーが取り出さないようにする。
+	// 配信は Event を含む
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// RecordAttempt は送信の記録を保存し、配信の状態を更新する
+	RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookDeliveryAttempt) error
+
+	// FindDeliveries は送信先の配信を新しい順に limit 件まで返す
+	FindDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error)
+	// FindDeliveryByID は送信先の配信を配信ログ (古い順) を含めて返す
+	FindDeliveryByID(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error)
+	// UpdateDelivery は配信の状態を保存する
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
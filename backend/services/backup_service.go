@@ -0,0 +1,50 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type backupService struct {
+	uow repositories.UnitOfWork
+}
+
+func NewBackupService(uow repositories.UnitOfWork) BackupService {
+	return &backupService{uow: uow}
+}
+
+func (s *backupService) Export(ctx context.Context) (*models.Backup, error) {
+	if s.uow.Repositories().Backup == nil {
+		return nil, errors.New("backup is not supported by this repository")
+	}
+	var backup *models.Backup
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		var err error
+		backup, err = repos.Backup.Dump(ctx)
+		return err
+	})
+	return backup, err
+}
+
+func (s *backupService) Restore(ctx context.Context, backup *models.Backup) error {
+	if s.uow.Repositories().Backup == nil {
+		return errors.New("backup is not supported by this repository")
+	}
+	// 語数などはバックアップに含めないため本文から計算し直す
+	for i := range backup.Posts {
+		applyTextStats(&backup.Posts[i])
+	}
+	return s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		empty, err := repos.Backup.IsEmpty(ctx)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return fmt.Errorf("%w: the database is not empty", ErrInvalidInput)
+		}
+		return repos.Backup.Restore(ctx, backup)
+	})
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"blog/models"
+	"context"
+)
+
+type BackupService interface {
+	// Export は全てのデータを 1 つのトランザクションで読み込んで返す
+	Export(ctx context.Context) (*models.Backup, error)
+	// Restore は backup を ID を保ったまま復元する。
+	// データベースに投稿・タグ・連載が 1 件でもある場合は ErrInvalidInput を返す。
+	Restore(ctx context.Context, backup *models.Backup) error
+}
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"blog/repositories"
+	"errors"
+)
+
+var (
+	// ErrNotFound は対象が存在しないことを表す
+	ErrNotFound = repositories.ErrNotFound
+	// ErrInvalidInput はリクエストの内容が不正であることを表す
+	ErrInvalidInput = errors.New("invalid input")
+)
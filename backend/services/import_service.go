@@ -0,0 +1,247 @@
+package services
+
+import (
+	"blog/importer"
+	"blog/metrics"
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxTitleLength と maxAuthorLength は models.Post のカラム長
+	maxTitleLength  = 255
+	maxAuthorLength = 100
+	// maxSourceIDLength は models.ImportedPost.SourceID のカラム長
+	maxSourceIDLength = 255
+)
+
+type importService struct {
+	uow       repositories.UnitOfWork
+	listeners []PostEventListener
+}
+
+// NewImportService は ImportService を生成する。
+// 取り込みは PostService を経由しないため、作成・更新した投稿は listeners に PostEvent として通知する。
+func NewImportService(uow repositories.UnitOfWork, listeners ...PostEventListener) ImportService {
+	return &importService{uow: uow, listeners: listeners}
+}
+
+func (s *importService) Import(ctx context.Context, source importer.Format, entries []importer.Entry, dryRun bool) (*models.ImportReport, error) {
+	if s.uow.Repositories().Imports == nil {
+		return nil, errors.New("import is not supported by this repository")
+	}
+
+	report := &models.ImportReport{Source: string(source), DryRun: dryRun, Results: make([]models.ImportResult, 0, len(entries))}
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		var result models.ImportResult
+		switch {
+		case entry.Skip != "":
+			result = models.ImportResult{SourceID: entry.SourceID, Title: entry.Title, Action: models.ImportSkipped, Reason: entry.Skip}
+		case seen[entry.SourceID]:
+			result = models.ImportResult{SourceID: entry.SourceID, Title: entry.Title, Action: models.ImportFailed, Reason: "duplicate source ID"}
+		default:
+			result = s.importEntry(ctx, source, entry, dryRun)
+		}
+		seen[entry.SourceID] = true
+
+		report.Results = append(report.Results, result)
+		switch result.Action {
+		case models.ImportCreated:
+			report.Created++
+		case models.ImportUpdated:
+			report.Updated++
+		case models.ImportUnchanged:
+			report.Unchanged++
+		case models.ImportSkipped:
+			report.Skipped++
+		case models.ImportFailed:
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// importEntry は 1 件の投稿を 1 つのトランザクションで取り込む
+func (s *importService) importEntry(ctx context.Context, source importer.Format, entry importer.Entry, dryRun bool) models.ImportResult {
+	result := models.ImportResult{SourceID: entry.SourceID, Title: entry.Title}
+	fail := func(err error) models.ImportResult {
+		result.Action, result.PostID, result.Reason = models.ImportFailed, 0, err.Error()
+		return result
+	}
+
+	if err := validateEntry(entry); err != nil {
+		return fail(err)
+	}
+	tags := make([]models.Tag, len(entry.Tags))
+	for i, name := range entry.Tags {
+		tags[i] = models.Tag{Name: name}
+	}
+	names, err := normalizeTagNames(tags)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		imported, err := repos.Imports.Find(ctx, string(source), entry.SourceID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			result.Action = models.ImportCreated
+			if dryRun {
+				return nil
+			}
+			post, err := createImportedPost(ctx, repos, entry, names)
+			if err != nil {
+				return err
+			}
+			result.PostID = post.ID
+			// 取り込んだ投稿は下書きにしないため、作成と同時に公開される
+			if err := enqueueWebhooks(ctx, repos, post, PostCreated, PostPublished); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else {
+			post, err := repos.Posts.FindByID(ctx, imported.PostID)
+			if errors.Is(err, repositories.ErrNotFound) {
+				// 取り込んだ後に削除した投稿は復活させない
+				result.Action, result.Reason = models.ImportSkipped, fmt.Sprintf("post %d was deleted after a previous import", imported.PostID)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			result.PostID = post.ID
+			if importedPostUnchanged(post, entry, names) {
+				result.Action = models.ImportUnchanged
+				return nil
+			}
+			result.Action = models.ImportUpdated
+			if dryRun {
+				return nil
+			}
+			if err := updateImportedPost(ctx, repos, post, entry, names); err != nil {
+				return err
+			}
+			if err := enqueueWebhooks(ctx, repos, post, PostUpdated); err != nil {
+				return err
+			}
+		}
+		return repos.Imports.Save(ctx, &models.ImportedPost{
+			Source:     string(source),
+			SourceID:   entry.SourceID,
+			PostID:     result.PostID,
+			ImportedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	if !dryRun {
+		switch result.Action {
+		case models.ImportCreated:
+			metrics.PostsCreatedTotal.Inc()
+			s.notify(ctx, PostEvent{Type: PostCreated, PostID: result.PostID})
+		case models.ImportUpdated:
+			s.notify(ctx, PostEvent{Type: PostUpdated, PostID: result.PostID})
+		}
+	}
+	return result
+}
+
+// createImportedPost は取り込み元の作成日時と更新日時を保って投稿を作成する
+func createImportedPost(ctx context.Context, repos repositories.Repositories, entry importer.Entry, names []string) (*models.Post, error) {
+	tags, err := resolveTags(ctx, repos, names)
+	if err != nil {
+		return nil, err
+	}
+	post := &models.Post{
+		Title:     entry.Title,
+		Content:   entry.Content,
+		Author:    entry.Author,
+		Tags:      tags,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = time.Now()
+	}
+	if post.UpdatedAt.Before(post.CreatedAt) {
+		post.UpdatedAt = post.CreatedAt
+	}
+	applyTextStats(post)
+	if err := repos.Posts.Create(ctx, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// updateImportedPost は取り込み済みの投稿を取り込み元の内容で更新する。作成日時は変えない
+func updateImportedPost(ctx context.Context, repos repositories.Repositories, post *models.Post, entry importer.Entry, names []string) error {
+	tags, err := resolveTags(ctx, repos, names)
+	if err != nil {
+		return err
+	}
+	post.Title = entry.Title
+	post.Content = entry.Content
+	post.Author = entry.Author
+	applyTextStats(post)
+	post.UpdatedAt = time.Now()
+	if repos.Tags == nil {
+		post.Tags = tags
+		return repos.Posts.Update(ctx, post)
+	}
+	if err := repos.Posts.Update(ctx, post); err != nil {
+		return err
+	}
+	return repos.Tags.ReplacePostTags(ctx, post, tags)
+}
+
+// importedPostUnchanged は取り込み済みの投稿が取り込み元と同じ内容かどうかを返す
+func importedPostUnchanged(post *models.Post, entry importer.Entry, names []string) bool {
+	if post.Title != entry.Title || post.Content != entry.Content || post.Author != entry.Author {
+		return false
+	}
+	current := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		current[i] = tag.Name
+	}
+	names = slices.Clone(names)
+	slices.Sort(current)
+	slices.Sort(names)
+	return slices.Equal(current, names)
+}
+
+// validateEntry は取り込み元の投稿が保存できるかを確認する
+func validateEntry(entry importer.Entry) error {
+	switch {
+	case strings.TrimSpace(entry.SourceID) == "":
+		return fmt.Errorf("%w: missing source ID", ErrInvalidInput)
+	case utf8.RuneCountInString(entry.SourceID) > maxSourceIDLength:
+		return fmt.Errorf("%w: source ID is longer than %d characters", ErrInvalidInput, maxSourceIDLength)
+	case strings.TrimSpace(entry.Title) == "":
+		return fmt.Errorf("%w: missing title", ErrInvalidInput)
+	case utf8.RuneCountInString(entry.Title) > maxTitleLength:
+		return fmt.Errorf("%w: title is longer than %d characters", ErrInvalidInput, maxTitleLength)
+	case utf8.RuneCountInString(entry.Author) > maxAuthorLength:
+		return fmt.Errorf("%w: author is longer than %d characters", ErrInvalidInput, maxAuthorLength)
+	}
+	return nil
+}
+
+func (s *importService) notify(ctx context.Context, event PostEvent) {
+	for _, listener := range s.listeners {
+		listener(ctx, event)
+	}
+}
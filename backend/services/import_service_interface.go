@@ -0,0 +1,15 @@
+package services
+
+import (
+	"blog/importer"
+	"blog/models"
+	"context"
+)
+
+type ImportService interface {
+	// Import は entries を取り込み元 source (importer.Format) の投稿として取り込む。
+	// 取り込み済みの SourceID は新しく作成せず、内容が変わっていれば更新する。
+	// 1 件ずつ別のトランザクションで保存し、失敗した投稿はレポートに記録して続行する。
+	// dryRun の場合は何も保存せずに、行う予定の操作をレポートとして返す。
+	Import(ctx context.Context, source importer.Format, entries []importer.Entry, dryRun bool) (*models.ImportReport, error)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"blog/frontmatter"
+	"blog/models"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// フロントマターから設定するフィールドのカラム長
+const (
+	maxSlugLength        = 255
+	maxDescriptionLength = 1000
+	maxCoverImageLength  = 1000
+)
+
+// postFrontMatterKeys は投稿のフィールドに対応するフロントマターのキー。それ以外のキーは Metadata に保存する
+var postFrontMatterKeys = map[string]bool{
+	"title": true, "tags": true, "date": true, "slug": true, "description": true, "draft": true,
+	"cover_image": true, "coverimage": true, "cover": true,
+}
+
+// applyFrontMatter は本文の先頭の YAML (---) または TOML (+++) のフロントマターを投稿のフィールドに反映し、
+// 本文から取り除く。リクエストで値を指定したフィールドはフロントマターより優先する。
+// 更新では stored に保存済みの投稿を渡す。保存済みの値のままのフィールドは指定されていないものとして、フロントマターの値で上書きする。
+// date は投稿日時として返す (フロントマターがないか date がない場合はゼロ値)。
+func applyFrontMatter(post *models.Post, stored *models.Post) (time.Time, error) {
+	matter, body, err := frontmatter.Parse(post.Content)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if matter == nil {
+		return time.Time{}, validatePostFields(post)
+	}
+	post.Content = body
+	if stored == nil {
+		stored = &models.Post{}
+	}
+
+	fill := func(field *string, storedValue, value string) {
+		if value != "" && (*field == "" || *field == storedValue) {
+			*field = value
+		}
+	}
+	fill(&post.Title, stored.Title, matter.String("title"))
+	if len(post.Tags) == 0 {
+		for _, name := range matter.Strings("tags") {
+			post.Tags = append(post.Tags, models.Tag{Name: name})
+		}
+	}
+	fill(&post.Slug, stored.Slug, matter.String("slug"))
+	fill(&post.Description, stored.Description, matter.String("description"))
+	fill(&post.CoverImage, stored.CoverImage, coverImage(matter))
+	// 更新では draft: false で公開にも戻せる
+	if draft, ok := matter.Bool("draft"); ok && (draft || post.Draft == stored.Draft) {
+		post.Draft = draft
+	}
+
+	var date time.Time
+	if _, ok := matter["date"]; ok {
+		var valid bool
+		if date, valid = matter.Time("date"); !valid {
+			return time.Time{}, fmt.Errorf("%w: invalid date in front matter: %v", ErrInvalidInput, matter["date"])
+		}
+	}
+
+	metadata := models.PostMetadata{}
+	for key, value := range matter {
+		if !postFrontMatterKeys[key] {
+			metadata[key] = value
+		}
+	}
+	// リクエストで指定したキーを優先する。保存済みのままのキーはフロントマターで上書きする
+	for key, value := range post.Metadata {
+		if _, ok := metadata[key]; ok && reflect.DeepEqual(value, stored.Metadata[key]) {
+			continue
+		}
+		metadata[key] = value
+	}
+	if post.Metadata, err = normalizeMetadata(metadata); err != nil {
+		return time.Time{}, err
+	}
+	return date, validatePostFields(post)
+}
+
+// coverImage はカバー画像の URL を返す。Hugo のテーマで使われる cover: {image: ...} も受け付ける
+func coverImage(matter frontmatter.Matter) string {
+	if cover, ok := matter["cover"].(map[string]any); ok {
+		return frontmatter.Matter(cover).String("image")
+	}
+	return matter.String("cover_image", "coverimage", "cover")
+}
+
+// normalizeMetadata は保存後に読み込んだ値と同じになるよう JSON を経由した値に変換する
+func normalizeMetadata(metadata models.PostMetadata) (models.PostMetadata, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("%w: front matter cannot be stored as metadata: %w", ErrInvalidInput, err)
+	}
+	var normalized models.PostMetadata
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// validatePostFields はフロントマターからも設定できるフィールドを整えてカラム長を検証する
+func validatePostFields(post *models.Post) error {
+	post.Slug = strings.TrimSpace(post.Slug)
+	for _, field := range []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"slug", post.Slug, maxSlugLength},
+		{"description", post.Description, maxDescriptionLength},
+		{"cover image", post.CoverImage, maxCoverImageLength},
+	} {
+		if utf8.RuneCountInString(field.value) > field.max {
+			return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidInput, field.name, field.max)
+		}
+	}
+	return nil
+}
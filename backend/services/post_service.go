@@ -1,50 +1,204 @@
 package services
 
 import (
+	"blog/metrics"
 	"blog/models"
 	"blog/repositories"
+	"blog/textstats"
+	"context"
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// maxTagNameLength は models.Tag.Name のカラム長
+const maxTagNameLength = 100
+
 type postService struct {
 	repo repositories.PostRepository
+	uow  repositories.UnitOfWork
 }
 
+// NewPostService はトランザクションを使わない PostService を生成する
 func NewPostService(repo repositories.PostRepository) PostService {
-	return &postService{repo: repo}
+	return NewPostServiceWithUnitOfWork(repositories.NewNonTransactionalUnitOfWork(repositories.Repositories{Posts: repo}))
 }
 
-func (s *postService) GetAllPosts() ([]models.Post, error) {
-	return s.repo.FindAll()
+// NewPostServiceWithUnitOfWork は読み込みと書き込みを UnitOfWork のトランザクションで行う PostService を生成する
+func NewPostServiceWithUnitOfWork(uow repositories.UnitOfWork) PostService {
+	return &postService{repo: uow.Repositories().Posts, uow: uow}
 }
 
-func (s *postService) GetPostByID(id uint) (*models.Post, error) {
-	return s.repo.FindByID(id)
+func (s *postService) GetAllPosts(ctx context.Context, query PostQuery) ([]models.Post, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	return s.repo.List(ctx, query)
 }
 
-func (s *postService) CreatePost(post *models.Post) error {
-	post.CreatedAt = time.Now()
-	post.UpdatedAt = time.Now()
-	return s.repo.Create(post)
+func (s *postService) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	return s.repo.FindByID(ctx, id)
 }
 
-func (s *postService) UpdatePost(id uint, postData models.Post) error {
-	post, err := s.repo.FindByID(id)
+func (s *postService) CreatePost(ctx context.Context, post *models.Post) error {
+	date, err := applyFrontMatter(post, nil)
+	if err != nil {
+		return err
+	}
+	names, err := normalizeTagNames(post.Tags)
 	if err != nil {
 		return err
 	}
 
-	post.Title = postData.Title
-	post.Content = postData.Content
-	post.UpdatedAt = time.Now()
+	err = s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		tags, err := resolveTags(ctx, repos, names)
+		if err != nil {
+			return err
+		}
+		post.Tags = tags
+		applyTextStats(post)
+		post.CreatedAt = time.Now()
+		post.UpdatedAt = time.Now()
+		// フロントマターの date を投稿日時とする
+		if !date.IsZero() {
+			post.CreatedAt = date
+		}
+		if err := repos.Posts.Create(ctx, post); err != nil {
+			return err
+		}
+		events := []PostEventType{PostCreated}
+		if !post.Draft {
+			events = append(events, PostPublished)
+		}
+		return enqueueWebhooks(ctx, repos, post, events...)
+	})
+	if err != nil {
+		return err
+	}
+	metrics.PostsCreatedTotal.Inc()
+	return nil
+}
+
+func (s *postService) UpdatePost(ctx context.Context, id uint, postData models.Post) error {
+	return s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		post, err := repos.Posts.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		// 本文のフロントマターは保存済みの値より優先する
+		date, err := applyFrontMatter(&postData, post)
+		if err != nil {
+			return err
+		}
+		names, err := normalizeTagNames(postData.Tags)
+		if err != nil {
+			return err
+		}
+
+		events := []PostEventType{PostUpdated}
+		if post.Draft && !postData.Draft {
+			events = append(events, PostPublished)
+		}
+
+		post.Title = postData.Title
+		post.Content = postData.Content
+		post.Slug = postData.Slug
+		post.Description = postData.Description
+		post.CoverImage = postData.CoverImage
+		post.Draft = postData.Draft
+		post.Metadata = postData.Metadata
+		applyTextStats(post)
+		post.UpdatedAt = time.Now()
+		if !date.IsZero() {
+			post.CreatedAt = date
+		}
 
-	return s.repo.Update(post)
+		// Tags が省略された場合は既存のタグを維持し、空配列の場合は全て外す
+		if err := saveUpdatedPost(ctx, repos, post, postData.Tags == nil, names); err != nil {
+			return err
+		}
+		return enqueueWebhooks(ctx, repos, post, events...)
+	})
 }
 
-func (s *postService) DeletePost(id uint) error {
-	post, err := s.repo.FindByID(id)
+// saveUpdatedPost は投稿を保存する。keepTags でない場合はタグを names に置き換える
+func saveUpdatedPost(ctx context.Context, repos repositories.Repositories, post *models.Post, keepTags bool, names []string) error {
+	if keepTags {
+		return repos.Posts.Update(ctx, post)
+	}
+	tags, err := resolveTags(ctx, repos, names)
 	if err != nil {
 		return err
 	}
-	return s.repo.Delete(post)
+	if repos.Tags == nil {
+		post.Tags = tags
+		return repos.Posts.Update(ctx, post)
+	}
+	if err := repos.Posts.Update(ctx, post); err != nil {
+		return err
+	}
+	if err := repos.Tags.ReplacePostTags(ctx, post, tags); err != nil {
+		return err
+	}
+	post.Tags = tags
+	return nil
+}
+
+func (s *postService) DeletePost(ctx context.Context, id uint) error {
+	return s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		post, err := repos.Posts.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if repos.Series != nil {
+			// 削除した投稿が連載の前後リンクに残らないようにする
+			if err := repos.Series.RemovePost(ctx, id); err != nil {
+				return err
+			}
+		}
+		if err := repos.Posts.Delete(ctx, post); err != nil {
+			return err
+		}
+		return enqueueWebhooks(ctx, repos, post, PostDeleted)
+	})
+}
+
+// applyTextStats は本文から語数・文字数・読了時間・抜粋を計算して設定する
+func applyTextStats(post *models.Post) {
+	stats := textstats.Compute(post.Content)
+	post.WordCount = stats.Words
+	post.CharCount = stats.Characters
+	post.ReadingTimeMinutes = stats.ReadingMinutes
+	post.Excerpt = stats.Excerpt
+}
+
+// resolveTags はタグ名からタグを取得する。TagRepository がない場合 (メモリ実装など) は名前だけのタグを返す
+func resolveTags(ctx context.Context, repos repositories.Repositories, names []string) ([]models.Tag, error) {
+	if repos.Tags != nil {
+		return repos.Tags.FindOrCreate(ctx, names)
+	}
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+	return tags, nil
+}
+
+// normalizeTagNames はタグ名の前後の空白を除いて小文字にし、空と重複を取り除く
+func normalizeTagNames(tags []models.Tag) ([]string, error) {
+	names := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		name := strings.ToLower(strings.TrimSpace(tag.Name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if utf8.RuneCountInString(name) > maxTagNameLength {
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidInput, name, maxTagNameLength)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
 }
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"blog/cache"
+	"blog/logging"
+	"blog/metrics"
+	"blog/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const cacheName = "posts"
+
+// listGenerationKey は一覧のキャッシュの世代番号を保存するキー。
+// バックエンドに置くため、バックエンドを共有する全てのプロセスが同じ世代の一覧を読む
+const listGenerationKey = "posts:generation"
+
+// cacheLoadTimeout はキャッシュのミスでまとめて行う読み込みのタイムアウト。
+// 読み込みは待っている全ての呼び出し元のために行うため、呼び出し元のキャンセルでは止めない
+const cacheLoadTimeout = 10 * time.Second
+
+// CacheTTL はキャッシュの有効期間
+type CacheTTL struct {
+	Post time.Duration
+	List time.Duration
+}
+
+// cachedPostService は読み取り結果をキャッシュする PostService のデコレータ。
+// 書き込み時は該当する投稿のキャッシュを破棄する。一覧は PostQuery ごとにキャッシュするため、
+// キーにバックエンドに保存した世代番号を含めて書き込みの度に世代を進め、古い一覧は TTL で消えるのに任せる。
+// 読み込み中にこのプロセスで書き込みがあった場合は、書き込み前の値の可能性があるため保存しない。
+type cachedPostService struct {
+	next    PostService
+	backend cache.Backend
+	ttl     CacheTTL
+	group   singleflight.Group
+	// generation はこのプロセスでの書き込みの回数
+	generation atomic.Uint64
+}
+
+// CachedPostService は読み取り結果をキャッシュする PostService
+type CachedPostService interface {
+	PostService
+	// Invalidate は event の投稿と一覧のキャッシュを破棄する。
+	// PostService を経由しない書き込み (取り込みなど) の PostEventListener として使う。
+	Invalidate(ctx context.Context, event PostEvent)
+}
+
+func NewCachedPostService(next PostService, backend cache.Backend, ttl CacheTTL) CachedPostService {
+	return &cachedPostService{next: next, backend: backend, ttl: ttl}
+}
+
+func postCacheKey(id uint) string {
+	return fmt.Sprintf("post:%d", id)
+}
+
+func listCacheKey(generation int64, query PostQuery) string {
+	return fmt.Sprintf("posts:%d:%s", generation, query)
+}
+
+func (s *cachedPostService) GetAllPosts(ctx context.Context, query PostQuery) ([]models.Post, error) {
+	generation, err := s.backend.Incr(ctx, listGenerationKey, 0)
+	if err != nil {
+		// 世代が分からない場合は古い一覧を返さないようキャッシュを使わない
+		logging.FromContext(ctx).WarnContext(ctx, "cache get failed", "key", listGenerationKey, "error", err)
+		return s.next.GetAllPosts(ctx, query)
+	}
+
+	var posts []models.Post
+	err = s.readThrough(ctx, listCacheKey(generation, query), s.ttl.List, &posts, func(ctx context.Context) (any, error) {
+		return s.next.GetAllPosts(ctx, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (s *cachedPostService) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	err := s.readThrough(ctx, postCacheKey(id), s.ttl.Post, &post, func(ctx context.Context) (any, error) {
+		return s.next.GetPostByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (s *cachedPostService) CreatePost(ctx context.Context, post *models.Post) error {
+	if err := s.next.CreatePost(ctx, post); err != nil {
+		return err
+	}
+	s.advance(ctx)
+	return nil
+}
+
+func (s *cachedPostService) UpdatePost(ctx context.Context, id uint, postData models.Post) error {
+	err := s.next.UpdatePost(ctx, id, postData)
+	s.advance(ctx)
+	s.invalidate(ctx, postCacheKey(id))
+	return err
+}
+
+func (s *cachedPostService) DeletePost(ctx context.Context, id uint) error {
+	err := s.next.DeletePost(ctx, id)
+	s.advance(ctx)
+	s.invalidate(ctx, postCacheKey(id))
+	return err
+}
+
+func (s *cachedPostService) Invalidate(ctx context.Context, event PostEvent) {
+	s.advance(ctx)
+	s.invalidate(ctx, postCacheKey(event.PostID))
+}
+
+// advance は書き込みの後に世代を進め、全てのプロセスの一覧のキャッシュを古くする
+func (s *cachedPostService) advance(ctx context.Context) {
+	s.generation.Add(1)
+	if _, err := s.backend.Incr(ctx, listGenerationKey, 1); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "cache invalidation failed", "keys", []string{listGenerationKey}, "error", err)
+	}
+}
+
+// readThrough はキャッシュにあれば dest にデコードし、なければ load の結果を保存する。
+// 同じ世代の同じキーへの同時ミスは singleflight で 1 回の読み込みにまとめる。
+func (s *cachedPostService) readThrough(ctx context.Context, key string, ttl time.Duration, dest any, load func(context.Context) (any, error)) error {
+	logger := logging.FromContext(ctx)
+
+	data, ok, err := s.backend.Get(ctx, key)
+	if err != nil {
+		logger.WarnContext(ctx, "cache get failed", "key", key, "error", err)
+	}
+	if ok && json.Unmarshal(data, dest) == nil {
+		metrics.CacheRequestsTotal.WithLabelValues(cacheName, "hit").Inc()
+		return nil
+	}
+	metrics.CacheRequestsTotal.WithLabelValues(cacheName, "miss").Inc()
+
+	// 書き込みの後のミスは、書き込みの前に始まった読み込みを待たずに読み込み直す
+	generation := s.generation.Load()
+	result := s.group.DoChan(fmt.Sprintf("%d:%s", generation, key), func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheLoadTimeout)
+		defer cancel()
+
+		value, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if s.generation.Load() != generation {
+			return data, nil
+		}
+		if err := s.backend.Set(ctx, key, data, ttl); err != nil {
+			logger.WarnContext(ctx, "cache set failed", "key", key, "error", err)
+		}
+		// 保存と書き込みの破棄が入れ違った場合は、保存した古い値を消す
+		if s.generation.Load() != generation {
+			s.invalidate(ctx, key)
+		}
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), dest)
+	}
+}
+
+func (s *cachedPostService) invalidate(ctx context.Context, keys ...string) {
+	if err := s.backend.Delete(ctx, keys...); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "cache invalidation failed", "keys", keys, "error", err)
+	}
+}
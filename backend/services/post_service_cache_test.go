@@ -0,0 +1,221 @@
+package services_test
+
+import (
+	"blog/cache"
+	"blog/models"
+	"blog/services"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newCachedService(repo *MockPostRepository) services.PostService {
+	return services.NewCachedPostService(services.NewPostService(repo), cache.NewMemory(100), services.CacheTTL{
+		Post: time.Minute,
+		List: time.Minute,
+	})
+}
+
+func TestCachedPostService_GetPostByID(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := newCachedService(repo)
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "Test Post"}, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		post, err := service.GetPostByID(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test Post", post.Title)
+	}
+	repo.AssertNumberOfCalls(t, "FindByID", 1)
+}
+
+func TestCachedPostService_DoesNotCacheErrors(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := newCachedService(repo)
+	repo.On("FindByID", mock.Anything, uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
+
+	_, err := service.GetPostByID(context.Background(), 99)
+	assert.Error(t, err)
+	_, err = service.GetPostByID(context.Background(), 99)
+	assert.Error(t, err)
+	repo.AssertNumberOfCalls(t, "FindByID", 2)
+}
+
+func TestCachedPostService_InvalidatesOnUpdate(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := newCachedService(repo)
+	repo.On("List", mock.Anything, mock.Anything).Return([]models.Post{{ID: 1, Title: "Old Title"}}, nil).Once()
+	repo.On("List", mock.Anything, mock.Anything).Return([]models.Post{{ID: 1, Title: "New Title"}}, nil).Once()
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "Old Title"}, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	posts, err := service.GetAllPosts(context.Background(), services.PostQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Old Title", posts[0].Title)
+
+	assert.NoError(t, service.UpdatePost(context.Background(), 1, models.Post{Title: "New Title"}))
+
+	posts, err = service.GetAllPosts(context.Background(), services.PostQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "New Title", posts[0].Title)
+	repo.AssertNumberOfCalls(t, "List", 2)
+}
+
+func TestCachedPostService_Invalidate(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewCachedPostService(services.NewPostService(repo), cache.NewMemory(100), services.CacheTTL{
+		Post: time.Minute,
+		List: time.Minute,
+	})
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "Old Title"}, nil).Once()
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "New Title"}, nil).Once()
+	repo.On("List", mock.Anything, mock.Anything).Return([]models.Post{{ID: 1}}, nil)
+
+	_, err := service.GetPostByID(context.Background(), 1)
+	assert.NoError(t, err)
+	_, err = service.GetAllPosts(context.Background(), services.PostQuery{})
+	assert.NoError(t, err)
+
+	// PostService を経由しない書き込みの後
+	service.Invalidate(context.Background(), services.PostEvent{Type: services.PostUpdated, PostID: 1})
+
+	post, err := service.GetPostByID(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "New Title", post.Title)
+	_, err = service.GetAllPosts(context.Background(), services.PostQuery{})
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "List", 2)
+}
+
+func TestCachedPostService_CachesListPerQuery(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := newCachedService(repo)
+	titles := services.PostQuery{Fields: []string{"title"}}
+	repo.On("List", mock.Anything, services.PostQuery{}).Return([]models.Post{{ID: 1, Title: "Title", Content: "Content"}}, nil).Once()
+	repo.On("List", mock.Anything, titles).Return([]models.Post{{ID: 1, Title: "Title"}}, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		posts, err := service.GetAllPosts(context.Background(), services.PostQuery{})
+		assert.NoError(t, err)
+		assert.Equal(t, "Content", posts[0].Content)
+
+		posts, err = service.GetAllPosts(context.Background(), titles)
+		assert.NoError(t, err)
+		assert.Empty(t, posts[0].Content)
+	}
+	repo.AssertNumberOfCalls(t, "List", 2)
+}
+
+func TestCachedPostService_CollapsesConcurrentMisses(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := newCachedService(repo)
+	repo.On("FindByID", mock.Anything, uint(1)).
+		After(50*time.Millisecond).
+		Return(&models.Post{ID: 1, Title: "Test Post"}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			post, err := service.GetPostByID(context.Background(), 1)
+			assert.NoError(t, err)
+			assert.Equal(t, "Test Post", post.Title)
+		}()
+	}
+	wg.Wait()
+
+	repo.AssertNumberOfCalls(t, "FindByID", 1)
+}
+
+func TestCachedPostService_LoadOutlivesCanceledCaller(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := newCachedService(repo)
+	started, release := make(chan struct{}), make(chan struct{})
+	var loadErr error
+	repo.On("FindByID", mock.Anything, uint(1)).
+		Run(func(args mock.Arguments) {
+			close(started)
+			<-release
+			loadErr = args.Get(0).(context.Context).Err()
+		}).
+		Return(&models.Post{ID: 1, Title: "Test Post"}, nil).Once()
+
+	// 最初の呼び出し元がキャンセルしても、読み込みは続けてキャッシュに保存する
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := service.GetPostByID(ctx, 1)
+		done <- err
+	}()
+	<-started
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		post, err := service.GetPostByID(context.Background(), 1)
+		return err == nil && post.Title == "Test Post"
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, loadErr)
+	repo.AssertNumberOfCalls(t, "FindByID", 1)
+}
+
+func TestCachedPostService_DoesNotCacheLoadStartedBeforeInvalidation(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewCachedPostService(services.NewPostService(repo), cache.NewMemory(100), services.CacheTTL{
+		Post: time.Minute,
+		List: time.Minute,
+	})
+	started, release := make(chan struct{}), make(chan struct{})
+	repo.On("FindByID", mock.Anything, uint(1)).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(&models.Post{ID: 1, Title: "Old Title"}, nil).Once()
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "New Title"}, nil).Once()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.GetPostByID(context.Background(), 1)
+	}()
+	<-started
+	// 読み込み中の書き込み。書き込みの前に読んだ値は保存しない
+	service.Invalidate(context.Background(), services.PostEvent{Type: services.PostUpdated, PostID: 1})
+	close(release)
+	<-done
+
+	post, err := service.GetPostByID(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "New Title", post.Title)
+	repo.AssertNumberOfCalls(t, "FindByID", 2)
+}
+
+// バックエンドを共有するプロセスの書き込みで、他のプロセスの一覧のキャッシュも古くなる
+func TestCachedPostService_SharesListGenerationThroughBackend(t *testing.T) {
+	backend := cache.NewMemory(100)
+	ttl := services.CacheTTL{Post: time.Minute, List: time.Minute}
+	repo := new(MockPostRepository)
+	repo.On("List", mock.Anything, mock.Anything).Return([]models.Post{{ID: 1, Title: "Old Title"}}, nil).Once()
+	repo.On("List", mock.Anything, mock.Anything).Return([]models.Post{{ID: 1, Title: "New Title"}}, nil).Once()
+	reader := services.NewCachedPostService(services.NewPostService(repo), backend, ttl)
+	writer := services.NewCachedPostService(services.NewPostService(repo), backend, ttl)
+
+	posts, err := reader.GetAllPosts(context.Background(), services.PostQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Old Title", posts[0].Title)
+
+	writer.Invalidate(context.Background(), services.PostEvent{Type: services.PostUpdated, PostID: 1})
+
+	posts, err = reader.GetAllPosts(context.Background(), services.PostQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "New Title", posts[0].Title)
+	repo.AssertNumberOfCalls(t, "List", 2)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"blog/models"
+	"context"
+)
+
+// PostEventType は投稿の変更の種類
+type PostEventType string
+
+const (
+	PostCreated PostEventType = "post.created"
+	PostUpdated PostEventType = "post.updated"
+	PostDeleted PostEventType = "post.deleted"
+	// PostPublished は下書きでない投稿が作成されたか、下書きが公開されたこと。Webhook でだけ通知する
+	PostPublished PostEventType = "post.published"
+)
+
+// PostEventTypes は Webhook で通知できるイベントの種類
+var PostEventTypes = []PostEventType{PostCreated, PostUpdated, PostPublished, PostDeleted}
+
+// PostEvent は書き込みが成功した後に通知される投稿の変更
+type PostEvent struct {
+	Type   PostEventType
+	PostID uint
+}
+
+// PostEventListener は投稿の変更を受け取る。
+// 書き込みのリクエスト内で同期的に呼ばれるため、時間のかかる処理は非同期に行うこと。
+type PostEventListener func(ctx context.Context, event PostEvent)
+
+// observedPostService は書き込みが成功した後にリスナーへ PostEvent を通知するデコレータ
+type observedPostService struct {
+	next      PostService
+	listeners []PostEventListener
+}
+
+func NewObservedPostService(next PostService, listeners ...PostEventListener) PostService {
+	return &observedPostService{next: next, listeners: listeners}
+}
+
+func (s *observedPostService) GetAllPosts(ctx context.Context, query PostQuery) ([]models.Post, error) {
+	return s.next.GetAllPosts(ctx, query)
+}
+
+func (s *observedPostService) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	return s.next.GetPostByID(ctx, id)
+}
+
+func (s *observedPostService) CreatePost(ctx context.Context, post *models.Post) error {
+	if err := s.next.CreatePost(ctx, post); err != nil {
+		return err
+	}
+	s.notify(ctx, PostEvent{Type: PostCreated, PostID: post.ID})
+	return nil
+}
+
+func (s *observedPostService) UpdatePost(ctx context.Context, id uint, postData models.Post) error {
+	if err := s.next.UpdatePost(ctx, id, postData); err != nil {
+		return err
+	}
+	s.notify(ctx, PostEvent{Type: PostUpdated, PostID: id})
+	return nil
+}
+
+func (s *observedPostService) DeletePost(ctx context.Context, id uint) error {
+	if err := s.next.DeletePost(ctx, id); err != nil {
+		return err
+	}
+	s.notify(ctx, PostEvent{Type: PostDeleted, PostID: id})
+	return nil
+}
+
+func (s *observedPostService) notify(ctx context.Context, event PostEvent) {
+	for _, listener := range s.listeners {
+		listener(ctx, event)
+	}
+}
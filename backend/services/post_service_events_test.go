@@ -0,0 +1,49 @@
+package services_test
+
+import (
+	"blog/models"
+	"blog/services"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestObservedPostService_NotifiesAfterWrites(t *testing.T) {
+	repo := new(MockPostRepository)
+	var events []services.PostEvent
+	service := services.NewObservedPostService(services.NewPostService(repo), func(_ context.Context, event services.PostEvent) {
+		events = append(events, event)
+	})
+
+	repo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Post).ID = 1
+	}).Return(nil)
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1}, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	repo.On("Delete", mock.Anything, mock.Anything).Return(nil)
+
+	assert.NoError(t, service.CreatePost(context.Background(), &models.Post{Title: "New Post"}))
+	assert.NoError(t, service.UpdatePost(context.Background(), 1, models.Post{Title: "Updated"}))
+	assert.NoError(t, service.DeletePost(context.Background(), 1))
+
+	assert.Equal(t, []services.PostEvent{
+		{Type: services.PostCreated, PostID: 1},
+		{Type: services.PostUpdated, PostID: 1},
+		{Type: services.PostDeleted, PostID: 1},
+	}, events)
+}
+
+func TestObservedPostService_DoesNotNotifyOnError(t *testing.T) {
+	repo := new(MockPostRepository)
+	notified := false
+	service := services.NewObservedPostService(services.NewPostService(repo), func(context.Context, services.PostEvent) {
+		notified = true
+	})
+	repo.On("FindByID", mock.Anything, uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
+
+	assert.Error(t, service.DeletePost(context.Background(), 99))
+	assert.False(t, notified)
+}
@@ -1,11 +1,27 @@
 package services
 
-import "blog/models"
+import (
+	"blog/models"
+	"blog/repositories"
+	"context"
+)
+
+// PostQuery は投稿の一覧で読み込むフィールドと関連
+type PostQuery = repositories.PostQuery
+
+var (
+	// PostFields は PostQuery.Fields で指定できるフィールド
+	PostFields = repositories.PostFields
+	// PostIncludes は PostQuery.Include で指定できる関連
+	PostIncludes = repositories.PostIncludes
+)
 
 type PostService interface {
-	GetAllPosts() ([]models.Post, error)
-	GetPostByID(id uint) (*models.Post, error)
-	CreatePost(post *models.Post) error
-	UpdatePost(id uint, postData models.Post) error
-	DeletePost(id uint) error
+	// GetAllPosts は query のフィールドと関連を読み込んだ投稿を返す。
+	// 許可されていないフィールドや関連が含まれる場合は ErrInvalidInput を返す。
+	GetAllPosts(ctx context.Context, query PostQuery) ([]models.Post, error)
+	GetPostByID(ctx context.Context, id uint) (*models.Post, error)
+	CreatePost(ctx context.Context, post *models.Post) error
+	UpdatePost(ctx context.Context, id uint, postData models.Post) error
+	DeletePost(ctx context.Context, id uint) error
 }
@@ -2,8 +2,11 @@ package services_test
 
 import (
 	"blog/models"
+	"blog/repositories"
 	"blog/services"
+	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,37 +18,42 @@ type MockPostRepository struct {
 	mock.Mock
 }
 
-func (m *MockPostRepository) FindAll() ([]models.Post, error) {
-	args := m.Called()
+func (m *MockPostRepository) FindAll(ctx context.Context) ([]models.Post, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]models.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) FindByID(id uint) (*models.Post, error) {
-	args := m.Called(id)
+func (m *MockPostRepository) List(ctx context.Context, query repositories.PostQuery) ([]models.Post, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) Create(post *models.Post) error {
-	args := m.Called(post)
+func (m *MockPostRepository) Create(ctx context.Context, post *models.Post) error {
+	args := m.Called(ctx, post)
 	return args.Error(0)
 }
 
-func (m *MockPostRepository) Update(post *models.Post) error {
-	args := m.Called(post)
+func (m *MockPostRepository) Update(ctx context.Context, post *models.Post) error {
+	args := m.Called(ctx, post)
 	return args.Error(0)
 }
 
-func (m *MockPostRepository) Delete(post *models.Post) error {
-	args := m.Called(post)
+func (m *MockPostRepository) Delete(ctx context.Context, post *models.Post) error {
+	args := m.Called(ctx, post)
 	return args.Error(0)
 }
 
 func TestGetAllPosts(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
-	repo.On("FindAll").Return([]models.Post{{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}}, nil)
+	repo.On("List", mock.Anything, services.PostQuery{}).Return([]models.Post{{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}}, nil)
 
-	posts, err := service.GetAllPosts()
+	posts, err := service.GetAllPosts(context.Background(), services.PostQuery{})
 	assert.NoError(t, err)
 	assert.Len(t, posts, 1)
 	assert.Equal(t, "Test Post", posts[0].Title)
@@ -53,12 +61,21 @@ func TestGetAllPosts(t *testing.T) {
 	assert.Equal(t, "Test Author", posts[0].Author)
 }
 
+func TestGetAllPosts_InvalidQuery(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+
+	_, err := service.GetAllPosts(context.Background(), services.PostQuery{Fields: []string{"password"}})
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	repo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
 func TestGetPostByID(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
-	repo.On("FindByID", uint(1)).Return(&models.Post{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}, nil)
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author", CreatedAt: time.Now(), UpdatedAt: time.Now()}, nil)
 
-	post, err := service.GetPostByID(1)
+	post, err := service.GetPostByID(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, post)
 	assert.Equal(t, "Test Post", post.Title)
@@ -70,9 +87,9 @@ func TestGetPostByID_NotFound(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
 
-	repo.On("FindByID", uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
+	repo.On("FindByID", mock.Anything, uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
 
-	post, err := service.GetPostByID(99)
+	post, err := service.GetPostByID(context.Background(), 99)
 	assert.Error(t, err)
 	assert.Nil(t, post)
 }
@@ -81,9 +98,9 @@ func TestCreatePost(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
 	post := &models.Post{Title: "New Post", Content: "New Content", Author: "New Author"}
-	repo.On("Create", mock.Anything).Return(nil)
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.CreatePost(post)
+	err := service.CreatePost(context.Background(), post)
 	assert.NoError(t, err)
 	assert.NotZero(t, post.CreatedAt)
 	assert.NotZero(t, post.UpdatedAt)
@@ -96,18 +113,119 @@ func TestCreatePost_Error(t *testing.T) {
 	service := services.NewPostService(repo)
 	post := &models.Post{Title: "New Post", Content: "New Content", Author: "New Author"}
 
-	repo.On("Create", mock.Anything).Return(errors.New("failed to create post"))
+	repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("failed to create post"))
 
-	err := service.CreatePost(post)
+	err := service.CreatePost(context.Background(), post)
 	assert.Error(t, err)
 }
 
+func TestCreatePost_ComputesTextStats(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+	post := &models.Post{Title: "New Post", Content: "# Hello\n\nGoで**並行処理**"}
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	err := service.CreatePost(context.Background(), post)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, post.WordCount)
+	assert.Equal(t, 12, post.CharCount)
+	assert.Equal(t, 1, post.ReadingTimeMinutes)
+	assert.Equal(t, "Hello Goで並行処理", post.Excerpt)
+}
+
+func TestCreatePost_NormalizesTags(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+	post := &models.Post{Title: "New Post", Tags: []models.Tag{{Name: " Go "}, {Name: "go"}, {Name: ""}, {Name: "ブログ"}}}
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	err := service.CreatePost(context.Background(), post)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Tag{{Name: "go"}, {Name: "ブログ"}}, post.Tags)
+}
+
+func TestCreatePost_TagTooLong(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+	post := &models.Post{Title: "New Post", Tags: []models.Tag{{Name: strings.Repeat("あ", 101)}}}
+
+	err := service.CreatePost(context.Background(), post)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreatePost_FrontMatter(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+	post := &models.Post{Content: `---
+title: From Editor
+tags: [Go, web]
+date: 2024-03-01T09:00:00+09:00
+slug: from-editor
+description: A post written in an editor
+draft: true
+cover:
+  image: /images/cover.png
+emoji: 🐹
+series_order: 2
+---
+
+# Body
+`}
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	err := service.CreatePost(context.Background(), post)
+	assert.NoError(t, err)
+	assert.Equal(t, "From Editor", post.Title)
+	assert.Equal(t, "# Body\n", post.Content)
+	assert.Equal(t, []models.Tag{{Name: "go"}, {Name: "web"}}, post.Tags)
+	assert.True(t, post.CreatedAt.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "from-editor", post.Slug)
+	assert.Equal(t, "A post written in an editor", post.Description)
+	assert.True(t, post.Draft)
+	assert.Equal(t, "/images/cover.png", post.CoverImage)
+	assert.Equal(t, models.PostMetadata{"emoji": "🐹", "series_order": float64(2)}, post.Metadata)
+	assert.Equal(t, "Body", post.Excerpt)
+}
+
+func TestCreatePost_TOMLFrontMatter(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+	post := &models.Post{Title: "Request Title", Content: "+++\ntitle = \"Ignored\"\ncover_image = \"/c.png\"\n+++\nBody"}
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	err := service.CreatePost(context.Background(), post)
+	assert.NoError(t, err)
+	// リクエストで指定したフィールドが優先される
+	assert.Equal(t, "Request Title", post.Title)
+	assert.Equal(t, "/c.png", post.CoverImage)
+	assert.Equal(t, "Body", post.Content)
+	assert.Nil(t, post.Metadata)
+}
+
+func TestCreatePost_InvalidFrontMatter(t *testing.T) {
+	for name, content := range map[string]string{
+		"syntax": "---\ntitle: [unclosed\n---\nBody",
+		"date":   "---\ndate: yesterday\n---\nBody",
+		"slug":   "---\nslug: " + strings.Repeat("a", 256) + "\n---\nBody",
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockPostRepository)
+			service := services.NewPostService(repo)
+
+			err := service.CreatePost(context.Background(), &models.Post{Title: "Post", Content: content})
+			assert.ErrorIs(t, err, services.ErrInvalidInput)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestUpdatePost(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
 	existingPost := &models.Post{ID: 1, Title: "Old Title", Content: "Old Content", Author: "Old Author"}
-	repo.On("FindByID", uint(1)).Return(existingPost, nil)
-	repo.On("Update", mock.Anything).Return(nil)
+	repo.On("FindByID", mock.Anything, uint(1)).Return(existingPost, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
 
 	updatedPost := models.Post{Title: "Updated Title", Content: "Updated Content", Author: "Updated Author"}
 
@@ -116,21 +234,69 @@ func TestUpdatePost(t *testing.T) {
 	existingPost.Author = updatedPost.Author
 	existingPost.UpdatedAt = time.Now()
 
-	err := service.UpdatePost(1, updatedPost)
+	err := service.UpdatePost(context.Background(), 1, updatedPost)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Title", existingPost.Title)
 	assert.Equal(t, "Updated Content", existingPost.Content)
+	assert.Equal(t, 2, existingPost.WordCount)
+	assert.Equal(t, "Updated Content", existingPost.Excerpt)
 	assert.Equal(t, "Updated Author", existingPost.Author)
 }
 
+func TestUpdatePost_FrontMatter(t *testing.T) {
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+	existingPost := &models.Post{ID: 1, Title: "Old Title", Content: "Old Content", Slug: "old", Metadata: models.PostMetadata{"old": true}}
+	repo.On("FindByID", mock.Anything, uint(1)).Return(existingPost, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	err := service.UpdatePost(context.Background(), 1, models.Post{Content: "---\ntitle: New Title\nlayout: post\n---\nNew Content"})
+	assert.NoError(t, err)
+	assert.Equal(t, "New Title", existingPost.Title)
+	assert.Equal(t, "New Content", existingPost.Content)
+	assert.Empty(t, existingPost.Slug)
+	assert.Equal(t, models.PostMetadata{"layout": "post"}, existingPost.Metadata)
+}
+
+// 更新の入力は保存済みの投稿にリクエストのフィールドを上書きしたもの。保存済みのままのフィールドはフロントマターで上書きする
+func TestUpdatePost_FrontMatterOverridesStoredValues(t *testing.T) {
+	stored := models.Post{ID: 1, Title: "Old Title", Content: "Old Content", Slug: "old", Description: "Old", CoverImage: "/old.png", Draft: true,
+		Metadata: models.PostMetadata{"layout": "page", "kept": true}}
+	repo := new(MockPostRepository)
+	service := services.NewPostService(repo)
+	existingPost := stored
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&existingPost, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	postData := stored
+	postData.Content = "---\ntitle: New Title\nslug: new\ndescription: New\ncover: /new.png\nlayout: post\ndraft: false\n---\nNew Content"
+	err := service.UpdatePost(context.Background(), 1, postData)
+	assert.NoError(t, err)
+	assert.Equal(t, "New Title", existingPost.Title)
+	assert.Equal(t, "new", existingPost.Slug)
+	assert.Equal(t, "New", existingPost.Description)
+	assert.Equal(t, "/new.png", existingPost.CoverImage)
+	assert.False(t, existingPost.Draft)
+	assert.Equal(t, models.PostMetadata{"layout": "post", "kept": true}, existingPost.Metadata)
+
+	// draft: true で下書きに戻し、リクエストで指定したフィールドはフロントマターより優先する
+	postData = existingPost
+	postData.Title = "Request Title"
+	postData.Content = "---\ntitle: Matter Title\ndraft: true\n---\nNew Content"
+	err = service.UpdatePost(context.Background(), 1, postData)
+	assert.NoError(t, err)
+	assert.Equal(t, "Request Title", existingPost.Title)
+	assert.True(t, existingPost.Draft)
+}
+
 func TestUpdatePost_NotFound(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
 
-	repo.On("FindByID", uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
+	repo.On("FindByID", mock.Anything, uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
 
 	updatedPost := models.Post{Title: "Updated Title", Content: "Updated Content", Author: "Updated Author"}
-	err := service.UpdatePost(99, updatedPost)
+	err := service.UpdatePost(context.Background(), 99, updatedPost)
 
 	assert.Error(t, err)
 }
@@ -140,11 +306,11 @@ func TestUpdatePost_Error(t *testing.T) {
 	service := services.NewPostService(repo)
 
 	existingPost := &models.Post{ID: 1, Title: "Old Title", Content: "Old Content", Author: "Old Author"}
-	repo.On("FindByID", uint(1)).Return(existingPost, nil)
-	repo.On("Update", mock.Anything).Return(errors.New("failed to update post"))
+	repo.On("FindByID", mock.Anything, uint(1)).Return(existingPost, nil)
+	repo.On("Update", mock.Anything, mock.Anything).Return(errors.New("failed to update post"))
 
 	updatedPost := models.Post{Title: "Updated Title", Content: "Updated Content", Author: "Updated Author"}
-	err := service.UpdatePost(1, updatedPost)
+	err := service.UpdatePost(context.Background(), 1, updatedPost)
 
 	assert.Error(t, err)
 }
@@ -153,10 +319,10 @@ func TestDeletePost(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
 	existingPost := &models.Post{ID: 1, Title: "Test Post", Content: "Test Content", Author: "Test Author"}
-	repo.On("FindByID", uint(1)).Return(existingPost, nil)
-	repo.On("Delete", mock.Anything).Return(nil)
+	repo.On("FindByID", mock.Anything, uint(1)).Return(existingPost, nil)
+	repo.On("Delete", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.DeletePost(1)
+	err := service.DeletePost(context.Background(), 1)
 	assert.NoError(t, err)
 }
 
@@ -164,8 +330,8 @@ func TestDeletePost_NotFound(t *testing.T) {
 	repo := new(MockPostRepository)
 	service := services.NewPostService(repo)
 
-	repo.On("FindByID", uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
+	repo.On("FindByID", mock.Anything, uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
 
-	err := service.DeletePost(99)
+	err := service.DeletePost(context.Background(), 99)
 	assert.Error(t, err)
 }
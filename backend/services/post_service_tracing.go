@@ -0,0 +1,69 @@
+package services
+
+import (
+	"blog/models"
+	"blog/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedPostService は PostService の各メソッドをスパンで囲むデコレータ
+type tracedPostService struct {
+	next   PostService
+	tracer trace.Tracer
+}
+
+func NewTracedPostService(next PostService) PostService {
+	return &tracedPostService{next: next, tracer: tracing.Tracer()}
+}
+
+func (s *tracedPostService) GetAllPosts(ctx context.Context, query PostQuery) ([]models.Post, error) {
+	ctx, span := s.tracer.Start(ctx, "PostService.GetAllPosts", trace.WithAttributes(attribute.String("post.query", query.String())))
+	defer span.End()
+
+	posts, err := s.next.GetAllPosts(ctx, query)
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	return posts, recordError(span, err)
+}
+
+func (s *tracedPostService) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	ctx, span := s.tracer.Start(ctx, "PostService.GetPostByID", trace.WithAttributes(attribute.Int("post.id", int(id))))
+	defer span.End()
+
+	post, err := s.next.GetPostByID(ctx, id)
+	return post, recordError(span, err)
+}
+
+func (s *tracedPostService) CreatePost(ctx context.Context, post *models.Post) error {
+	ctx, span := s.tracer.Start(ctx, "PostService.CreatePost")
+	defer span.End()
+
+	err := s.next.CreatePost(ctx, post)
+	span.SetAttributes(attribute.Int("post.id", int(post.ID)))
+	return recordError(span, err)
+}
+
+func (s *tracedPostService) UpdatePost(ctx context.Context, id uint, postData models.Post) error {
+	ctx, span := s.tracer.Start(ctx, "PostService.UpdatePost", trace.WithAttributes(attribute.Int("post.id", int(id))))
+	defer span.End()
+
+	return recordError(span, s.next.UpdatePost(ctx, id, postData))
+}
+
+func (s *tracedPostService) DeletePost(ctx context.Context, id uint) error {
+	ctx, span := s.tracer.Start(ctx, "PostService.DeletePost", trace.WithAttributes(attribute.Int("post.id", int(id))))
+	defer span.End()
+
+	return recordError(span, s.next.DeletePost(ctx, id))
+}
+
+func recordError(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
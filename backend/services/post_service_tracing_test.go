@@ -0,0 +1,37 @@
+package services_test
+
+import (
+	"blog/models"
+	"blog/services"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracedPostService(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	repo := new(MockPostRepository)
+	service := services.NewTracedPostService(services.NewPostService(repo))
+	repo.On("FindByID", mock.Anything, uint(1)).Return(&models.Post{ID: 1}, nil)
+	repo.On("FindByID", mock.Anything, uint(99)).Return((*models.Post)(nil), errors.New("post not found"))
+
+	_, err := service.GetPostByID(context.Background(), 1)
+	assert.NoError(t, err)
+	_, err = service.GetPostByID(context.Background(), 99)
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "PostService.GetPostByID", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"blog/views"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// MaxStatsDays は集計できる期間の最大日数
+	MaxStatsDays = 365
+	// MaxPopularPosts は 1 回に取得できる人気の投稿の最大数
+	MaxPopularPosts = 50
+)
+
+type postStatsService struct {
+	posts repositories.PostRepository
+	views repositories.PostViewRepository
+}
+
+func NewPostStatsService(uow repositories.UnitOfWork) PostStatsService {
+	repos := uow.Repositories()
+	return &postStatsService{posts: repos.Posts, views: repos.Views}
+}
+
+func (s *postStatsService) GetPopularPosts(ctx context.Context, days, limit int) ([]models.PopularPost, error) {
+	if err := validateDays(days); err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > MaxPopularPosts {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, MaxPopularPosts)
+	}
+	return s.views.FindPopular(ctx, since(days), limit)
+}
+
+func (s *postStatsService) GetPostStats(ctx context.Context, postID uint, days int) (*models.PostStats, error) {
+	if err := validateDays(days); err != nil {
+		return nil, err
+	}
+	if _, err := s.posts.FindByID(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	from := since(days)
+	daily, err := s.views.FindByPostID(ctx, postID, from)
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.PostStats{PostID: postID, Since: from, Daily: daily}
+	for _, v := range daily {
+		stats.Views += v.Views
+	}
+	return stats, nil
+}
+
+func validateDays(days int) error {
+	if days < 1 || days > MaxStatsDays {
+		return fmt.Errorf("%w: window must be between 1 and %d days", ErrInvalidInput, MaxStatsDays)
+	}
+	return nil
+}
+
+// since は今日を含む直近 days 日の初日を返す
+func since(days int) string {
+	return views.Day(time.Now().UTC().AddDate(0, 0, -(days - 1)))
+}
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"blog/models"
+	"context"
+)
+
+type PostStatsService interface {
+	// GetPopularPosts は直近 days 日 (今日を含む) の閲覧数が多い順に投稿を最大 limit 件返す
+	GetPopularPosts(ctx context.Context, days, limit int) ([]models.PopularPost, error)
+	// GetPostStats は投稿の直近 days 日 (今日を含む) の閲覧数と日別の内訳を返す
+	GetPostStats(ctx context.Context, postID uint, days int) (*models.PostStats, error)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"fmt"
+)
+
+// MaxRelatedPosts は 1 回に取得できる関連記事の最大数
+const MaxRelatedPosts = 20
+
+type relatedPostService struct {
+	posts   repositories.PostRepository
+	related repositories.RelatedPostRepository
+}
+
+func NewRelatedPostService(uow repositories.UnitOfWork) RelatedPostService {
+	repos := uow.Repositories()
+	return &relatedPostService{posts: repos.Posts, related: repos.Related}
+}
+
+func (s *relatedPostService) GetRelatedPosts(ctx context.Context, postID uint, limit int) ([]models.RelatedPost, error) {
+	if limit < 1 || limit > MaxRelatedPosts {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, MaxRelatedPosts)
+	}
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	// 下書きの関連記事は公開しない
+	if post.Draft {
+		return nil, ErrNotFound
+	}
+	return s.related.FindByPostID(ctx, postID, limit)
+}
+
+func (s *relatedPostService) GetRelatedPostsByPostIDs(ctx context.Context, postIDs []uint, limit int) (map[uint][]models.RelatedPost, error) {
+	if limit < 1 || limit > MaxRelatedPosts {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, MaxRelatedPosts)
+	}
+	if len(postIDs) == 0 {
+		return map[uint][]models.RelatedPost{}, nil
+	}
+	return s.related.FindByPostIDs(ctx, postIDs, limit)
+}
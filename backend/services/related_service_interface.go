@@ -0,0 +1,15 @@
+package services
+
+import (
+	"blog/models"
+	"context"
+)
+
+type RelatedPostService interface {
+	// GetRelatedPosts は投稿に類似する投稿をスコアの高い順に最大 limit 件返す。
+	// 関連記事はバックグラウンドで計算するため、投稿直後は空になることがある。
+	GetRelatedPosts(ctx context.Context, postID uint, limit int) ([]models.RelatedPost, error)
+	// GetRelatedPostsByPostIDs は複数の投稿の関連記事を 1 回のクエリで投稿ごとに最大 limit 件返す。
+	// 存在しない投稿や関連記事のない投稿はマップに含めない。
+	GetRelatedPostsByPostIDs(ctx context.Context, postIDs []uint, limit int) (map[uint][]models.RelatedPost, error)
+}
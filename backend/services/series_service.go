@@ -0,0 +1,182 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type seriesService struct {
+	repo      repositories.SeriesRepository
+	uow       repositories.UnitOfWork
+	listeners []PostEventListener
+}
+
+// NewSeriesService は連載のサービスを生成する。投稿の一覧には連載の情報が含まれるため、
+// 連載の変更で表示が変わる投稿は listeners に PostUpdated として通知する (投稿のキャッシュの無効化など)。
+func NewSeriesService(uow repositories.UnitOfWork, listeners ...PostEventListener) SeriesService {
+	return &seriesService{repo: uow.Repositories().Series, uow: uow, listeners: listeners}
+}
+
+func (s *seriesService) GetAllSeries(ctx context.Context) ([]models.Series, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *seriesService) GetSeriesByID(ctx context.Context, id uint) (*models.Series, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *seriesService) CreateSeries(ctx context.Context, series *models.Series) error {
+	series.Entries = nil
+	series.CreatedAt = time.Now()
+	series.UpdatedAt = time.Now()
+	return s.repo.Create(ctx, series)
+}
+
+func (s *seriesService) UpdateSeries(ctx context.Context, id uint, seriesData models.Series) (*models.Series, error) {
+	var updated *models.Series
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		series, err := repos.Series.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		series.Title = seriesData.Title
+		series.Description = seriesData.Description
+		series.UpdatedAt = time.Now()
+		if err := repos.Series.Update(ctx, series); err != nil {
+			return err
+		}
+		updated = series
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(ctx, entryPostIDs(updated))
+	return updated, nil
+}
+
+func (s *seriesService) DeleteSeries(ctx context.Context, id uint) error {
+	var postIDs []uint
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		series, err := repos.Series.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		postIDs = entryPostIDs(series)
+		return repos.Series.Delete(ctx, series)
+	})
+	if err != nil {
+		return err
+	}
+	s.notify(ctx, postIDs)
+	return nil
+}
+
+func (s *seriesService) SetSeriesPosts(ctx context.Context, id uint, postIDs []uint) (*models.Series, error) {
+	seen := make(map[uint]bool, len(postIDs))
+	for _, postID := range postIDs {
+		if seen[postID] {
+			return nil, fmt.Errorf("%w: post %d appears more than once", ErrInvalidInput, postID)
+		}
+		seen[postID] = true
+	}
+
+	var (
+		result *models.Series
+		// 外れた回と加わった回の両方の表示が変わる
+		changed = slices.Clone(postIDs)
+	)
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repositories.Repositories) error {
+		series, err := repos.Series.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, postID := range entryPostIDs(series) {
+			if !seen[postID] {
+				changed = append(changed, postID)
+			}
+		}
+
+		for _, postID := range postIDs {
+			if _, err := repos.Posts.FindByID(ctx, postID); err != nil {
+				if errors.Is(err, ErrNotFound) {
+					return fmt.Errorf("%w: post %d does not exist", ErrInvalidInput, postID)
+				}
+				return err
+			}
+		}
+
+		if err := repos.Series.ReplaceEntries(ctx, id, postIDs); err != nil {
+			return err
+		}
+
+		// 構成の変更で ETag が変わるよう更新日時を進める
+		series.UpdatedAt = time.Now()
+		if err := repos.Series.Update(ctx, series); err != nil {
+			return err
+		}
+
+		result, err = repos.Series.FindByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(ctx, changed)
+	return result, nil
+}
+
+func (s *seriesService) GetNavigation(ctx context.Context, postID uint) (*models.SeriesNavigation, error) {
+	series, err := s.repo.FindByPostID(ctx, postID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buildNavigation(series, postID), nil
+}
+
+// notify は連載の変更で表示が変わった投稿をリスナーに知らせる
+func (s *seriesService) notify(ctx context.Context, postIDs []uint) {
+	for _, postID := range postIDs {
+		for _, listener := range s.listeners {
+			listener(ctx, PostEvent{Type: PostUpdated, PostID: postID})
+		}
+	}
+}
+
+// entryPostIDs は連載の各回の投稿の ID を返す
+func entryPostIDs(series *models.Series) []uint {
+	ids := make([]uint, len(series.Entries))
+	for i, entry := range series.Entries {
+		ids[i] = entry.PostID
+	}
+	return ids
+}
+
+// buildNavigation は公開済みの回の中での位置と前後の回を返す。投稿が下書きで Entries にない場合は nil を返す
+func buildNavigation(series *models.Series, postID uint) *models.SeriesNavigation {
+	for i, entry := range series.Entries {
+		if entry.PostID != postID {
+			continue
+		}
+		nav := &models.SeriesNavigation{ID: series.ID, Title: series.Title, Position: i + 1, Total: len(series.Entries), UpdatedAt: series.UpdatedAt}
+		if i > 0 {
+			prev := series.Entries[i-1]
+			nav.Previous = &models.SeriesLink{ID: prev.PostID, Title: prev.Post.Title}
+		}
+		if i < len(series.Entries)-1 {
+			next := series.Entries[i+1]
+			nav.Next = &models.SeriesLink{ID: next.PostID, Title: next.Post.Title}
+		}
+		return nav
+	}
+	return nil
+}
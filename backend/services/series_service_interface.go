@@ -0,0 +1,18 @@
+package services
+
+import (
+	"blog/models"
+	"context"
+)
+
+type SeriesService interface {
+	GetAllSeries(ctx context.Context) ([]models.Series, error)
+	GetSeriesByID(ctx context.Context, id uint) (*models.Series, error)
+	CreateSeries(ctx context.Context, series *models.Series) error
+	UpdateSeries(ctx context.Context, id uint, seriesData models.Series) (*models.Series, error)
+	DeleteSeries(ctx context.Context, id uint) error
+	// SetSeriesPosts は連載の各回を postIDs の順に設定する
+	SetSeriesPosts(ctx context.Context, id uint, postIDs []uint) (*models.Series, error)
+	// GetNavigation は投稿が属する連載と前後の回を公開済みの回から返す。連載に属していない場合や投稿が下書きの場合は nil を返す
+	GetNavigation(ctx context.Context, postID uint) (*models.SeriesNavigation, error)
+}
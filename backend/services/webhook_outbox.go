@@ -0,0 +1,49 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// enqueueWebhooks は投稿の変更を Webhook の送信待ちのイベント (outbox) として保存する。
+// 投稿の書き込みと同じトランザクションで呼び、書き込みがロールバックされた場合はイベントも残さない。
+// Webhooks リポジトリがない場合 (メモリ実装など) は何もしない。
+func enqueueWebhooks(ctx context.Context, repos repositories.Repositories, post *models.Post, types ...PostEventType) error {
+	if repos.Webhooks == nil {
+		return nil
+	}
+	for _, eventType := range types {
+		event, err := newWebhookEvent(eventType, post)
+		if err != nil {
+			return err
+		}
+		if err := repos.Webhooks.Enqueue(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newWebhookEvent(eventType PostEventType, post *models.Post) (*models.WebhookEvent, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook event ID: %w", err)
+	}
+	payload := models.WebhookPayload{
+		ID:        "evt_" + hex.EncodeToString(id),
+		Type:      string(eventType),
+		CreatedAt: time.Now().UTC(),
+		Post:      *post,
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return &models.WebhookEvent{ID: payload.ID, Type: payload.Type, Payload: string(data), CreatedAt: payload.CreatedAt}, nil
+}
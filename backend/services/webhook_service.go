@@ -0,0 +1,138 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repositories"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const (
+	// maxWebhookURLLength と maxWebhookSecretLength は models.WebhookSubscription のカラム長
+	maxWebhookURLLength    = 2000
+	maxWebhookSecretLength = 255
+	// recentDeliveries は配信の一覧で返す件数
+	recentDeliveries = 100
+)
+
+type webhookService struct {
+	repo repositories.WebhookRepository
+	// wake は送り直す配信ができたことを送信のジョブに知らせる。nil の場合は次のポーリングを待つ
+	wake func()
+}
+
+// NewWebhookService は WebhookService を生成する。wake は配信を送り直す時に呼ぶ (nil でもよい)
+func NewWebhookService(uow repositories.UnitOfWork, wake func()) WebhookService {
+	return &webhookService{repo: uow.Repositories().Webhooks, wake: wake}
+}
+
+func (s *webhookService) GetAllSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.FindAllSubscriptions(ctx)
+}
+
+func (s *webhookService) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	return s.repo.FindSubscriptionByID(ctx, id)
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	if subscription.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		subscription.Secret = "whsec_" + hex.EncodeToString(secret)
+	}
+	if err := validateSubscription(subscription); err != nil {
+		return err
+	}
+	subscription.ID = 0
+	subscription.CreatedAt = time.Now()
+	subscription.UpdatedAt = time.Now()
+	return s.repo.CreateSubscription(ctx, subscription)
+}
+
+func (s *webhookService) UpdateSubscription(ctx context.Context, id uint, data models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	subscription, err := s.repo.FindSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subscription.URL = data.URL
+	subscription.Events = data.Events
+	subscription.Active = data.Active
+	if data.Secret != "" {
+		subscription.Secret = data.Secret
+	}
+	if err := validateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	subscription.UpdatedAt = time.Now()
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, id uint) error {
+	subscription, err := s.repo.FindSubscriptionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, subscription)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, subscriptionID uint) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.FindSubscriptionByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeliveries(ctx, subscriptionID, recentDeliveries)
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	return s.repo.FindDeliveryByID(ctx, subscriptionID, deliveryID)
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.FindDeliveryByID(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	// 再試行の回数を数え直し、上限に達するまで自動の再試行も行う
+	delivery.Status = models.WebhookPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	if s.wake != nil {
+		s.wake()
+	}
+	return delivery, nil
+}
+
+// validateSubscription は送信先の URL とイベントの種類を検証する
+func validateSubscription(subscription *models.WebhookSubscription) error {
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidInput)
+	}
+	if len(subscription.URL) > maxWebhookURLLength {
+		return fmt.Errorf("%w: url must be at most %d characters", ErrInvalidInput, maxWebhookURLLength)
+	}
+	if len(subscription.Secret) > maxWebhookSecretLength {
+		return fmt.Errorf("%w: secret must be at most %d characters", ErrInvalidInput, maxWebhookSecretLength)
+	}
+	for _, event := range subscription.Events {
+		if !slices.Contains(PostEventTypes, PostEventType(event)) {
+			return fmt.Errorf("%w: unknown event %q (allowed: %v)", ErrInvalidInput, event, PostEventTypes)
+		}
+	}
+	events := slices.Clone(subscription.Events)
+	slices.Sort(events)
+	subscription.Events = slices.Compact(events)
+	return nil
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"blog/models"
+	"context"
+)
+
+type WebhookService interface {
+	GetAllSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	// CreateSubscription は送信先を登録する。Secret が空の場合は生成する
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	// UpdateSubscription は URL、イベントの種類と有効かどうかを更新する。Secret が空でない場合は鍵も置き換える
+	UpdateSubscription(ctx context.Context, id uint, data models.WebhookSubscription) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	// GetDeliveries は送信先の最近の配信を新しい順に返す
+	GetDeliveries(ctx context.Context, subscriptionID uint) ([]models.WebhookDelivery, error)
+	// GetDelivery は配信を配信ログを含めて返す
+	GetDelivery(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error)
+	// Redeliver は配信をすぐに送り直す。成功した配信や再試行の上限に達した配信も送り直せる
+	Redeliver(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error)
+}
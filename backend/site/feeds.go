@@ -0,0 +1,161 @@
+package site
+
+import (
+	"blog/models"
+	"encoding/xml"
+	"time"
+)
+
+// feedSize はフィードに含める新しい投稿の数
+const feedSize = 20
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title     string         `xml:"title"`
+	ID        string         `xml:"id"`
+	Link      atomLink       `xml:"link"`
+	Published string         `xml:"published"`
+	Updated   string         `xml:"updated"`
+	Author    *atomAuthor    `xml:"author,omitempty"`
+	Summary   string         `xml:"summary,omitempty"`
+	Category  []atomCategory `xml:"category"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type sitemap struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// FeedFiles はフィードとサイトマップのファイル名 (サイトのルートからのパス)
+var FeedFiles = []string{"feed.xml", "atom.xml", "sitemap.xml"}
+
+// Feeds は公開されている投稿 (新しい順) から RSS、Atom とサイトマップを生成する。キーは FeedFiles のファイル名。
+// 日時は投稿の更新日時から決め、投稿が変わらなければ同じ内容になるようにする
+func (r *Renderer) Feeds(paths Paths, posts []models.Post) (map[string][]byte, error) {
+	var updated time.Time
+	for _, post := range posts {
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+	}
+	recent := posts[:min(len(posts), feedSize)]
+
+	rss := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:       r.cfg.Title,
+		Link:        r.urls.Abs("/"),
+		Description: r.cfg.Description,
+	}}
+	atom := atomFeed{
+		Title: r.cfg.Title,
+		ID:    r.urls.Abs("/"),
+		Links: []atomLink{{Rel: "alternate", Href: r.urls.Abs("/")}, {Rel: "self", Href: r.urls.Abs("/atom.xml")}},
+	}
+	if !updated.IsZero() {
+		rss.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+		atom.Updated = updated.UTC().Format(time.RFC3339)
+	}
+	for _, post := range recent {
+		link := r.urls.Abs(paths.Post(post.ID))
+		var tags []string
+		for _, tag := range post.Tags {
+			tags = append(tags, tag.Name)
+		}
+		rss.Channel.Items = append(rss.Channel.Items, rssItem{
+			Title:       post.Title,
+			Link:        link,
+			GUID:        link,
+			PubDate:     post.CreatedAt.UTC().Format(time.RFC1123Z),
+			Description: summary(post),
+			Categories:  tags,
+		})
+		entry := atomEntry{
+			Title:     post.Title,
+			ID:        link,
+			Link:      atomLink{Rel: "alternate", Href: link},
+			Published: post.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   post.UpdatedAt.UTC().Format(time.RFC3339),
+			Summary:   summary(post),
+		}
+		if post.Author != "" {
+			entry.Author = &atomAuthor{Name: post.Author}
+		}
+		for _, tag := range tags {
+			entry.Category = append(entry.Category, atomCategory{Term: tag})
+		}
+		atom.Entries = append(atom.Entries, entry)
+	}
+
+	urls := sitemap{URLs: []sitemapURL{{Loc: r.urls.Abs("/"), LastMod: lastMod(updated)}}}
+	for _, post := range posts {
+		urls.URLs = append(urls.URLs, sitemapURL{Loc: r.urls.Abs(paths.Post(post.ID)), LastMod: lastMod(post.UpdatedAt)})
+	}
+	for _, list := range Lists(posts)[1:] {
+		urls.URLs = append(urls.URLs, sitemapURL{Loc: r.urls.Abs(list.Path)})
+	}
+
+	files := make(map[string][]byte, len(FeedFiles))
+	for file, value := range map[string]any{"feed.xml": rss, "atom.xml": atom, "sitemap.xml": urls} {
+		data, err := xml.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[file] = append([]byte(xml.Header), append(data, '\n')...)
+	}
+	return files, nil
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02")
+}
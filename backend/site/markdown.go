@@ -0,0 +1,30 @@
+package site
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/html"
+)
+
+// MarkdownToHTML は投稿の Markdown を HTML に変換する。投稿の本文は外部から入力されるため、
+// 本文中の生の HTML は出力せず、javascript: などのスキームのリンクはテキストにする
+func MarkdownToHTML(content string) []byte {
+	renderer := html.NewRenderer(html.RendererOptions{Flags: html.CommonFlags | html.SkipHTML | html.Safelink})
+	renderer.IsSafeURLOverride = isSafeURL
+	return markdown.ToHTML([]byte(content), nil, renderer)
+}
+
+// isSafeURL はリンク先が相対 URL か http、https、mailto のいずれかかを返す
+func isSafeURL(dest []byte) bool {
+	u, err := url.Parse(strings.TrimSpace(string(dest)))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package site
+
+import (
+	"blog/models"
+	"cmp"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// URLs はサイト内のパスからリンクと絶対 URL を作る。
+// サイト内のパスはエスケープしていないもの (例: /tags/ブログ) で、静的サイトのファイルの配置と対応する。
+type URLs struct {
+	base     string
+	basePath string
+}
+
+// NewURLs は公開サイトの URL (例: https://blog.example.com/blog) から URLs を生成する
+func NewURLs(baseURL string) (URLs, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return URLs{}, fmt.Errorf("invalid site base URL %q", baseURL)
+	}
+	return URLs{base: strings.TrimSuffix(baseURL, "/"), basePath: strings.TrimSuffix(base.Path, "/")}, nil
+}
+
+// Link はサイト内のパスをリンクにする。BaseURL にパスがある場合はその下に置く
+func (u URLs) Link(p string) string {
+	return u.basePath + (&url.URL{Path: p}).EscapedPath()
+}
+
+// Abs はサイト内のパスを絶対 URL にする。http(s) の URL はそのまま返す
+func (u URLs) Abs(p string) string {
+	if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+		return p
+	}
+	return u.base + (&url.URL{Path: p}).EscapedPath()
+}
+
+// Paths は公開されている投稿ごとのパス。slug があれば /posts/<slug>、なければ /posts/<ID>。
+// 同じ slug の投稿が複数ある場合は ID の小さい投稿だけが slug を使う。
+type Paths struct {
+	posts map[uint]string
+	slugs map[string]uint
+}
+
+// NewPaths は公開されている投稿のパスを決める
+func NewPaths(posts []models.Post) Paths {
+	paths := Paths{posts: make(map[uint]string, len(posts)), slugs: make(map[string]uint)}
+	sorted := slices.Clone(posts)
+	slices.SortFunc(sorted, func(a, b models.Post) int { return cmp.Compare(a.ID, b.ID) })
+	for _, post := range sorted {
+		if !usableSlug(post.Slug) {
+			continue
+		}
+		if _, ok := paths.slugs[post.Slug]; !ok {
+			paths.slugs[post.Slug] = post.ID
+			paths.posts[post.ID] = "/posts/" + post.Slug
+		}
+	}
+	for _, post := range posts {
+		if _, ok := paths.posts[post.ID]; !ok {
+			paths.posts[post.ID] = "/posts/" + strconv.FormatUint(uint64(post.ID), 10)
+		}
+	}
+	return paths
+}
+
+// usableSlug は slug をパスに使えるかを返す。数字だけの slug は他の投稿の ID と区別できないため使わない
+func usableSlug(slug string) bool {
+	if slug == "" || slug != pathSegment(slug) {
+		return false
+	}
+	_, err := strconv.ParseUint(slug, 10, 0)
+	return err != nil
+}
+
+// Post は投稿のパス
+func (p Paths) Post(id uint) string {
+	if path, ok := p.posts[id]; ok {
+		return path
+	}
+	return "/posts/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// Find は /posts/<slug> の slug (または ID) から公開されている投稿の ID を探す
+func (p Paths) Find(slug string) (uint, bool) {
+	if id, ok := p.slugs[slug]; ok {
+		return id, true
+	}
+	id, err := strconv.ParseUint(slug, 10, 0)
+	if err != nil {
+		return 0, false
+	}
+	_, ok := p.posts[uint(id)]
+	return uint(id), ok
+}
+
+// TagPath はタグの一覧のパス
+func TagPath(name string) string {
+	return "/tags/" + pathSegment(name)
+}
+
+// ArchivePath は月別アーカイブのパス
+func ArchivePath(year int, month time.Month) string {
+	return fmt.Sprintf("/archive/%04d/%02d", year, month)
+}
+
+// PagePath は一覧の page ページ目のパス。1 ページ目は base
+func PagePath(base string, page int) string {
+	if page <= 1 {
+		return base
+	}
+	return path.Join(base, "page", strconv.Itoa(page))
+}
+
+// pathSegment はパスの 1 要素として使える文字列にする。区切りや URL で特別な意味を持つ文字は - にする
+func pathSegment(name string) string {
+	segment := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\?#%`, r) || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if segment == "." || segment == ".." {
+		segment = strings.Repeat("-", len(segment))
+	}
+	return segment
+}
@@ -0,0 +1,342 @@
+package site
+
+import (
+	"blog/config"
+	"blog/frontmatter"
+	"blog/models"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Meta は <head> に出力するページの説明 (OpenGraph、Twitter カード、canonical、JSON-LD)
+type Meta struct {
+	Title       string
+	Description string
+	// Canonical はページの絶対 URL
+	Canonical string
+	// Type は og:type (website または article)
+	Type string
+	// Image は絶対 URL のカバー画像
+	Image     string
+	Published time.Time
+	Modified  time.Time
+	Author    string
+	Tags      []string
+	// JSONLD は投稿のページの schema.org BlogPosting。html/template が <script> の中で JSON にエンコードする
+	JSONLD *BlogPosting
+}
+
+// BlogPosting は schema.org の BlogPosting (https://schema.org/BlogPosting)
+type BlogPosting struct {
+	Context          string     `json:"@context"`
+	Type             string     `json:"@type"`
+	Headline         string     `json:"headline"`
+	Description      string     `json:"description,omitempty"`
+	URL              string     `json:"url"`
+	MainEntityOfPage string     `json:"mainEntityOfPage"`
+	Image            string     `json:"image,omitempty"`
+	DatePublished    string     `json:"datePublished"`
+	DateModified     string     `json:"dateModified"`
+	Author           *Person    `json:"author,omitempty"`
+	Keywords         []string   `json:"keywords,omitempty"`
+	Publisher        *Publisher `json:"publisher,omitempty"`
+}
+
+type Person struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type Publisher struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Page はテーマのテンプレートに渡す値
+type Page struct {
+	Site config.SiteConfig
+	// Title は <title>
+	Title string
+	Meta  Meta
+
+	// 一覧。Heading はタグやアーカイブの見出しで、トップページでは空
+	Heading string
+	Posts   []models.Post
+	Page    int
+	PrevURL string
+	NextURL string
+
+	// 投稿
+	Post    *models.Post
+	Content template.HTML
+
+	urls  URLs
+	paths Paths
+}
+
+// Link はサイト内のパスをリンクにする
+func (p *Page) Link(path string) string {
+	return p.urls.Link(path)
+}
+
+// PostURL は投稿へのリンク
+func (p *Page) PostURL(post models.Post) string {
+	return p.urls.Link(p.paths.Post(post.ID))
+}
+
+// TagURL はタグの一覧へのリンク
+func (p *Page) TagURL(name string) string {
+	return p.urls.Link(TagPath(name))
+}
+
+// ArchiveURL は t の月のアーカイブへのリンク
+func (p *Page) ArchiveURL(t time.Time) string {
+	t = t.UTC()
+	return p.urls.Link(ArchivePath(t.Year(), t.Month()))
+}
+
+// List は投稿の一覧 (トップ、タグ、月別アーカイブ)
+type List struct {
+	// Path は 1 ページ目のパス
+	Path    string
+	Heading string
+	Posts   []models.Post
+}
+
+// Published は公開されている (下書きでない) 投稿を新しい順に返す
+func Published(posts []models.Post) []models.Post {
+	posts = slices.DeleteFunc(slices.Clone(posts), func(post models.Post) bool { return post.Draft })
+	slices.SortFunc(posts, func(a, b models.Post) int {
+		if n := b.CreatedAt.Compare(a.CreatedAt); n != 0 {
+			return n
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return posts
+}
+
+// TopList はトップページの一覧
+func TopList(posts []models.Post) List {
+	return List{Path: "/", Posts: posts}
+}
+
+// TagList はパスの要素が segment のタグの一覧。該当する投稿がなければ false
+func TagList(posts []models.Post, segment string) (List, bool) {
+	for _, name := range tagsOf(posts) {
+		if pathSegment(name) == segment {
+			return tagList(posts, name), true
+		}
+	}
+	return List{}, false
+}
+
+func tagList(posts []models.Post, name string) List {
+	tagged := slices.DeleteFunc(slices.Clone(posts), func(post models.Post) bool {
+		return !slices.ContainsFunc(post.Tags, func(t models.Tag) bool { return t.Name == name })
+	})
+	return List{Path: TagPath(name), Heading: "#" + name, Posts: tagged}
+}
+
+// ArchiveList は year 年 month 月 (UTC) に投稿した投稿の一覧。該当する投稿がなければ false
+func ArchiveList(posts []models.Post, year int, month time.Month) (List, bool) {
+	monthly := slices.DeleteFunc(slices.Clone(posts), func(post models.Post) bool {
+		created := post.CreatedAt.UTC()
+		return created.Year() != year || created.Month() != month
+	})
+	if len(monthly) == 0 {
+		return List{}, false
+	}
+	return List{Path: ArchivePath(year, month), Heading: fmt.Sprintf("%d年%d月", year, month), Posts: monthly}, true
+}
+
+// Lists はトップ、全てのタグ、投稿のある全ての月の一覧
+func Lists(posts []models.Post) []List {
+	lists := []List{TopList(posts)}
+	for _, name := range tagsOf(posts) {
+		lists = append(lists, tagList(posts, name))
+	}
+	var months []time.Time
+	for _, post := range posts {
+		created := post.CreatedAt.UTC()
+		month := time.Date(created.Year(), created.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if !slices.ContainsFunc(months, month.Equal) {
+			months = append(months, month)
+		}
+	}
+	for _, month := range months {
+		list, _ := ArchiveList(posts, month.Year(), month.Month())
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+func tagsOf(posts []models.Post) []string {
+	var tags []string
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			if !slices.Contains(tags, tag.Name) {
+				tags = append(tags, tag.Name)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// summary は一覧やフィードに表示する説明。フロントマターの description がなければ抜粋
+func summary(post models.Post) string {
+	if post.Description != "" {
+		return post.Description
+	}
+	return post.Excerpt
+}
+
+// Renderer はテーマで一覧と投稿のページを描画する。静的サイトの書き出しとサーバーでの描画で共通に使う
+type Renderer struct {
+	cfg   config.SiteConfig
+	urls  URLs
+	theme *Theme
+}
+
+// NewRenderer は設定のテーマ (ThemeDir が空の場合は既定のテーマ) で描画する Renderer を生成する
+func NewRenderer(cfg config.SiteConfig) (*Renderer, error) {
+	urls, err := NewURLs(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PageSize <= 0 {
+		return nil, fmt.Errorf("invalid page size %d", cfg.PageSize)
+	}
+	theme, err := LoadTheme(cfg.ThemeDir, cfg.ThemeReload)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return &Renderer{cfg: cfg, urls: urls, theme: theme}, nil
+}
+
+// URLs はサイト内のパスからリンクと絶対 URL を作る
+func (r *Renderer) URLs() URLs {
+	return r.urls
+}
+
+// Static はテーマの静的ファイル
+func (r *Renderer) Static() fs.FS {
+	return r.theme.Static()
+}
+
+// Signature は出力に影響するテーマと設定のハッシュ
+func (r *Renderer) Signature() (string, error) {
+	theme, err := r.theme.Signature()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, generatorVersion, r.cfg.BaseURL, r.cfg.Title, r.cfg.Description, r.cfg.PageSize, theme)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// PageCount は一覧のページ数。投稿がなくても 1 ページはある
+func (r *Renderer) PageCount(list List) int {
+	return max((len(list.Posts)+r.cfg.PageSize-1)/r.cfg.PageSize, 1)
+}
+
+// RenderList は一覧の page ページ目を描画する
+func (r *Renderer) RenderList(w io.Writer, paths Paths, list List, page int) error {
+	pages := r.PageCount(list)
+	if page < 1 || page > pages {
+		return fmt.Errorf("page %d is out of range (1-%d)", page, pages)
+	}
+	title := r.cfg.Title
+	if list.Heading != "" {
+		title = list.Heading + " - " + title
+	}
+	if page > 1 {
+		title = fmt.Sprintf("%s (%d ページ目)", title, page)
+	}
+	data := &Page{
+		Site:  r.cfg,
+		Title: title,
+		Meta: Meta{
+			Title:       title,
+			Description: r.cfg.Description,
+			Canonical:   r.urls.Abs(PagePath(list.Path, page)),
+			Type:        "website",
+		},
+		Heading: list.Heading,
+		Posts:   list.Posts[(page-1)*r.cfg.PageSize : min(page*r.cfg.PageSize, len(list.Posts))],
+		Page:    page,
+		urls:    r.urls,
+		paths:   paths,
+	}
+	if page > 1 {
+		data.PrevURL = r.urls.Link(PagePath(list.Path, page-1))
+	}
+	if page < pages {
+		data.NextURL = r.urls.Link(PagePath(list.Path, page+1))
+	}
+	return r.theme.Render(w, "list.html", data)
+}
+
+// RenderPost は投稿のページを描画する
+func (r *Renderer) RenderPost(w io.Writer, paths Paths, post *models.Post) error {
+	// フロントマターは保存時に取り除くが、それ以前に保存された投稿の分もここで除く
+	content := post.Content
+	if _, body, err := frontmatter.Parse(content); err == nil {
+		content = body
+	}
+	data := &Page{
+		Site:    r.cfg,
+		Title:   post.Title + " - " + r.cfg.Title,
+		Meta:    r.postMeta(paths, post),
+		Post:    post,
+		Content: template.HTML(MarkdownToHTML(content)),
+		urls:    r.urls,
+		paths:   paths,
+	}
+	return r.theme.Render(w, "post.html", data)
+}
+
+func (r *Renderer) postMeta(paths Paths, post *models.Post) Meta {
+	meta := Meta{
+		Title:       post.Title,
+		Description: summary(*post),
+		Canonical:   r.urls.Abs(paths.Post(post.ID)),
+		Type:        "article",
+		Published:   post.CreatedAt,
+		Modified:    post.UpdatedAt,
+		Author:      post.Author,
+	}
+	if post.CoverImage != "" {
+		meta.Image = r.urls.Abs(post.CoverImage)
+	}
+	for _, tag := range post.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	meta.JSONLD = &BlogPosting{
+		Context:          "https://schema.org",
+		Type:             "BlogPosting",
+		Headline:         meta.Title,
+		Description:      meta.Description,
+		URL:              meta.Canonical,
+		MainEntityOfPage: meta.Canonical,
+		Image:            meta.Image,
+		DatePublished:    post.CreatedAt.UTC().Format(time.RFC3339),
+		DateModified:     post.UpdatedAt.UTC().Format(time.RFC3339),
+		Keywords:         meta.Tags,
+		Publisher:        &Publisher{Type: "Organization", Name: r.cfg.Title, URL: r.urls.Abs("/")},
+	}
+	if post.Author != "" {
+		meta.JSONLD.Author = &Person{Type: "Person", Name: post.Author}
+	}
+	return meta
+}
@@ -0,0 +1,289 @@
+// Package site は公開されている投稿をテーマ (html/template) で描画する。
+// 同じ URL の構成でサーバーが HTML を返すことも、静的サイトとして書き出すこともできる。
+//
+// URL と静的サイトのファイルの構成:
+//
+//	/, /page/<N>                         index.html, page/<N>/index.html  投稿の一覧
+//	/posts/<slug または ID>              posts/<slug>/index.html          投稿
+//	/tags/<タグ>, /tags/<タグ>/page/<N>  tags/<タグ>/index.html, ...      タグごとの一覧
+//	/archive/<年>/<月>, .../page/<N>     archive/<年>/<月>/index.html, ... 月別アーカイブ
+//	/feed.xml (RSS 2.0), /atom.xml, /sitemap.xml
+//	/static/...                          テーマの静的ファイル
+//
+// 前回のビルドの記録 (.build.json) と比べて変更のない投稿は描画し直さず、
+// 内容の変わらないファイルは書き込まない (CDN への同期で更新日時が変わらないようにする)。
+// メディアファイルはこのブログに存在しないため、コピーするのは static/ の CSS だけ。
+package site
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/repositories"
+	"blog/services"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// generatorVersion は出力に影響する変更をしたら上げる。変わると全ての投稿を描画し直す
+const generatorVersion = 2
+
+// manifestFile は前回のビルドの記録
+const manifestFile = ".build.json"
+
+// Result はビルドの結果
+type Result struct {
+	// Rendered は描画した投稿の数、Unchanged は前回から変更がなく描画しなかった投稿の数
+	Rendered  int
+	Unchanged int
+	// Written は内容が変わって書き込んだファイルの数、Removed は不要になって削除したファイルの数
+	Written int
+	Removed int
+}
+
+// manifest は前回のビルドで書き出したファイルと、投稿ごとの描画に使った内容のハッシュ
+type manifest struct {
+	Signature string
+	Posts     map[uint]string
+	Files     []string
+}
+
+type Builder struct {
+	posts    services.PostService
+	renderer *Renderer
+	dir      string
+}
+
+// NewBuilder は dir に静的サイトを書き出す Builder を生成する
+func NewBuilder(posts services.PostService, cfg config.SiteConfig, dir string) (*Builder, error) {
+	renderer, err := NewRenderer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{posts: posts, renderer: renderer, dir: dir}, nil
+}
+
+// build は 1 回のビルドの状態
+type build struct {
+	*Builder
+	paths    Paths
+	result   Result
+	previous manifest
+	current  manifest
+	written  map[string]bool
+}
+
+// Build は公開されている (下書きでない) 投稿から静的サイトを書き出す。full の場合は変更のない投稿も描画し直す
+func (b *Builder) Build(ctx context.Context, full bool) (*Result, error) {
+	posts, err := b.posts.GetAllPosts(ctx, services.PostQuery{Include: []string{repositories.IncludeTags, repositories.IncludeSeries}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posts: %w", err)
+	}
+	posts = Published(posts)
+	signature, err := b.renderer.Signature()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &build{
+		Builder: b,
+		paths:   NewPaths(posts),
+		current: manifest{Signature: signature, Posts: make(map[uint]string)},
+		written: make(map[string]bool),
+	}
+	if err := s.loadManifest(); err != nil {
+		return nil, err
+	}
+	// テーマや設定が変わった場合は全ての投稿を描画し直す
+	if full || s.previous.Signature != s.current.Signature {
+		s.previous.Posts = nil
+	}
+
+	for i := range posts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := s.writePost(&posts[i]); err != nil {
+			return nil, err
+		}
+	}
+	for _, list := range Lists(posts) {
+		if err := s.writeList(list); err != nil {
+			return nil, err
+		}
+	}
+	feeds, err := b.renderer.Feeds(s.paths, posts)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range FeedFiles {
+		if err := s.write(file, feeds[file]); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.copyStatic(); err != nil {
+		return nil, err
+	}
+	if err := s.removeStale(); err != nil {
+		return nil, err
+	}
+	if err := s.saveManifest(); err != nil {
+		return nil, err
+	}
+	return &s.result, nil
+}
+
+// fingerprint は投稿のページに表示する内容のハッシュ
+func fingerprint(post *models.Post, postPath string) string {
+	h := sha256.New()
+	fmt.Fprintln(h, post.ID, postPath, post.UpdatedAt.UTC().Format(time.RFC3339Nano), post.CreatedAt.UTC().Format(time.RFC3339Nano))
+	for _, tag := range post.Tags {
+		fmt.Fprintln(h, "tag", tag.Name)
+	}
+	if post.Series != nil {
+		fmt.Fprintln(h, "series", post.Series.Title, post.Series.Position)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// indexFile はサイト内のパスを表示するファイル
+func indexFile(p string) string {
+	return strings.TrimPrefix(path.Join(p, "index.html"), "/")
+}
+
+func (s *build) writePost(post *models.Post) error {
+	postPath := s.paths.Post(post.ID)
+	file := indexFile(postPath)
+	hash := fingerprint(post, postPath)
+	s.current.Posts[post.ID] = hash
+	if s.previous.Posts[post.ID] == hash && s.exists(file) {
+		s.written[file] = true
+		s.result.Unchanged++
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := s.renderer.RenderPost(&buf, s.paths, post); err != nil {
+		return fmt.Errorf("failed to render %s: %w", file, err)
+	}
+	if err := s.write(file, buf.Bytes()); err != nil {
+		return err
+	}
+	s.result.Rendered++
+	return nil
+}
+
+// writeList は投稿の一覧をページに分けて書き出す。投稿がなくても 1 ページ目は書き出す
+func (s *build) writeList(list List) error {
+	for page := 1; page <= s.renderer.PageCount(list); page++ {
+		file := indexFile(PagePath(list.Path, page))
+		var buf bytes.Buffer
+		if err := s.renderer.RenderList(&buf, s.paths, list, page); err != nil {
+			return fmt.Errorf("failed to render %s: %w", file, err)
+		}
+		if err := s.write(file, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *build) copyStatic() error {
+	static := s.renderer.Static()
+	return fs.WalkDir(static, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(static, name)
+		if err != nil {
+			return err
+		}
+		return s.write(path.Join("static", name), data)
+	})
+}
+
+// write は出力ディレクトリからの相対パス file に書き込む。内容が同じ場合は書き込まない
+func (s *build) write(file string, data []byte) error {
+	file = strings.TrimPrefix(file, "/")
+	s.written[file] = true
+	name := filepath.Join(s.dir, filepath.FromSlash(file))
+	if existing, err := os.ReadFile(name); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		return err
+	}
+	s.result.Written++
+	return nil
+}
+
+func (s *build) exists(file string) bool {
+	_, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(file)))
+	return err == nil
+}
+
+// removeStale は前回書き出して今回は書き出さなかったファイル (削除した投稿や減ったページ) を削除する
+func (s *build) removeStale() error {
+	for file := range s.written {
+		s.current.Files = append(s.current.Files, file)
+	}
+	slices.Sort(s.current.Files)
+
+	for _, file := range s.previous.Files {
+		if s.written[file] {
+			continue
+		}
+		name := filepath.Join(s.dir, filepath.FromSlash(file))
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		s.result.Removed++
+		// 空になったディレクトリも消す。空でなければ失敗するので、そこで止める
+		for dir := filepath.Dir(name); dir != filepath.Clean(s.dir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *build) loadManifest() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, manifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &s.previous); err != nil {
+		return fmt.Errorf("invalid %s: %w", manifestFile, err)
+	}
+	// 出力ディレクトリの外を指すパスは信用しない
+	s.previous.Files = slices.DeleteFunc(s.previous.Files, func(file string) bool {
+		return !fs.ValidPath(file)
+	})
+	return nil
+}
+
+func (s *build) saveManifest() error {
+	data, err := json.MarshalIndent(s.current, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, manifestFile), append(data, '\n'), 0o644)
+}
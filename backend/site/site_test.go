@@ -0,0 +1,128 @@
+package site_test
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/repositories"
+	"blog/services"
+	"blog/site"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func assertFiles(t *testing.T, dir string, exist bool, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		assert.Equal(t, exist, err == nil, name)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	posts := services.NewPostService(repositories.NewMemoryPostRepository())
+	first := &models.Post{Title: "First", Content: "# Hello", Tags: []models.Tag{{Name: "go"}}}
+	second := &models.Post{Title: "Second <b>", Content: "second", Slug: "second", Description: "About the second post", Tags: []models.Tag{{Name: "go"}, {Name: "ブログ"}}}
+	draft := &models.Post{Title: "Draft", Content: "draft", Draft: true}
+	for _, post := range []*models.Post{first, second, draft} {
+		require.NoError(t, posts.CreatePost(ctx, post))
+	}
+	archive := first.CreatedAt.UTC().Format("2006/01")
+
+	dir := t.TempDir()
+	builder, err := site.NewBuilder(posts, config.SiteConfig{BaseURL: "https://example.com/blog/", Title: "Example", PageSize: 1}, dir)
+	require.NoError(t, err)
+
+	result, err := builder.Build(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rendered)
+	assertFiles(t, dir, true,
+		"index.html", "page/2/index.html", "posts/1/index.html", "posts/second/index.html",
+		"tags/go/index.html", "tags/go/page/2/index.html", "tags/ブログ/index.html",
+		"archive/"+archive+"/index.html", "archive/"+archive+"/page/2/index.html",
+		"feed.xml", "atom.xml", "sitemap.xml", "static/style.css")
+	assertFiles(t, dir, false, "posts/2/index.html", "posts/3/index.html", "page/3/index.html")
+
+	post := readFile(t, dir, "posts/1/index.html")
+	assert.Contains(t, post, "<h1>Hello</h1>")
+	assert.Contains(t, post, `<link rel="canonical" href="https://example.com/blog/posts/1">`)
+	assert.Contains(t, post, `href="/blog/tags/go"`)
+	assert.Contains(t, post, `href="/blog/archive/`+archive+`"`)
+	index := readFile(t, dir, "index.html")
+	assert.Contains(t, index, "Second &lt;b&gt;")
+	assert.Contains(t, index, "About the second post")
+	assert.Contains(t, index, `href="/blog/posts/second"`)
+	assert.Contains(t, index, `href="/blog/page/2"`)
+	assert.Contains(t, index, `href="/blog/tags/%E3%83%96%E3%83%AD%E3%82%B0"`)
+	feed := readFile(t, dir, "feed.xml")
+	assert.Contains(t, feed, "<link>https://example.com/blog/posts/second</link>")
+	assert.NotContains(t, feed, "Draft")
+	sitemap := readFile(t, dir, "sitemap.xml")
+	assert.Contains(t, sitemap, "<loc>https://example.com/blog/tags/go</loc>")
+	assert.Contains(t, sitemap, "<loc>https://example.com/blog/archive/"+archive+"</loc>")
+
+	// 変更がなければ何も書き込まない
+	result, err = builder.Build(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, site.Result{Unchanged: 2}, *result)
+
+	// 変更した投稿だけを描画し直し、削除した投稿と減ったページを消す
+	require.NoError(t, posts.UpdatePost(ctx, second.ID, models.Post{Title: "Second updated", Content: "updated", Slug: "second", Tags: []models.Tag{{Name: "go"}}}))
+	require.NoError(t, posts.DeletePost(ctx, first.ID))
+	result, err = builder.Build(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rendered)
+	assert.Equal(t, 0, result.Unchanged)
+	assertFiles(t, dir, false, "posts/1", "page/2", "tags/go/page", "tags/ブログ", "archive/"+archive+"/page")
+	assert.Contains(t, readFile(t, dir, "posts/second/index.html"), "Second updated")
+
+	result, err = builder.Build(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rendered)
+	assert.Equal(t, 0, result.Written)
+}
+
+func TestNewBuilder_InvalidConfig(t *testing.T) {
+	posts := services.NewPostService(repositories.NewMemoryPostRepository())
+
+	_, err := site.NewBuilder(posts, config.SiteConfig{BaseURL: "example.com", PageSize: 10}, t.TempDir())
+	assert.Error(t, err)
+	_, err = site.NewBuilder(posts, config.SiteConfig{BaseURL: "https://example.com", PageSize: 0}, t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestNewBuilder_InvalidTheme(t *testing.T) {
+	posts := services.NewPostService(repositories.NewMemoryPostRepository())
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "post.html"), []byte(`{{define "content"}}{{.Post.Title}`), 0o644))
+
+	_, err := site.NewBuilder(posts, config.SiteConfig{BaseURL: "https://example.com", PageSize: 10, ThemeDir: dir}, t.TempDir())
+	assert.Error(t, err)
+	_, err = site.NewBuilder(posts, config.SiteConfig{BaseURL: "https://example.com", PageSize: 10, ThemeDir: filepath.Join(dir, "missing")}, t.TempDir())
+	assert.Error(t, err)
+}
+
+// 投稿の本文の生の HTML と危険なスキームのリンクは出力しない
+func TestMarkdownToHTML(t *testing.T) {
+	html := string(site.MarkdownToHTML("# Title\n\n<script>alert(1)</script>\n\nText <img src=x onerror=alert(1)> [ok](https://example.com) [rel](/posts/1) [bad](javascript:alert(1)) [bad2](JavaScript:alert(1))"))
+	assert.Contains(t, html, "<h1>Title</h1>")
+	assert.NotContains(t, html, "<script")
+	assert.NotContains(t, html, "<img")
+	assert.NotContains(t, html, `href="javascript:`)
+	assert.NotContains(t, html, `href="JavaScript:`)
+	assert.Contains(t, html, `<a href="https://example.com">ok</a>`)
+	assert.Contains(t, html, `<a href="/posts/1">rel</a>`)
+}
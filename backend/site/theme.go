@@ -0,0 +1,150 @@
+package site
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultTheme は既定のテーマ。テーマのディレクトリも同じ構成にする:
+//
+//	templates/layout.html  全てのページの枠 ({{define "layout"}})
+//	templates/head.html    <head> の中身 ({{define "head"}})。OpenGraph、Twitter カード、JSON-LD など
+//	templates/list.html    投稿の一覧 ({{define "content"}})。トップ、タグ、月別アーカイブで使う
+//	templates/post.html    投稿 ({{define "content"}})
+//	static/                CSS などの静的ファイル。/static/ で配信する
+//
+//go:embed templates static
+var defaultTheme embed.FS
+
+// themePages は layout.html と head.html と組み合わせて使うページのテンプレート
+var themePages = []string{"list.html", "post.html"}
+
+// Theme は html/template のテーマ
+type Theme struct {
+	fsys   fs.FS
+	reload bool
+	pages  map[string]*template.Template
+}
+
+// LoadTheme はテーマを読み込む。dir が空の場合は既定のテーマを使い、dir にないファイルは既定のテーマから読む。
+// reload の場合は描画のたびにテンプレートを読み込み直す (開発中にテーマの変更をすぐに反映する)。
+func LoadTheme(dir string, reload bool) (*Theme, error) {
+	var fsys fs.FS = defaultTheme
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("invalid theme directory: %w", err)
+		}
+		fsys = overlayFS{upper: os.DirFS(dir), lower: defaultTheme}
+	}
+	theme := &Theme{fsys: fsys, reload: reload}
+	// reload の場合も起動時にテンプレートの誤りを検出する
+	pages, err := theme.parse()
+	if err != nil {
+		return nil, err
+	}
+	theme.pages = pages
+	return theme, nil
+}
+
+func (t *Theme) parse() (map[string]*template.Template, error) {
+	funcs := template.FuncMap{
+		"date":    func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+		"isoDate": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+		"summary": summary,
+	}
+	pages := make(map[string]*template.Template, len(themePages))
+	for _, page := range themePages {
+		tmpl, err := template.New(page).Funcs(funcs).ParseFS(t.fsys, "templates/layout.html", "templates/head.html", "templates/"+page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse theme: %w", err)
+		}
+		pages[page] = tmpl
+	}
+	return pages, nil
+}
+
+// Render はページのテンプレートで data を描画する
+func (t *Theme) Render(w io.Writer, page string, data *Page) error {
+	pages := t.pages
+	if t.reload {
+		var err error
+		if pages, err = t.parse(); err != nil {
+			return err
+		}
+	}
+	tmpl, ok := pages[page]
+	if !ok {
+		return fmt.Errorf("unknown theme page %q", page)
+	}
+	return tmpl.ExecuteTemplate(w, "layout", data)
+}
+
+// Static はテーマの静的ファイル
+func (t *Theme) Static() fs.FS {
+	static, err := fs.Sub(t.fsys, "static")
+	if err != nil {
+		panic(err)
+	}
+	return static
+}
+
+// Signature はテーマの全てのファイルのハッシュ。テーマが変わったかの判定に使う
+func (t *Theme) Signature() (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(t.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(t.fsys, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(h, name, len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// overlayFS は upper にあるファイルを優先し、ないファイルは lower から読む
+type overlayFS struct {
+	upper, lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.lower.Open(name)
+	}
+	return f, err
+}
+
+// ReadDir は両方のディレクトリの内容を合わせて返す
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	upper, upperErr := fs.ReadDir(o.upper, name)
+	lower, lowerErr := fs.ReadDir(o.lower, name)
+	if upperErr != nil && lowerErr != nil {
+		return nil, upperErr
+	}
+	entries := slices.Clone(upper)
+	for _, entry := range lower {
+		if !slices.ContainsFunc(upper, func(e fs.DirEntry) bool { return e.Name() == entry.Name() }) {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
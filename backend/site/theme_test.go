@@ -0,0 +1,51 @@
+package site_test
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/site"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, renderer *site.Renderer, posts []models.Post) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, renderer.RenderList(&buf, site.NewPaths(posts), site.TopList(posts), 1))
+	return buf.String()
+}
+
+func TestRenderer_ThemeDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o755))
+	list := filepath.Join(dir, "templates", "list.html")
+	posts := []models.Post{{ID: 1, Title: "First"}}
+
+	for _, reload := range []bool{false, true} {
+		require.NoError(t, os.WriteFile(list, []byte(`{{define "content"}}<p>v1 {{len .Posts}}</p>{{end}}`), 0o644))
+		renderer, err := site.NewRenderer(config.SiteConfig{BaseURL: "https://example.com", Title: "Example", PageSize: 10, ThemeDir: dir, ThemeReload: reload})
+		require.NoError(t, err)
+
+		// テーマにないテンプレートと静的ファイルは既定のテーマを使う
+		page := render(t, renderer, posts)
+		assert.Contains(t, page, "<p>v1 1</p>")
+		assert.Contains(t, page, `<link rel="canonical" href="https://example.com/">`)
+		_, err = fs.Stat(renderer.Static(), "style.css")
+		assert.NoError(t, err)
+
+		// reload の場合だけテンプレートの変更を次の描画に反映する
+		require.NoError(t, os.WriteFile(list, []byte(`{{define "content"}}<p>v2</p>{{end}}`), 0o644))
+		page = render(t, renderer, posts)
+		if reload {
+			assert.Contains(t, page, "<p>v2</p>")
+		} else {
+			assert.Contains(t, page, "<p>v1 1</p>")
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package textstats は Markdown の本文から語数・文字数・読了時間・抜粋を求める
+package textstats
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+const (
+	// wordsPerMinute は英語など空白で区切る言語の 1 分あたりの読める語数
+	wordsPerMinute = 200
+	// cjkCharsPerMinute は日本語など CJK の 1 分あたりの読める文字数
+	cjkCharsPerMinute = 500
+	// ExcerptLength は抜粋の最大文字数 (末尾の … を除く)
+	ExcerptLength = 160
+)
+
+// Stats は本文の統計
+type Stats struct {
+	// Words は語数。CJK の文字は 1 文字を 1 語として数える
+	Words int
+	// Characters は空白を除いた文字数
+	Characters int
+	// ReadingMinutes は読了までの目安の分数。本文がある場合は 1 以上
+	ReadingMinutes int
+	// Excerpt は Markdown の記法を除いた先頭の抜粋
+	Excerpt string
+}
+
+// Compute は Markdown の本文の統計を求める。コードブロックと HTML は数えない
+func Compute(source string) Stats {
+	text := PlainText(source)
+
+	var stats Stats
+	var latinWords, cjkChars int
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case isCJK(r):
+			cjkChars++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				latinWords++
+			}
+			inWord = true
+		default:
+			// 記号は語を区切らない (例: don't, e-mail)
+		}
+		stats.Characters++
+	}
+
+	stats.Words = latinWords + cjkChars
+	if stats.Characters > 0 {
+		minutes := float64(latinWords)/wordsPerMinute + float64(cjkChars)/cjkCharsPerMinute
+		stats.ReadingMinutes = max(1, int(math.Ceil(minutes)))
+	}
+	stats.Excerpt = Excerpt(text, ExcerptLength)
+	return stats
+}
+
+// PlainText は Markdown の記法を除いたテキストを返す。ブロックの間は改行で区切る
+func PlainText(source string) string {
+	doc := markdown.Parse([]byte(source), parser.New())
+
+	var b strings.Builder
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch n := node.(type) {
+		case *ast.CodeBlock, *ast.HTMLBlock, *ast.HTMLSpan:
+			return ast.SkipChildren
+		case *ast.Text:
+			if entering {
+				b.Write(n.Literal)
+			}
+		case *ast.Code:
+			if entering {
+				b.Write(n.Literal)
+			}
+		case *ast.Softbreak, *ast.Hardbreak:
+			b.WriteByte(' ')
+		case *ast.Paragraph, *ast.Heading, *ast.ListItem, *ast.TableCell, *ast.BlockQuote:
+			if !entering && b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+				b.WriteByte('\n')
+			}
+		}
+		return ast.GoToNext
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// Excerpt は空白をまとめたテキストの先頭 limit 文字を返す。
+// 切り詰める場合は語の途中で切らないようにして末尾に … を付ける。
+func Excerpt(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := limit
+	// 空白で区切る言語は直前の空白で切る。CJK は任意の位置で切れる
+	if !isCJK(runes[cut-1]) && !isCJK(runes[cut]) && !unicode.IsSpace(runes[cut]) {
+		if i := lastSpace(runes[:cut]); i > limit/2 {
+			cut = i
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,52 @@
+package textstats_test
+
+import (
+	"blog/textstats"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlainText(t *testing.T) {
+	source := "# Title\n\nSome **bold** and [a link](https://example.com) with `code`.\n\n```go\nfunc main() {}\n```\n\n- one\n- two\n"
+	assert.Equal(t, "Title\nSome bold and a link with code.\none\ntwo", textstats.PlainText(source))
+}
+
+func TestCompute_English(t *testing.T) {
+	stats := textstats.Compute("Hello, world! Don't panic.")
+	assert.Equal(t, 4, stats.Words)
+	assert.Equal(t, 23, stats.Characters)
+	assert.Equal(t, 1, stats.ReadingMinutes)
+	assert.Equal(t, "Hello, world! Don't panic.", stats.Excerpt)
+
+	long := textstats.Compute(strings.Repeat("word ", 450))
+	assert.Equal(t, 450, long.Words)
+	assert.Equal(t, 3, long.ReadingMinutes)
+}
+
+func TestCompute_Japanese(t *testing.T) {
+	stats := textstats.Compute("## 見出し\n\nGoで並行処理を書く。")
+	// "見出し" 3 文字 + "で並行処理を書く" 8 文字 + "Go" 1 語
+	assert.Equal(t, 12, stats.Words)
+	assert.Equal(t, 14, stats.Characters)
+	assert.Equal(t, 1, stats.ReadingMinutes)
+
+	long := textstats.Compute(strings.Repeat("あ", 1200))
+	assert.Equal(t, 3, long.ReadingMinutes)
+}
+
+func TestCompute_Empty(t *testing.T) {
+	stats := textstats.Compute("```\nonly code\n```")
+	assert.Equal(t, textstats.Stats{}, stats)
+}
+
+func TestExcerpt(t *testing.T) {
+	assert.Equal(t, "short text", textstats.Excerpt("short \n text", 20))
+	assert.Equal(t, "The quick brown…", textstats.Excerpt("The quick brown fox jumps", 18))
+	assert.Equal(t, "吾輩は猫である…", textstats.Excerpt("吾輩は猫である。名前はまだ無い。", 7))
+
+	excerpt := textstats.Excerpt(strings.Repeat("あ", 500), textstats.ExcerptLength)
+	assert.Equal(t, textstats.ExcerptLength+1, utf8.RuneCountInString(excerpt))
+}
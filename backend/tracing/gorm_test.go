@@ -0,0 +1,20 @@
+package tracing_test
+
+import (
+	"blog/tracing"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	cases := map[string]string{
+		`SELECT * FROM "posts" WHERE "posts"."id" = $1 LIMIT $2`: `SELECT * FROM "posts" WHERE "posts"."id" = $1 LIMIT $2`,
+		`SELECT * FROM posts WHERE title = 'secret' AND id = 42`: `SELECT * FROM posts WHERE title = ? AND id = ?`,
+		`UPDATE posts SET content = 'it''s here', score = 1.5`:   `UPDATE posts SET content = ?, score = ?`,
+		`SELECT t1.id FROM posts t1`:                             `SELECT t1.id FROM posts t1`,
+	}
+	for input, want := range cases {
+		assert.Equal(t, want, tracing.SanitizeSQL(input))
+	}
+}
@@ -0,0 +1,16 @@
+package views
+
+import (
+	"regexp"
+	"strings"
+)
+
+// botPattern はクローラー・リンクプレビュー・監視ツール・HTTP ライブラリの User-Agent に含まれる語
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|facebookexternalhit|embedly|preview|` +
+	`curl|wget|python-requests|python-urllib|go-http-client|okhttp|axios|headless|lighthouse|pingdom|uptime|monitor`)
+
+// IsBot は User-Agent が人間のブラウザ以外によるものかを判定する。User-Agent がない場合もボットとみなす
+func IsBot(userAgent string) bool {
+	userAgent = strings.TrimSpace(userAgent)
+	return userAgent == "" || botPattern.MatchString(userAgent)
+}
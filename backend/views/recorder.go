@@ -0,0 +1,178 @@
+// Package views は投稿の閲覧を数え、日別の集計としてデータベースに保存する
+package views
+
+import (
+	"blog/logging"
+	"blog/metrics"
+	"blog/models"
+	"blog/repositories"
+	"container/list"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// dayLayout は集計する日付の形式 (UTC)
+const dayLayout = "2006-01-02"
+
+// Day は t の UTC の日付を集計用の形式で返す
+func Day(t time.Time) string {
+	return t.UTC().Format(dayLayout)
+}
+
+type viewKey struct {
+	postID uint
+	day    string
+}
+
+// Recorder は投稿の閲覧をメモリ上で数え、定期的にまとめて PostViewRepository に加算する。
+//
+// 同じ日に同じ訪問者が同じ投稿を閲覧した場合は 1 回として数える。訪問者は IP アドレスと User-Agent を
+// プロセスごとのランダムなソルトでハッシュした値で識別し、生の値は保持も保存もしない。
+// 重複の判定はプロセス内で行うため、複数台で動かした場合や再起動をまたいだ場合は重複して数えることがある。
+// 覚えておく訪問者は maxVisitors までで、超えた場合は最も長く閲覧のない訪問者から忘れる (忘れた訪問者の再閲覧は数える)。
+type Recorder struct {
+	repo          repositories.PostViewRepository
+	flushInterval time.Duration
+	// maxBuffered を超える (投稿, 日) の組がたまったら定期実行を待たずに書き込む
+	maxBuffered int
+	// maxVisitors は重複の判定のために覚えておく (訪問者, 投稿) の組の数。0 以下の場合は上限なし
+	maxVisitors int
+	salt        [32]byte
+
+	mu  sync.Mutex
+	day string
+	// seen は今日の訪問者から recent の要素への索引。recent は最近閲覧した順に並ぶ
+	seen   map[[sha256.Size]byte]*list.Element
+	recent *list.List
+	counts map[viewKey]int64
+	full   chan struct{}
+}
+
+func NewRecorder(repo repositories.PostViewRepository, flushInterval time.Duration, maxBuffered, maxVisitors int) *Recorder {
+	r := &Recorder{
+		repo:          repo,
+		flushInterval: flushInterval,
+		maxBuffered:   maxBuffered,
+		maxVisitors:   maxVisitors,
+		seen:          make(map[[sha256.Size]byte]*list.Element),
+		recent:        list.New(),
+		counts:        make(map[viewKey]int64),
+		full:          make(chan struct{}, 1),
+	}
+	if _, err := rand.Read(r.salt[:]); err != nil {
+		panic("views: failed to generate salt: " + err.Error())
+	}
+	return r
+}
+
+// Record は投稿の閲覧を記録する。ボットによる閲覧と、同じ日の同じ訪問者による再閲覧は数えない
+func (r *Recorder) Record(postID uint, clientIP, userAgent string) {
+	if IsBot(userAgent) {
+		metrics.PostViewsTotal.WithLabelValues("bot").Inc()
+		return
+	}
+
+	day := Day(time.Now())
+	visitor := r.visitorKey(postID, day, clientIP, userAgent)
+
+	r.mu.Lock()
+	if day != r.day {
+		// 日付が変わったら前日の訪問者の記録は不要になる
+		r.day = day
+		clear(r.seen)
+		r.recent.Init()
+	}
+	if elem, ok := r.seen[visitor]; ok {
+		r.recent.MoveToFront(elem)
+		r.mu.Unlock()
+		metrics.PostViewsTotal.WithLabelValues("duplicate").Inc()
+		return
+	}
+	r.seen[visitor] = r.recent.PushFront(visitor)
+	evicted := r.maxVisitors > 0 && r.recent.Len() > r.maxVisitors
+	if evicted {
+		oldest := r.recent.Remove(r.recent.Back()).([sha256.Size]byte)
+		delete(r.seen, oldest)
+	}
+	r.counts[viewKey{postID: postID, day: day}]++
+	full := len(r.counts) >= r.maxBuffered
+	r.mu.Unlock()
+
+	metrics.PostViewsTotal.WithLabelValues("counted").Inc()
+	if evicted {
+		metrics.PostViewVisitorsEvictedTotal.Inc()
+	}
+	if full {
+		select {
+		case r.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// visitorKey は訪問者・投稿・日付の組をソルト付きでハッシュする
+func (r *Recorder) visitorKey(postID uint, day, clientIP, userAgent string) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(r.salt[:])
+	h.Write([]byte(day))
+	binary.Write(h, binary.BigEndian, uint64(postID))
+	h.Write([]byte{0})
+	h.Write([]byte(clientIP))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// Run は ctx がキャンセルされるまで定期的に閲覧数を書き込む。停止時に残りを書き込む
+func (r *Recorder) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 停止時はキャンセルされていないコンテキストで最後の書き込みを行う
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			return r.Flush(flushCtx)
+		case <-ticker.C:
+		case <-r.full:
+		}
+		if err := r.Flush(ctx); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to flush post views", "error", err)
+		}
+	}
+}
+
+// Flush はたまった閲覧数を書き込む。失敗した場合は次回の書き込みに持ち越す
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	counts := r.counts
+	r.counts = make(map[viewKey]int64)
+	r.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+	views := make([]models.PostView, 0, len(counts))
+	for key, n := range counts {
+		views = append(views, models.PostView{PostID: key.postID, Day: key.day, Views: n})
+	}
+
+	if err := r.repo.Increment(ctx, views); err != nil {
+		r.mu.Lock()
+		for key, n := range counts {
+			r.counts[key] += n
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package views_test
+
+import (
+	"blog/metrics"
+	"blog/models"
+	"blog/views"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const browserUA = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Safari/605.1.15"
+
+type fakeViewRepository struct {
+	mu    sync.Mutex
+	views []models.PostView
+	err   error
+}
+
+func (r *fakeViewRepository) Increment(_ context.Context, views []models.PostView) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.views = append(r.views, views...)
+	return nil
+}
+
+func (r *fakeViewRepository) FindPopular(context.Context, string, int) ([]models.PopularPost, error) {
+	return nil, nil
+}
+
+func (r *fakeViewRepository) FindByPostID(context.Context, uint, string) ([]models.PostView, error) {
+	return nil, nil
+}
+
+func (r *fakeViewRepository) total(postID uint) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	for _, v := range r.views {
+		if v.PostID == postID {
+			total += v.Views
+		}
+	}
+	return total
+}
+
+func TestIsBot(t *testing.T) {
+	assert.False(t, views.IsBot(browserUA))
+	assert.True(t, views.IsBot(""))
+	assert.True(t, views.IsBot("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"))
+	assert.True(t, views.IsBot("facebookexternalhit/1.1"))
+	assert.True(t, views.IsBot("curl/8.4.0"))
+	assert.True(t, views.IsBot("Go-http-client/1.1"))
+}
+
+func TestRecorder_DedupAndBots(t *testing.T) {
+	repo := &fakeViewRepository{}
+	recorder := views.NewRecorder(repo, time.Hour, 1000, 1000)
+
+	recorder.Record(1, "192.0.2.1", browserUA)
+	recorder.Record(1, "192.0.2.1", browserUA) // 同じ訪問者
+	recorder.Record(1, "192.0.2.2", browserUA)
+	recorder.Record(1, "192.0.2.1", "Mozilla/5.0 (X11; Linux x86_64) Firefox/131.0")
+	recorder.Record(2, "192.0.2.1", browserUA)
+	recorder.Record(1, "192.0.2.3", "Googlebot/2.1")
+
+	require.NoError(t, recorder.Flush(context.Background()))
+	assert.Equal(t, int64(3), repo.total(1))
+	assert.Equal(t, int64(1), repo.total(2))
+	for _, v := range repo.views {
+		assert.Equal(t, views.Day(time.Now()), v.Day)
+	}
+
+	// 書き込み済みの分は再度書き込まない
+	repo.views = nil
+	require.NoError(t, recorder.Flush(context.Background()))
+	assert.Empty(t, repo.views)
+}
+
+func TestRecorder_ForgetsLeastRecentVisitors(t *testing.T) {
+	repo := &fakeViewRepository{}
+	recorder := views.NewRecorder(repo, time.Hour, 1000, 2)
+	before := testutil.ToFloat64(metrics.PostViewVisitorsEvictedTotal)
+
+	recorder.Record(1, "192.0.2.1", browserUA)
+	recorder.Record(1, "192.0.2.2", browserUA)
+	recorder.Record(1, "192.0.2.1", browserUA) // 重複。最近の訪問者になる
+	recorder.Record(1, "192.0.2.3", browserUA) // 192.0.2.2 を忘れる
+	recorder.Record(1, "192.0.2.1", browserUA) // 覚えているので重複
+	recorder.Record(1, "192.0.2.2", browserUA) // 忘れたため数える
+
+	require.NoError(t, recorder.Flush(context.Background()))
+	assert.Equal(t, int64(4), repo.total(1))
+	assert.Equal(t, before+2, testutil.ToFloat64(metrics.PostViewVisitorsEvictedTotal))
+}
+
+func TestRecorder_KeepsCountsOnFailure(t *testing.T) {
+	repo := &fakeViewRepository{err: errors.New("database is down")}
+	recorder := views.NewRecorder(repo, time.Hour, 1000, 1000)
+	recorder.Record(1, "192.0.2.1", browserUA)
+
+	assert.Error(t, recorder.Flush(context.Background()))
+
+	repo.err = nil
+	recorder.Record(1, "192.0.2.2", browserUA)
+	require.NoError(t, recorder.Flush(context.Background()))
+	assert.Equal(t, int64(2), repo.total(1))
+}
+
+func TestRecorder_FlushesWhenBufferIsFull(t *testing.T) {
+	repo := &fakeViewRepository{}
+	recorder := views.NewRecorder(repo, time.Hour, 2, 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- recorder.Run(ctx) }()
+
+	recorder.Record(1, "192.0.2.1", browserUA)
+	recorder.Record(2, "192.0.2.1", browserUA)
+	assert.Eventually(t, func() bool {
+		return repo.total(1) == 1 && repo.total(2) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	recorder.Record(3, "192.0.2.1", browserUA)
+	cancel()
+	require.NoError(t, <-done)
+
+	// 停止時に残りも書き込まれる
+	assert.Equal(t, int64(1), repo.total(1))
+	assert.Equal(t, int64(1), repo.total(2))
+	assert.Equal(t, int64(1), repo.total(3))
+}
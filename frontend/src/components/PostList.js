@@ -6,7 +6,7 @@ const PostList = () => {
   const [posts, setPosts] = useState([]);
 
   useEffect(() => {
-    fetch(`${process.env.REACT_APP_URL_DOMAIN}/api/posts`)
+    fetch(`${process.env.REACT_APP_URL_DOMAIN}/api/v1/posts`)
       .then((response) => response.json())
       .then((data) => setPosts(data))
       .catch((error) => console.error("Error fetching posts:", error));
@@ -31,9 +31,7 @@ const PostList = () => {
                       {post.Title}
                     </Link>
                   </h2>
-                  <p className="text-gray-700 mb-4">
-                    {post.Content.slice(0, 100)}...
-                  </p>
+                  <p className="text-gray-700 mb-4">{post.Excerpt}</p>
                   <div className="flex justify-between">
                     <Link
                       to={`/posts/${post.ID}`}